@@ -2,15 +2,19 @@ package auth
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 type Client struct {
@@ -18,12 +22,25 @@ type Client struct {
 	Compute  *gophercloud.ServiceClient
 	Provider *gophercloud.ProviderClient
 	Image    *gophercloud.ServiceClient // Added for image client
+	Network  *gophercloud.ServiceClient
+	// Availability is the endpoint interface (public, internal or admin) of every service client
+	Availability gophercloud.Availability
 }
 
 type Config struct {
-	Region  string
-	Timeout time.Duration
-	Verbose bool
+	Region   string
+	Timeout  time.Duration
+	Verbose  bool
+	Insecure bool // Skip TLS certificate verification for OpenStack endpoints (does not affect SSH)
+	// RequestTimeout limits each HTTP request to OpenStack, independently of the command's
+	// context deadline; 0 leaves requests limited by the context only
+	RequestTimeout time.Duration
+	// Interface selects public, internal or admin endpoints from the catalog; empty falls back
+	// to OS_INTERFACE and then to public
+	Interface string
+	// Retries is how often a read request is repeated after a 5xx response or a timeout;
+	// 0 disables retries
+	Retries int
 }
 
 const DefaultTimeout = 120 * time.Second
@@ -31,14 +48,9 @@ const DefaultTimeout = 120 * time.Second
 var log = logrus.New()
 
 func NewClient(ctx context.Context, cfg Config) (*Client, error) {
-	log.SetOutput(os.Stdout)
-	if cfg.Verbose {
-		log.SetLevel(logrus.DebugLevel)
-	} else {
-		log.SetLevel(logrus.InfoLevel)
-	}
+	util.SetupLogger(log, cfg.Verbose)
 
-	log.Debugf("Initializing new OpenStack client with config: Region=%s, Timeout=%v, Verbose=%v", cfg.Region, cfg.Timeout, cfg.Verbose)
+	log.Debugf("Initializing new OpenStack client with config: Region=%s, Timeout=%v, RequestTimeout=%v, Verbose=%v, Insecure=%v", cfg.Region, cfg.Timeout, cfg.RequestTimeout, cfg.Verbose, cfg.Insecure)
 	if cfg.Region == "" {
 		cfg.Region = os.Getenv("OS_REGION_NAME")
 		if cfg.Region == "" {
@@ -47,6 +59,18 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		}
 	}
 
+	if cfg.RequestTimeout < 0 {
+		return nil, fmt.Errorf("invalid request timeout %v: must not be negative", cfg.RequestTimeout)
+	}
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("invalid retry count %d: must not be negative", cfg.Retries)
+	}
+	availability, err := ParseInterface(cfg.Interface)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Using %s endpoints", availability)
+
 	if cfg.Timeout == 0 {
 		if timeoutStr := os.Getenv("OS_TIMEOUT_SECONDS"); timeoutStr != "" {
 			if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout > 0 {
@@ -60,38 +84,39 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		}
 	}
 
-	requiredEnv := []string{"OS_AUTH_URL", "OS_USERNAME", "OS_PASSWORD", "OS_PROJECT_NAME", "OS_DOMAIN_NAME"}
-	for _, env := range requiredEnv {
-		if os.Getenv(env) == "" {
-			log.Debugf("Checking environment variable: %s", env)
-			return nil, fmt.Errorf("missing required environment variable: %s", env)
-		}
-	}
-
 	log.Debug("Loading authentication options from environment")
-	ao, err := openstack.AuthOptionsFromEnv()
+	ao, err := AuthOptionsFromEnv()
 	if err != nil {
 		log.Debugf("Failed to load auth options: %v", err)
-		return nil, errors.Wrap(err, "failed to load auth options from environment")
+		return nil, err
 	}
 	log.Debugf("Auth options loaded: IdentityEndpoint=%s, DomainName=%s, DomainID=%s", ao.IdentityEndpoint, ao.DomainName, ao.DomainID)
+	// Long-running commands such as vm info --watch outlive the token
+	ao.AllowReauth = true
 
 	log.Debug("Attempting client authentication")
-	provider, err := openstack.AuthenticatedClient(ctx, ao)
+	provider, err := openstack.NewClient(ao.IdentityEndpoint)
 	if err != nil {
+		log.Debugf("Failed to create provider client: %v", err)
+		return nil, errors.Wrap(err, "failed to create provider client")
+	}
+	ConfigureTLS(provider, cfg.Insecure)
+	ConfigureRequestTimeout(provider, cfg.RequestTimeout)
+	ConfigureRetries(provider, cfg.Retries)
+	if err := openstack.Authenticate(ctx, provider, ao); err != nil {
 		log.Debugf("Authentication failed: %v", err)
 		return nil, errors.Wrap(err, "authentication failed")
 	}
 	log.Debug("Authentication successful")
 
 	log.Debug("Creating Identity V3 client")
-	identity, err := openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{Region: cfg.Region})
+	identity, err := openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{Region: cfg.Region, Availability: availability})
 	if err != nil {
 		log.Debugf("Failed to create Identity V3 client: %v", err)
 		return nil, errors.Wrap(err, "failed to create Identity V3 client")
 	}
 	log.Debug("Creating Compute V2 client")
-	compute, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: cfg.Region})
+	compute, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: cfg.Region, Availability: availability})
 	if err != nil {
 		log.Debugf("Failed to create Compute V2 client: %v", err)
 		return nil, errors.Wrap(err, "failed to create Compute V2 client")
@@ -99,16 +124,120 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 	log.Debug("OpenStack clients initialized successfully")
 
 	return &Client{
-		Identity: identity,
-		Compute:  compute,
-		Provider: provider,
+		Identity:     identity,
+		Compute:      compute,
+		Provider:     provider,
+		Availability: availability,
+	}, nil
+}
+
+// ParseInterface maps an endpoint interface name to gophercloud's Availability. An empty name
+// falls back to OS_INTERFACE and then to public; the publicURL forms of old openrc files are
+// accepted too.
+func ParseInterface(name string) (gophercloud.Availability, error) {
+	if name == "" {
+		name = os.Getenv("OS_INTERFACE")
+	}
+	switch strings.TrimSuffix(strings.ToLower(name), "url") {
+	case "", "public":
+		return gophercloud.AvailabilityPublic, nil
+	case "internal":
+		return gophercloud.AvailabilityInternal, nil
+	case "admin":
+		return gophercloud.AvailabilityAdmin, nil
+	default:
+		return "", fmt.Errorf("invalid interface '%s'; valid: public, internal, admin", name)
+	}
+}
+
+// AuthOptionsFromEnv loads authentication options from the OS_* environment variables.
+// The domain can be given either as OS_DOMAIN_NAME or split into OS_USER_DOMAIN_NAME
+// and OS_PROJECT_DOMAIN_NAME, as written by most openrc files.
+func AuthOptionsFromEnv() (gophercloud.AuthOptions, error) {
+	requiredEnv := []string{"OS_AUTH_URL", "OS_USERNAME", "OS_PASSWORD", "OS_PROJECT_NAME"}
+	for _, env := range requiredEnv {
+		log.Debugf("Checking environment variable: %s", env)
+		if os.Getenv(env) == "" {
+			return gophercloud.AuthOptions{}, fmt.Errorf("missing required environment variable: %s", env)
+		}
+	}
+
+	if os.Getenv("OS_DOMAIN_NAME") != "" {
+		ao, err := openstack.AuthOptionsFromEnv()
+		if err != nil {
+			return gophercloud.AuthOptions{}, errors.Wrap(err, "failed to load auth options from environment")
+		}
+		return ao, nil
+	}
+
+	// gophercloud only reads OS_DOMAIN_NAME, so the split variables are mapped here:
+	// the user domain authenticates the user and the project domain scopes the token
+	userDomain := os.Getenv("OS_USER_DOMAIN_NAME")
+	projectDomain := os.Getenv("OS_PROJECT_DOMAIN_NAME")
+	if userDomain == "" || projectDomain == "" {
+		return gophercloud.AuthOptions{}, fmt.Errorf("missing required environment variable: OS_DOMAIN_NAME (or both OS_USER_DOMAIN_NAME and OS_PROJECT_DOMAIN_NAME)")
+	}
+	log.Debugf("Using split domains: OS_USER_DOMAIN_NAME=%s, OS_PROJECT_DOMAIN_NAME=%s", userDomain, projectDomain)
+	projectName := os.Getenv("OS_PROJECT_NAME")
+	return gophercloud.AuthOptions{
+		IdentityEndpoint: os.Getenv("OS_AUTH_URL"),
+		Username:         os.Getenv("OS_USERNAME"),
+		Password:         os.Getenv("OS_PASSWORD"),
+		DomainName:       userDomain,
+		TenantName:       projectName,
+		Scope: &gophercloud.AuthScope{
+			ProjectName: projectName,
+			DomainName:  projectDomain,
+		},
 	}, nil
 }
 
+// ConfigureTLS disables TLS certificate verification on the provider's HTTP client when insecure is set
+func ConfigureTLS(provider *gophercloud.ProviderClient, insecure bool) {
+	if !insecure {
+		return
+	}
+	log.Warn("TLS certificate verification is disabled for OpenStack endpoints")
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	provider.HTTPClient.Transport = transport
+}
+
+// ConfigureRequestTimeout limits every HTTP request of the provider to timeout, when it is positive
+func ConfigureRequestTimeout(provider *gophercloud.ProviderClient, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	log.Debugf("Limiting each OpenStack API request to %v", timeout)
+	provider.HTTPClient.Timeout = timeout
+}
+
+// ConfigureRetries makes every GET and HEAD request of the provider's clients repeat up to
+// retries times after a 5xx response or a timeout, waiting one second longer before each
+// retry. Other methods are never repeated, as they may have taken effect. Waits end with ctx,
+// so retries stay within the command's --timeout.
+func ConfigureRetries(provider *gophercloud.ProviderClient, retries int) {
+	if retries <= 0 {
+		return
+	}
+	log.Debugf("Retrying failed OpenStack read requests up to %d times", retries)
+	provider.RetryFunc = func(ctx context.Context, method, url string, _ *gophercloud.RequestOpts, err error, failCount uint) error {
+		if (method != http.MethodGet && method != http.MethodHead) || int(failCount) > retries || !util.IsTransient(err) {
+			return err
+		}
+		log.Warnf("Transient failure of %s %s (attempt %d/%d), retrying: %v", method, url, failCount, retries+1, err)
+		if sleepErr := util.Sleep(ctx, time.Duration(failCount)*time.Second); sleepErr != nil {
+			return err
+		}
+		return nil
+	}
+}
+
 func NewBlockStorageV3Client(client *Client) (*gophercloud.ServiceClient, error) {
 	log.Debug("Initializing Block Storage V3 client")
 	volumeClient, err := openstack.NewBlockStorageV3(client.Provider, gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: client.Availability,
 	})
 	if err != nil {
 		log.Debugf("Failed to create block storage v3 client: %v", err)
@@ -126,7 +255,8 @@ func NewComputeV2Client(client *Client) (*gophercloud.ServiceClient, error) {
 	}
 	log.Debug("Creating new Compute V2 client")
 	compute, err := openstack.NewComputeV2(client.Provider, gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: client.Availability,
 	})
 	if err != nil {
 		log.Debugf("Failed to create compute v2 client: %v", err)
@@ -145,7 +275,8 @@ func NewImageV2(client *Client) (*gophercloud.ServiceClient, error) {
 	}
 	log.Debug("Creating new Image V2 client")
 	image, err := openstack.NewImageV2(client.Provider, gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: client.Availability,
 	})
 	if err != nil {
 		log.Debugf("Failed to create image v2 client: %v", err)
@@ -155,3 +286,23 @@ func NewImageV2(client *Client) (*gophercloud.ServiceClient, error) {
 	log.Debug("Image V2 client initialized successfully")
 	return image, nil
 }
+
+func NewNetworkV2(client *Client) (*gophercloud.ServiceClient, error) {
+	log.Debug("Checking or initializing Network V2 client")
+	if client.Network != nil {
+		log.Debug("Returning existing Network V2 client")
+		return client.Network, nil
+	}
+	log.Debug("Creating new Network V2 client")
+	network, err := openstack.NewNetworkV2(client.Provider, gophercloud.EndpointOpts{
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: client.Availability,
+	})
+	if err != nil {
+		log.Debugf("Failed to create network v2 client: %v", err)
+		return nil, errors.Wrap(err, "failed to create network v2 client")
+	}
+	client.Network = network
+	log.Debug("Network V2 client initialized successfully")
+	return network, nil
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// authEnv are the OS_* variables AuthOptionsFromEnv and gophercloud read; each test case
+// starts with all of them empty
+var authEnv = []string{
+	"OS_AUTH_URL", "OS_USERNAME", "OS_USERID", "OS_PASSWORD", "OS_PASSCODE",
+	"OS_PROJECT_NAME", "OS_PROJECT_ID", "OS_TENANT_NAME", "OS_TENANT_ID",
+	"OS_DOMAIN_NAME", "OS_DOMAIN_ID", "OS_USER_DOMAIN_NAME", "OS_PROJECT_DOMAIN_NAME", "OS_SYSTEM_SCOPE",
+	"OS_APPLICATION_CREDENTIAL_ID", "OS_APPLICATION_CREDENTIAL_NAME", "OS_APPLICATION_CREDENTIAL_SECRET",
+}
+
+func TestAuthOptionsFromEnv(t *testing.T) {
+	base := map[string]string{
+		"OS_AUTH_URL":     "https://keystone.example.com:5000/v3",
+		"OS_USERNAME":     "alice",
+		"OS_PASSWORD":     "secret",
+		"OS_PROJECT_NAME": "demo",
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    gophercloud.AuthOptions
+		wantErr string
+	}{
+		{
+			name: "split user and project domains",
+			env:  map[string]string{"OS_USER_DOMAIN_NAME": "users", "OS_PROJECT_DOMAIN_NAME": "projects"},
+			want: gophercloud.AuthOptions{
+				IdentityEndpoint: "https://keystone.example.com:5000/v3",
+				Username:         "alice",
+				Password:         "secret",
+				DomainName:       "users",
+				TenantName:       "demo",
+				Scope:            &gophercloud.AuthScope{ProjectName: "demo", DomainName: "projects"},
+			},
+		},
+		{
+			name: "OS_DOMAIN_NAME fallback",
+			env:  map[string]string{"OS_DOMAIN_NAME": "Default"},
+			want: gophercloud.AuthOptions{
+				IdentityEndpoint: "https://keystone.example.com:5000/v3",
+				Username:         "alice",
+				Password:         "secret",
+				DomainName:       "Default",
+				TenantName:       "demo",
+			},
+		},
+		{
+			name: "OS_DOMAIN_NAME wins over split domains",
+			env:  map[string]string{"OS_DOMAIN_NAME": "Default", "OS_USER_DOMAIN_NAME": "users", "OS_PROJECT_DOMAIN_NAME": "projects"},
+			want: gophercloud.AuthOptions{
+				IdentityEndpoint: "https://keystone.example.com:5000/v3",
+				Username:         "alice",
+				Password:         "secret",
+				DomainName:       "Default",
+				TenantName:       "demo",
+			},
+		},
+		{
+			name:    "only user domain",
+			env:     map[string]string{"OS_USER_DOMAIN_NAME": "users"},
+			wantErr: "missing required environment variable: OS_DOMAIN_NAME (or both OS_USER_DOMAIN_NAME and OS_PROJECT_DOMAIN_NAME)",
+		},
+		{
+			name:    "only project domain",
+			env:     map[string]string{"OS_PROJECT_DOMAIN_NAME": "projects"},
+			wantErr: "missing required environment variable: OS_DOMAIN_NAME (or both OS_USER_DOMAIN_NAME and OS_PROJECT_DOMAIN_NAME)",
+		},
+		{
+			name:    "no domain",
+			env:     map[string]string{},
+			wantErr: "missing required environment variable: OS_DOMAIN_NAME (or both OS_USER_DOMAIN_NAME and OS_PROJECT_DOMAIN_NAME)",
+		},
+		{
+			name:    "missing password",
+			env:     map[string]string{"OS_DOMAIN_NAME": "Default", "OS_PASSWORD": ""},
+			wantErr: "missing required environment variable: OS_PASSWORD",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range authEnv {
+				t.Setenv(name, "")
+			}
+			for name, value := range base {
+				t.Setenv(name, value)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			got, err := AuthOptionsFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthOptionsFromEnv: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v (scope %+v), want %+v (scope %+v)", got, got.Scope, tt.want, tt.want.Scope)
+			}
+		})
+	}
+}
+
+// TestLogsStayOffJSONOutput emits the --insecure warning and a --verbose message the way a
+// command does before printing JSON, and checks that stdout holds only the JSON
+func TestLogsStayOffJSONOutput(t *testing.T) {
+	t.Cleanup(func() { util.SetupLogger(log, false) })
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verbose=%v", verbose), func(t *testing.T) {
+			stdout, stderr := testutil.CaptureOutput(t, func() {
+				util.SetupLogger(log, verbose)
+				provider := &gophercloud.ProviderClient{}
+				ConfigureTLS(provider, true)
+				ConfigureRequestTimeout(provider, time.Minute)
+				if err := output.Print("json", &output.Result{Data: []string{"web-1"}}); err != nil {
+					t.Errorf("Print: %v", err)
+				}
+			})
+			if want := "[\n  \"web-1\"\n]\n"; stdout != want {
+				t.Errorf("got stdout %q, want only the JSON %q", stdout, want)
+			}
+			if !strings.Contains(stderr, "TLS certificate verification is disabled") {
+				t.Errorf("the --insecure warning is missing from stderr %q", stderr)
+			}
+			if got := strings.Contains(stderr, "Limiting each OpenStack API request"); got != verbose {
+				t.Errorf("got the debug message on stderr: %v, want %v", got, verbose)
+			}
+		})
+	}
+}
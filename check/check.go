@@ -0,0 +1,191 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// Logger for structured logging
+var log = logrus.New()
+
+// Config holds configuration parameters for the check subcommand
+type Config struct {
+	Verbose      bool
+	OutputFormat string
+	Services     []string // Services to probe, from Services; empty for all
+	Timeout      time.Duration
+}
+
+// ServiceCheck is the outcome of probing one service endpoint
+type ServiceCheck struct {
+	Service      string `json:"service"`
+	Status       string `json:"status"` // OK or FAIL
+	LatencyMs    int64  `json:"latency_ms"`
+	Microversion string `json:"microversion,omitempty"` // Maximum supported microversion, where the service has them
+	Endpoint     string `json:"endpoint"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ErrServiceFailed is returned when at least one probed service failed its check
+var ErrServiceFailed = errors.New("service check failed")
+
+// probe is a cheap authenticated request against one service
+type probe struct {
+	newClient    func(client *auth.Client) (*gophercloud.ServiceClient, error)
+	list         func(ctx context.Context, sc *gophercloud.ServiceClient) error
+	microversion bool // Report the maximum microversion from the endpoint's version document
+}
+
+// Services lists the service names accepted by --services, in the order they are probed
+var Services = []string{"identity", "compute", "volume", "image", "network"}
+
+var probes = map[string]probe{
+	"identity": {
+		newClient: func(client *auth.Client) (*gophercloud.ServiceClient, error) { return client.Identity, nil },
+		list: func(ctx context.Context, sc *gophercloud.ServiceClient) error {
+			return firstPage(ctx, projects.ListAvailable(sc))
+		},
+	},
+	"compute": {
+		newClient: auth.NewComputeV2Client,
+		list: func(ctx context.Context, sc *gophercloud.ServiceClient) error {
+			return firstPage(ctx, servers.List(sc, servers.ListOpts{Limit: 1}))
+		},
+		microversion: true,
+	},
+	"volume": {
+		newClient: auth.NewBlockStorageV3Client,
+		list: func(ctx context.Context, sc *gophercloud.ServiceClient) error {
+			return firstPage(ctx, volumes.List(sc, volumes.ListOpts{Limit: 1}))
+		},
+		microversion: true,
+	},
+	"image": {
+		newClient: auth.NewImageV2,
+		list: func(ctx context.Context, sc *gophercloud.ServiceClient) error {
+			return firstPage(ctx, images.List(sc, images.ListOpts{Limit: 1}))
+		},
+	},
+	"network": {
+		newClient: func(client *auth.Client) (*gophercloud.ServiceClient, error) {
+			return openstack.NewNetworkV2(client.Provider, gophercloud.EndpointOpts{Region: os.Getenv("OS_REGION_NAME"), Availability: client.Availability})
+		},
+		list: func(ctx context.Context, sc *gophercloud.ServiceClient) error {
+			return firstPage(ctx, networks.List(sc, networks.ListOpts{Limit: 1}))
+		},
+	},
+}
+
+// ParseServices splits a comma-separated --services value, rejecting unknown names
+func ParseServices(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var selected []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := probes[name]; !ok {
+			return nil, fmt.Errorf("unknown service '%s'; valid: %s", name, strings.Join(Services, ", "))
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// Run probes the selected services with the already authenticated client and prints one line
+// per service. It returns ErrServiceFailed when any of them failed.
+func Run(ctx context.Context, client *auth.Client, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+	log.Debugf("Starting endpoint check with config: %+v", cfg)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	selected := cfg.Services
+	if len(selected) == 0 {
+		selected = Services
+	}
+
+	results := make([]ServiceCheck, 0, len(selected))
+	failed := 0
+	for _, name := range selected {
+		result := checkService(ctx, client, name, probes[name])
+		if result.Status != "OK" {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	out := &output.Result{
+		Headers: []string{"Service", "Status", "Latency", "Microversion", "Endpoint", "Error"},
+		Data:    results,
+	}
+	for _, r := range results {
+		out.AddRow(r.Service, r.Status, fmt.Sprintf("%dms", r.LatencyMs), r.Microversion, r.Endpoint, r.Error)
+	}
+	if err := output.Print(cfg.OutputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print check results")
+	}
+	if failed > 0 {
+		return errors.Wrapf(ErrServiceFailed, "%d of %d services failed", failed, len(results))
+	}
+	return nil
+}
+
+// checkService creates the service client from the catalog and times one list request
+func checkService(ctx context.Context, client *auth.Client, name string, p probe) ServiceCheck {
+	result := ServiceCheck{Service: name, Status: "FAIL"}
+	sc, err := p.newClient(client)
+	if err != nil {
+		log.Debugf("No %s endpoint: %v", name, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Endpoint = sc.Endpoint
+
+	start := time.Now()
+	err = p.list(ctx, sc)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		log.Debugf("Probe of %s failed after %dms: %v", name, result.LatencyMs, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "OK"
+
+	if p.microversion {
+		mv, err := utils.GetSupportedMicroversions(ctx, sc)
+		if err != nil {
+			log.Debugf("Microversions of %s not available: %v", name, err)
+		} else {
+			result.Microversion = fmt.Sprintf("%d.%d", mv.MaxMajor, mv.MaxMinor)
+		}
+	}
+	log.Debugf("Probe of %s succeeded in %dms", name, result.LatencyMs)
+	return result
+}
+
+// firstPage requests only the first page of pager
+func firstPage(ctx context.Context, pager pagination.Pager) error {
+	return pager.EachPage(ctx, func(context.Context, pagination.Page) (bool, error) {
+		return false, nil
+	})
+}
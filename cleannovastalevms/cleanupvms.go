@@ -2,11 +2,13 @@ package cleannovastalevms
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
@@ -14,6 +16,7 @@ import (
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
 	"github.com/sirupsen/logrus"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
 	"github.com/sudeeshjohn/openstack-tool/util"
 	"golang.org/x/crypto/ssh"
 )
@@ -21,27 +24,83 @@ import (
 // Logger for structured logging
 var log = logrus.New()
 
-// InstanceInfo holds the instance name, tenant name, and status for a VM.
+// InstanceInfo holds the instance name, tenant name, and status for a VM. Missing VMs also
+// carry the memory and creation time pvmctl reported, when it did.
 type InstanceInfo struct {
 	InstanceName string
 	TenantName   string
 	Status       string
+	MemoryMB     int        `json:",omitempty"`
+	Created      *time.Time `json:",omitempty"`
 }
 
-// VM represents a virtual machine with Name and Status fields
+// VM represents a virtual machine on the hypervisor as listed by pvmctl. MemoryMB and Created
+// are unset when the host's pvmctl does not report them.
 type VM struct {
-	Name   string
-	Status string
+	Name     string
+	Status   string
+	MemoryMB int        `json:",omitempty"`
+	Created  *time.Time `json:",omitempty"`
 }
 
-// Run executes the VM cleanup logic
-func Run(ctx context.Context, client *auth.Client, verbose bool, user, password, ip, outputFormat string, dryRun bool) error {
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	if verbose {
-		log.SetLevel(logrus.DebugLevel)
+// DeletionResult is the outcome of deleting one stale VM from the hypervisor
+type DeletionResult struct {
+	VM      string `json:"vm"`
+	Tenant  string `json:"tenant"`
+	Status  string `json:"status"`
+	Command string `json:"command"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Values of DeletionResult.Status
+const (
+	DeletionDeleted = "deleted"
+	DeletionFailed  = "failed"
+	DeletionDryRun  = "dry-run"
+	DeletionAborted = "aborted"
+)
+
+// ErrDeletionFailed is returned by Run when at least one stale VM could not be deleted
+var ErrDeletionFailed = errors.New("stale VMs could not be deleted")
+
+// Config holds configuration parameters for the clean-nova-stale-vms module
+type Config struct {
+	Verbose          bool
+	User             string // SSH username
+	Password         string // SSH password; optional when ssh-agent holds a usable key
+	IP               string // Hypervisor IP address
+	SSHPort          int    // Hypervisor SSH port
+	SSHBastion       string // Jump host as user@host[:port]; empty to connect directly
+	OutputFormat     string
+	DryRun           bool
+	InsecureHostKey  bool          // Skip SSH host key verification (does not affect OpenStack TLS)
+	CacheInventory   string        // Path of the OpenStack inventory cache file; empty disables caching
+	CacheTTL         time.Duration // Maximum age of cached inventory before it is refetched
+	Refresh          bool          // Ignore cached inventory and refetch it
+	CheckService     bool          // Check the hypervisor's nova-compute service before comparing
+	MaxHeartbeatAge  time.Duration // Oldest nova-compute heartbeat accepted by CheckService
+	Force            bool          // Compare even when CheckService finds the service down or stale
+	Quiet            bool          // Suppress progress messages on stderr
+	ParallelProjects int           // Projects whose servers are listed concurrently; at least 1
+	IncludeDisabled  bool          // List the servers of disabled projects with the rest of the inventory
+	Project          string        // Name or ID of the only project to list; empty lists all projects
+	WebhookURL       string        // POST the JSON summary here; empty disables the webhook
+	WebhookOn        string        // When to post: always or stale-only
+	WebhookTimeout   time.Duration // Timeout of each webhook request
+}
+
+// ValidateParallelProjects rejects --parallel-projects values below 1
+func ValidateParallelProjects(n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid --parallel-projects %d; must be at least 1", n)
 	}
-	log.Debugf("Starting VM cleanup for IP: %s, User: %s, OutputFormat: %s, DryRun: %v, Verbose: %v", ip, user, outputFormat, dryRun, verbose)
+	return nil
+}
+
+// Run executes the VM cleanup logic
+func Run(ctx context.Context, client *auth.Client, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+	log.Debugf("Starting VM cleanup for IP: %s, User: %s, OutputFormat: %s, DryRun: %v, Verbose: %v, InsecureHostKey: %v", cfg.IP, cfg.User, cfg.OutputFormat, cfg.DryRun, cfg.Verbose, cfg.InsecureHostKey)
 
 	region := os.Getenv("OS_REGION_NAME")
 	if region == "" {
@@ -57,29 +116,64 @@ func Run(ctx context.Context, client *auth.Client, verbose bool, user, password,
 	}
 	log.Debugf("Found %d hypervisors", len(hypervisorsList))
 
-	log.Debugf("Resolving hostname for IP: %s", ip)
-	hypervisorHostname := resolveHostname(ip, hypervisorsList)
-	if hypervisorHostname == "" {
-		log.Debugf("No hypervisor found for IP: %s", ip)
-		return fmt.Errorf("no matching hypervisor found for IP: %s", ip)
+	log.Debugf("Resolving hostname for IP: %s", cfg.IP)
+	hypervisor, ok := resolveHypervisor(cfg.IP, hypervisorsList)
+	if !ok {
+		log.Debugf("No hypervisor found for IP: %s", cfg.IP)
+		return fmt.Errorf("no matching hypervisor found for IP: %s", cfg.IP)
 	}
+	hypervisorHostname := hypervisor.HypervisorHostname
 	log.Debugf("Resolved hostname: %s", hypervisorHostname)
 
+	// A down nova-compute leaves a stale OpenStack inventory, which would report bogus missing VMs
+	var serviceState *ComputeServiceState
+	if cfg.CheckService {
+		serviceState, err = fetchComputeService(ctx, client, hypervisor)
+		if err != nil {
+			return fmt.Errorf("error checking nova-compute service: %v", err)
+		}
+		if reason := serviceState.staleReason(cfg.MaxHeartbeatAge); reason != "" {
+			if !cfg.Force {
+				return fmt.Errorf("%s; its OpenStack inventory may be stale, use --force to compare anyway", reason)
+			}
+			log.Warnf("%s; comparing anyway because of --force", reason)
+		}
+	}
+
 	var wg sync.WaitGroup
 	var openstackInstances []InstanceInfo
 	var remoteVMs []VM
 	var errOpenStack, errRemote error
+	var cachedAt time.Time
 	wg.Add(2)
 	log.Debug("Launching goroutines for OpenStack and remote VM list fetching")
 	go func() {
 		defer wg.Done()
+		// The cache holds the inventory of all projects, so a single project neither reads nor replaces it
+		if cfg.Project != "" {
+			log.Debugf("Fetching OpenStack VM list of project %s", cfg.Project)
+			openstackInstances, errOpenStack = fetchProjectVMList(ctx, client, hypervisorHostname, cfg.Project)
+			return
+		}
+		if cfg.CacheInventory != "" && !cfg.Refresh {
+			var ok bool
+			if openstackInstances, cachedAt, ok = loadCachedInventory(cfg.CacheInventory, hypervisorHostname, scopeOf(cfg), cfg.CacheTTL); ok {
+				log.Debugf("Using cached OpenStack VM list from %s (fetched %s)", cfg.CacheInventory, cachedAt.Format(time.RFC3339))
+				return
+			}
+		}
 		log.Debug("Fetching OpenStack VM list")
-		openstackInstances, errOpenStack = fetchOpenStackVMList(ctx, client, hypervisorHostname, region)
+		openstackInstances, errOpenStack = fetchOpenStackVMList(ctx, client, hypervisorHostname, region, cfg)
+		if errOpenStack == nil && cfg.CacheInventory != "" {
+			if err := saveCachedInventory(cfg.CacheInventory, hypervisorHostname, scopeOf(cfg), openstackInstances); err != nil {
+				log.Warnf("Failed to write inventory cache %s: %v", cfg.CacheInventory, err)
+			}
+		}
 	}()
 	go func() {
 		defer wg.Done()
 		log.Debug("Fetching remote VM list via SSH")
-		remoteVMs, errRemote = fetchRemoteVMListSSH(user, password, ip)
+		remoteVMs, errRemote = fetchRemoteVMListSSH(ctx, cfg)
 	}()
 	wg.Wait()
 	log.Debugf("Fetched OpenStack VMs: %d, Remote VMs: %d", len(openstackInstances), len(remoteVMs))
@@ -92,50 +186,129 @@ func Run(ctx context.Context, client *auth.Client, verbose bool, user, password,
 		return fmt.Errorf("error fetching remote VM list: %v", errRemote)
 	}
 
+	var cacheAge time.Duration
+	if !cachedAt.IsZero() {
+		cacheAge = time.Since(cachedAt).Round(time.Second)
+	}
+
+	missing := findMissingVms(openstackInstances, remoteVMs)
+	// The host may run VMs of other projects than --project, so a VM missing from that project is
+	// only stale if no other project has it either; otherwise it would be deleted from the host.
+	// The counts and ghosts still describe the chosen project.
+	if len(missing) > 0 && cfg.Project != "" {
+		log.Debugf("Checking all projects for %d VMs missing from project %s", len(missing), cfg.Project)
+		allCfg := cfg
+		allCfg.IncludeDisabled = true
+		allInstances, err := fetchOpenStackVMList(ctx, client, hypervisorHostname, region, allCfg)
+		if err != nil {
+			return fmt.Errorf("error checking other projects for VMs missing from project %s: %v", cfg.Project, err)
+		}
+		missing = findMissingVms(allInstances, remoteVMs)
+	}
+	// Servers of disabled projects still run, so a VM missing from the enabled projects is only
+	// stale if it is not in a disabled one either; otherwise it would be deleted from the host
+	if len(missing) > 0 && cfg.Project == "" && !cfg.IncludeDisabled {
+		log.Debugf("Checking disabled projects for %d missing VMs", len(missing))
+		disabledInstances, err := fetchDisabledProjectVMs(ctx, client, hypervisorHostname, cfg)
+		if err != nil {
+			return fmt.Errorf("error checking disabled projects for missing VMs: %v", err)
+		}
+		openstackInstances = append(openstackInstances, disabledInstances...)
+		missing = findMissingVms(openstackInstances, remoteVMs)
+	}
+
 	// Output results
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output")
-		data, err := json.MarshalIndent(struct {
-			OpenStackVMs []InstanceInfo `json:"openstack_vms"`
-			RemoteVMs    []VM           `json:"remote_vms"`
-			MissingVMs   []InstanceInfo `json:"missing_vms"`
+	ghosts := findGhostVMs(openstackInstances, remoteVMs)
+	summary := buildSummary(cfg, hypervisorHostname, openstackInstances, remoteVMs, missing, ghosts)
+	log.Debugf("Preparing %s output", cfg.OutputFormat)
+	result := &output.Result{
+		Headers: []string{"VM", "Tenant", "Status", "Memory", "Age"},
+		Data: struct {
+			InventoryCached     bool                 `json:"inventory_cached"`
+			InventoryAgeSeconds int64                `json:"inventory_age_seconds,omitempty"`
+			ComputeService      *ComputeServiceState `json:"compute_service,omitempty"`
+			OpenStackVMs        []InstanceInfo       `json:"openstack_vms"`
+			RemoteVMs           []VM                 `json:"remote_vms"`
+			MissingVMs          []InstanceInfo       `json:"missing_vms"`
+			Summary             Summary              `json:"summary"`
 		}{
-			OpenStackVMs: openstackInstances,
-			RemoteVMs:    remoteVMs,
-			MissingVMs:   findMissingVms(openstackInstances, remoteVMs),
-		}, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return fmt.Errorf("failed to marshal JSON: %v", err)
+			InventoryCached:     !cachedAt.IsZero(),
+			InventoryAgeSeconds: int64(cacheAge.Seconds()),
+			ComputeService:      serviceState,
+			OpenStackVMs:        openstackInstances,
+			RemoteVMs:           remoteVMs,
+			MissingVMs:          missing,
+			Summary:             summary,
+		},
+		Empty: "✅ No missing VMs detected!",
+	}
+	for _, vm := range missing {
+		result.AddRow(vm.InstanceName, vm.TenantName, vm.Status, formatMemory(vm.MemoryMB), formatAge(vm.Created))
+	}
+	if cfg.OutputFormat == "table" {
+		if !cachedAt.IsZero() {
+			fmt.Printf("⚠️  Using cached OpenStack inventory from %s (age %v, TTL %v); use --refresh to refetch\n", cfg.CacheInventory, cacheAge, cfg.CacheTTL)
+		}
+		if serviceState != nil && serviceState.NeverReported {
+			fmt.Printf("🔹 nova-compute on %s: %s (%s), no heartbeat reported\n", serviceState.Host, serviceState.State, serviceState.Status)
+		} else if serviceState != nil {
+			fmt.Printf("🔹 nova-compute on %s: %s (%s), last heartbeat %v ago\n", serviceState.Host, serviceState.State,
+				serviceState.Status, time.Duration(serviceState.HeartbeatAgeSeconds)*time.Second)
 		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output")
 		fmt.Printf("🔹 OpenStack VM count: %d\n", len(openstackInstances))
 		fmt.Printf("🔹 Remote VM count: %d\n", len(remoteVMs))
-		fmt.Printf("🔹 Missing VM count: %d\n", len(findMissingVms(openstackInstances, remoteVMs)))
-		if len(findMissingVms(openstackInstances, remoteVMs)) == 0 {
-			fmt.Println("✅ No missing VMs detected!")
-		} else {
+		fmt.Printf("🔹 Missing VM count: %d\n", len(missing))
+		fmt.Printf("🔹 Ghost VM count (in OpenStack, not on host): %d\n", len(ghosts))
+		if len(missing) > 0 {
 			fmt.Println("Missing VMs:")
-			for _, vm := range findMissingVms(openstackInstances, remoteVMs) {
-				fmt.Printf(" - VM: %s, Tenant: %s, Status: %s\n", vm.InstanceName, vm.TenantName, vm.Status)
-			}
 		}
 	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return err
+	}
+	notifyWebhook(ctx, cfg, summary)
 
-	if len(findMissingVms(openstackInstances, remoteVMs)) > 0 {
-		log.Debugf("Found %d missing VMs, initiating deletion process", len(findMissingVms(openstackInstances, remoteVMs)))
-		deleteAbandonedVMs(user, password, ip, findMissingVms(openstackInstances, remoteVMs), dryRun, outputFormat)
+	// The cache may predate VMs created since, which would look stale; it is good enough for a
+	// report or dry run, but VMs are only deleted when the live inventory does not know them
+	if len(missing) > 0 && !cachedAt.IsZero() && !cfg.DryRun {
+		missing, err = recheckMissing(ctx, client, hypervisorHostname, region, cfg, remoteVMs)
+		if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		log.Debugf("Found %d missing VMs, initiating deletion process", len(missing))
+		deletions, err := deleteAbandonedVMs(ctx, cfg, missing)
+		if err != nil {
+			return err
+		}
+		if err := printDeletions(cfg, deletions); err != nil {
+			return err
+		}
 	}
 	log.Debug("VM cleanup process completed")
 	return nil
 }
 
+// recheckMissing returns the remote VMs that the live OpenStack inventory of all projects,
+// disabled ones included, does not know, for deleting after a comparison with the cache
+func recheckMissing(ctx context.Context, client *auth.Client, hypervisorHostname, region string, cfg Config, remoteVMs []VM) ([]InstanceInfo, error) {
+	log.Infof("Rechecking the VMs missing from the cached inventory against the live OpenStack inventory before deleting")
+	liveCfg := cfg
+	liveCfg.IncludeDisabled = true
+	live, err := fetchOpenStackVMList(ctx, client, hypervisorHostname, region, liveCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error rechecking missing VMs against the live OpenStack inventory: %v", err)
+	}
+	missing := findMissingVms(live, remoteVMs)
+	log.Debugf("%d VMs are missing from the live OpenStack inventory", len(missing))
+	return missing, nil
+}
+
 func fetchHypervisorList(ctx context.Context, client *auth.Client) ([]hypervisors.Hypervisor, error) {
 	log.Debug("Fetching hypervisor list from OpenStack")
 	var hypervisorsList []hypervisors.Hypervisor
-	err := util.WithRetry(3, time.Second, func() error {
+	err := util.WithRetry(ctx, 3, time.Second, func() error {
 		log.Debug("Attempting to list hypervisors")
 		allPages, err := hypervisors.List(client.Compute, hypervisors.ListOpts{}).AllPages(ctx)
 		if err != nil {
@@ -158,43 +331,128 @@ func fetchHypervisorList(ctx context.Context, client *auth.Client) ([]hypervisor
 	return hypervisorsList, nil
 }
 
-func resolveHostname(ip string, hypervisorsList []hypervisors.Hypervisor) string {
+func resolveHypervisor(ip string, hypervisorsList []hypervisors.Hypervisor) (hypervisors.Hypervisor, bool) {
 	log.Debugf("Resolving hostname for IP: %s", ip)
 	for _, hypervisor := range hypervisorsList {
 		if hypervisor.HostIP == ip {
 			log.Debugf("Matched IP %s to hostname %s", ip, hypervisor.HypervisorHostname)
-			return hypervisor.HypervisorHostname
+			return hypervisor, true
 		}
 	}
 	log.Debugf("No hostname found for IP: %s", ip)
-	return ""
+	return hypervisors.Hypervisor{}, false
 }
 
-func fetchOpenStackVMList(ctx context.Context, client *auth.Client, hypervisorHostname, region string) ([]InstanceInfo, error) {
-	log.Debugf("Fetching OpenStack VM list for hypervisor: %s, region: %s", hypervisorHostname, region)
-	projectList, err := fetchAllProjects(ctx, client)
+// progressInterval is how often fetchOpenStackVMList reports progress on stderr
+const progressInterval = 5 * time.Second
+
+// fetchOpenStackVMList lists the VMs on hypervisorHostname in all enabled projects, or in all
+// projects with cfg.IncludeDisabled
+func fetchOpenStackVMList(ctx context.Context, client *auth.Client, hypervisorHostname, region string, cfg Config) ([]InstanceInfo, error) {
+	log.Debugf("Fetching OpenStack VM list for hypervisor: %s, region: %s, %d projects at a time", hypervisorHostname, region, cfg.ParallelProjects)
+	var enabled *bool
+	if !cfg.IncludeDisabled {
+		onlyEnabled := true
+		enabled = &onlyEnabled
+	}
+	projectList, err := fetchAllProjects(ctx, client, enabled)
 	if err != nil {
 		log.Debugf("Failed to fetch projects: %v", err)
 		return nil, fmt.Errorf("error fetching projects: %v", err)
 	}
 	log.Debugf("Fetched %d projects", len(projectList))
+	return fetchVMsInProjects(ctx, client, projectList, hypervisorHostname, cfg.ParallelProjects, cfg.Quiet)
+}
+
+// fetchProjectVMList lists the VMs on hypervisorHostname in the project named or with the ID
+// nameOrID, without listing the other projects
+func fetchProjectVMList(ctx context.Context, client *auth.Client, hypervisorHostname, nameOrID string) ([]InstanceInfo, error) {
+	project, err := findProject(ctx, client, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := fetchVMsForProject(ctx, client, *project, hypervisorHostname)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching VMs for project %s: %v", project.Name, err)
+	}
+	instances := make([]InstanceInfo, 0, len(names))
+	for _, name := range names {
+		instances = append(instances, InstanceInfo{InstanceName: name, TenantName: project.Name})
+	}
+	log.Debugf("Fetched %d VMs for project %s", len(instances), project.Name)
+	return instances, nil
+}
+
+// findProject resolves nameOrID as a project name, or else as a project ID
+func findProject(ctx context.Context, client *auth.Client, nameOrID string) (*projects.Project, error) {
+	allPages, err := projects.List(client.Identity, projects.ListOpts{Name: nameOrID}).AllPages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up project %s: %v", nameOrID, err)
+	}
+	projectList, err := projects.ExtractProjects(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract projects: %v", err)
+	}
+	switch len(projectList) {
+	case 1:
+		return &projectList[0], nil
+	case 0:
+		project, err := projects.Get(ctx, client.Identity, nameOrID).Extract()
+		if err != nil {
+			return nil, fmt.Errorf("no project found with name or ID '%s': %v", nameOrID, err)
+		}
+		return project, nil
+	default:
+		return nil, fmt.Errorf("%d projects are named '%s' in different domains; use the project ID", len(projectList), nameOrID)
+	}
+}
+
+// fetchDisabledProjectVMs lists the VMs on hypervisorHostname in disabled projects
+func fetchDisabledProjectVMs(ctx context.Context, client *auth.Client, hypervisorHostname string, cfg Config) ([]InstanceInfo, error) {
+	enabled := false
+	projectList, err := fetchAllProjects(ctx, client, &enabled)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Fetched %d disabled projects", len(projectList))
+	return fetchVMsInProjects(ctx, client, projectList, hypervisorHostname, cfg.ParallelProjects, cfg.Quiet)
+}
 
+// fetchVMsInProjects lists the VMs on hypervisorHostname in projectList, parallelProjects
+// projects at a time. It fails when the servers of any project cannot be listed: the VMs of
+// that project would otherwise look stale and be deleted, and the partial inventory cached.
+func fetchVMsInProjects(ctx context.Context, client *auth.Client, projectList []projects.Project, hypervisorHostname string, parallelProjects int, quiet bool) ([]InstanceInfo, error) {
 	var instanceNames []InstanceInfo
+	var failed []string
+	var firstErr error
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 10) // Limit to 10 concurrent project queries
+	sem := make(chan struct{}, parallelProjects) // Limit concurrent project queries to stay under API rate limits
+
+	var processed atomic.Int32
+	if !quiet {
+		done := make(chan struct{})
+		defer close(done)
+		go reportProgress(done, &processed, len(projectList))
+	}
 
 	for _, project := range projectList {
 		wg.Add(1)
 		go func(project projects.Project) {
 			defer wg.Done()
+			defer processed.Add(1)
 			sem <- struct{}{}
 			defer func() { <-sem }()
 			log.Debugf("Fetching VMs for project: %s (ID: %s)", project.Name, project.ID)
 			instances, err := fetchVMsForProject(ctx, client, project, hypervisorHostname)
 			if err != nil {
-				log.Debugf("Error fetching VMs for project %s: %v", project.Name, err)
-				fmt.Printf("Error fetching VMs for project %s: %v\n", project.Name, err)
+				log.Warnf("Error fetching VMs for project %s: %v", project.Name, err)
+				mu.Lock()
+				failed = append(failed, project.Name)
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
 				return
 			}
 			log.Debugf("Fetched %d VMs for project %s", len(instances), project.Name)
@@ -211,16 +469,36 @@ func fetchOpenStackVMList(ctx context.Context, client *auth.Client, hypervisorHo
 	}
 	wg.Wait()
 	close(sem)
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return nil, fmt.Errorf("failed to list VMs of %d of %d projects (%s): %v", len(failed), len(projectList), strings.Join(failed, ", "), firstErr)
+	}
 	log.Debugf("Total OpenStack VMs fetched: %d", len(instanceNames))
 	return instanceNames, nil
 }
 
-func fetchAllProjects(ctx context.Context, client *auth.Client) ([]projects.Project, error) {
+// reportProgress prints how many of total projects have been processed every progressInterval until done is closed
+func reportProgress(done <-chan struct{}, processed *atomic.Int32, total int) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			fmt.Fprintf(os.Stderr, "processed %d/%d projects\n", processed.Load(), total)
+		}
+	}
+}
+
+// fetchAllProjects lists the projects whose enabled flag matches enabled, or all projects when
+// enabled is nil
+func fetchAllProjects(ctx context.Context, client *auth.Client, enabled *bool) ([]projects.Project, error) {
 	log.Debug("Fetching all projects from OpenStack")
 	var projectList []projects.Project
-	err := util.WithRetry(3, time.Second, func() error {
+	err := util.WithRetry(ctx, 3, time.Second, func() error {
 		log.Debug("Attempting to list projects")
-		allPages, err := projects.List(client.Identity, projects.ListOpts{}).AllPages(ctx)
+		allPages, err := projects.List(client.Identity, projects.ListOpts{Enabled: enabled}).AllPages(ctx)
 		if err != nil {
 			log.Debugf("Failed to list projects: %v", err)
 			return fmt.Errorf("failed to list projects: %v", err)
@@ -244,7 +522,7 @@ func fetchAllProjects(ctx context.Context, client *auth.Client) ([]projects.Proj
 func fetchVMsForProject(ctx context.Context, client *auth.Client, project projects.Project, hypervisorHostname string) ([]string, error) {
 	log.Debugf("Fetching VMs for project %s (ID: %s) on hypervisor %s", project.Name, project.ID, hypervisorHostname)
 	var filteredInstances []string
-	err := util.WithRetry(3, time.Second, func() error {
+	err := util.WithRetry(ctx, 3, time.Second, func() error {
 		log.Debug("Attempting to list servers for project")
 		opts := servers.ListOpts{
 			AllTenants: true,
@@ -283,67 +561,72 @@ func fetchVMsForProject(ctx context.Context, client *auth.Client, project projec
 	return filteredInstances, nil
 }
 
-func fetchRemoteVMListSSH(user, password, ip string) ([]VM, error) {
-	log.Debugf("Fetching remote VM list via SSH for user: %s, IP: %s", user, ip)
+// sshClientConfig builds the SSH client configuration for the hypervisor. The returned close
+// function releases the ssh-agent connection and is called once dialing is done.
+func sshClientConfig(cfg Config) (*ssh.ClientConfig, func(), error) {
+	hostKeyCallback, err := util.HostKeyCallback(cfg.InsecureHostKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	authMethods, closeAgent, err := util.SSHAuthMethods(cfg.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	}, closeAgent, nil
+}
+
+func fetchRemoteVMListSSH(ctx context.Context, cfg Config) ([]VM, error) {
+	log.Debugf("Fetching remote VM list via SSH for user: %s, IP: %s", cfg.User, cfg.IP)
+	config, closeAgent, err := sshClientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
 	var remoteVMs []VM
-	err := util.WithRetry(3, time.Second, func() error {
+	err = util.WithRetry(ctx, 3, time.Second, func() error {
 		log.Debug("Establishing SSH connection")
-		config := &ssh.ClientConfig{
-			User: user,
-			Auth: []ssh.AuthMethod{
-				ssh.Password(password),
-			},
-			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		}
-		client, err := ssh.Dial("tcp", ip+":22", config)
+		client, err := util.DialSSHContext(ctx, util.SSHAddress(cfg.IP, cfg.SSHPort), config, cfg.SSHBastion)
 		if err != nil {
 			log.Debugf("SSH connection failed: %v", err)
-			return fmt.Errorf("SSH connection failed: %v", err)
+			return fmt.Errorf("SSH connection failed: %w", err)
 		}
 		defer client.Close()
 		log.Debug("SSH connection established")
 
-		log.Debug("Creating SSH session")
-		session, err := client.NewSession()
-		if err != nil {
-			log.Debugf("SSH session failed: %v", err)
-			return fmt.Errorf("SSH session failed: %v", err)
-		}
-		defer session.Close()
-		log.Debug("SSH session created")
-
-		log.Debug("Executing pvmctl command")
-		cmd := "export TERM=xterm; pvmctl vm list --display-fields LogicalPartition.name LogicalPartition.state | awk '!/ltc.*-nova/'"
-		output, err := session.Output(cmd)
-		if err != nil {
-			log.Debugf("Command failed: %v - output: %s", err, output)
-			return fmt.Errorf("command failed: %v - output: %s", err, output)
-		}
-		log.Debugf("Command output: %s", string(output))
-		remoteVMs = nil // Reset in case of retry
-		for _, line := range strings.Split(string(output), "\n") {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			log.Debugf("Processing line: %s", line)
-			fields := strings.Split(line, ",")
-			vmInfo := make(map[string]string)
-			for _, field := range fields {
-				parts := strings.Split(field, "=")
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					value := strings.TrimSpace(parts[1])
-					vmInfo[key] = value
-				}
+		// Each command needs its own session
+		run := func(cmd string) ([]byte, error) {
+			log.Debug("Creating SSH session")
+			session, err := client.NewSession()
+			if err != nil {
+				log.Debugf("SSH session failed: %v", err)
+				return nil, fmt.Errorf("SSH session failed: %v", err)
 			}
-			if name, exists := vmInfo["name"]; exists {
-				if state, exists := vmInfo["state"]; exists {
-					log.Debugf("Adding VM: Name=%s, State=%s", name, state)
-					remoteVMs = append(remoteVMs, VM{Name: name, Status: state})
+			defer session.Close()
+			log.Debugf("Executing pvmctl command: %s", cmd)
+			var output []byte
+			err = util.RunSessionContext(ctx, session, func() error {
+				var err error
+				output, err = session.Output(cmd)
+				return err
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, fmt.Errorf("pvmctl vm list interrupted: %w", err)
 				}
+				log.Debugf("Command failed: %v - output: %s", err, output)
+				return nil, fmt.Errorf("command failed: %v - output: %s", err, output)
 			}
+			return output, nil
+		}
+		vms, err := listRemoteVMs(ctx, cfg.IP, run)
+		if err != nil {
+			return err
 		}
+		remoteVMs = vms
 		log.Debugf("Fetched %d remote VMs", len(remoteVMs))
 		return nil
 	})
@@ -373,6 +656,8 @@ func findMissingVms(vmInstances []InstanceInfo, remoteVMs []VM) []InstanceInfo {
 				InstanceName: remoteVM.Name,
 				TenantName:   "Unknown",
 				Status:       remoteVM.Status,
+				MemoryMB:     remoteVM.MemoryMB,
+				Created:      remoteVM.Created,
 			})
 		}
 	}
@@ -380,38 +665,25 @@ func findMissingVms(vmInstances []InstanceInfo, remoteVMs []VM) []InstanceInfo {
 	return missing
 }
 
-func deleteAbandonedVMs(user, password, ip string, abandonedVMs []InstanceInfo, dryRun bool, outputFormat string) {
-	log.Debugf("Starting deletion of %d abandoned VMs, DryRun: %v", len(abandonedVMs), dryRun)
-	if len(abandonedVMs) == 0 {
-		if strings.ToLower(outputFormat) == "json" {
-			log.Debug("No abandoned VMs to delete, outputting empty JSON")
-			fmt.Println("[]")
-		} else {
-			log.Debug("No abandoned VMs to delete, outputting message")
-			fmt.Println("✅ No abandoned VMs to delete.")
-		}
-		return
+// deleteAbandonedVMs deletes the stale VMs from the hypervisor after a typed confirmation and
+// returns the outcome for each. On a dry run nothing is deleted and the results list the commands
+// that would run. An error means no VM could be attempted.
+func deleteAbandonedVMs(ctx context.Context, cfg Config, abandonedVMs []InstanceInfo) ([]DeletionResult, error) {
+	log.Debugf("Starting deletion of %d abandoned VMs, DryRun: %v", len(abandonedVMs), cfg.DryRun)
+	results := make([]DeletionResult, 0, len(abandonedVMs))
+	for _, vm := range abandonedVMs {
+		results = append(results, DeletionResult{
+			VM:      vm.InstanceName,
+			Tenant:  vm.TenantName,
+			Command: fmt.Sprintf("pvmctl LogicalPartition delete --object-id name=%s", vm.InstanceName),
+		})
 	}
-	if dryRun {
-		if strings.ToLower(outputFormat) == "json" {
-			log.Debug("Dry run mode, marshaling abandoned VMs to JSON")
-			data, err := json.MarshalIndent(abandonedVMs, "", "  ")
-			if err != nil {
-				log.Debugf("Error marshaling JSON: %v", err)
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
-			}
-			fmt.Println(string(data))
-		} else {
-			log.Debug("Dry run mode, listing VMs that would be deleted")
-			fmt.Println("⚠️ Dry-run mode enabled. VMs that would be deleted:")
-			for _, vm := range abandonedVMs {
-				fmt.Printf(" - VM: %s, Tenant: %s, Status: %s\n", vm.InstanceName, vm.TenantName, vm.Status)
-			}
-		}
-		return
+	if cfg.DryRun {
+		log.Debug("Dry run mode, listing VMs that would be deleted")
+		markDeletions(results, DeletionDryRun, "")
+		return results, nil
 	}
-	if strings.ToLower(outputFormat) == "json" {
+	if strings.ToLower(cfg.OutputFormat) == "json" {
 		log.Debugf("Prompting for confirmation to delete %d VMs", len(abandonedVMs))
 		fmt.Printf("{\"status\": \"prompt\", \"message\": \"Type 'confirm' to delete %d VMs\"}\n", len(abandonedVMs))
 	} else {
@@ -421,65 +693,106 @@ func deleteAbandonedVMs(user, password, ip string, abandonedVMs []InstanceInfo,
 	var response string
 	fmt.Scanln(&response)
 	if strings.ToLower(response) != "confirm" {
-		if strings.ToLower(outputFormat) == "json" {
-			log.Debug("Deletion aborted by user, outputting JSON response")
-			fmt.Println("{\"status\": \"aborted\", \"message\": \"Deletion aborted by user.\"}")
-		} else {
-			log.Debug("Deletion aborted by user, outputting message")
-			fmt.Println("❌ Deletion aborted by user.")
-		}
-		return
+		log.Debug("Deletion aborted by user")
+		markDeletions(results, DeletionAborted, "deletion aborted by user")
+		return results, nil
 	}
 	log.Debug("User confirmed deletion, establishing SSH connection")
-	config := &ssh.ClientConfig{
-		User: user,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	config, closeAgent, err := sshClientConfig(cfg)
+	if err != nil {
+		log.Debugf("SSH configuration error: %v", err)
+		return nil, fmt.Errorf("SSH configuration error: %v", err)
 	}
-	client, err := ssh.Dial("tcp", ip+":22", config)
+	client, err := util.DialSSHContext(ctx, util.SSHAddress(cfg.IP, cfg.SSHPort), config, cfg.SSHBastion)
+	closeAgent() // The agent is only needed for the handshake
 	if err != nil {
 		log.Debugf("SSH connection error: %v", err)
-		if strings.ToLower(outputFormat) == "json" {
-			fmt.Printf("{\"status\": \"error\", \"message\": \"SSH connection error: %v\"}\n", err)
-		} else {
-			fmt.Println("SSH connection error:", err)
-		}
-		return
+		return nil, fmt.Errorf("SSH connection error: %w", err)
 	}
 	defer client.Close()
 	log.Debug("SSH connection established, starting VM deletion loop")
-	for _, vm := range abandonedVMs {
+	for i := range results {
+		r := &results[i]
+		if ctx.Err() != nil {
+			r.Status, r.Error = DeletionFailed, fmt.Sprintf("not attempted: %v", ctx.Err())
+			continue
+		}
 		session, err := client.NewSession()
 		if err != nil {
-			log.Debugf("SSH session failed for VM %s: %v", vm.InstanceName, err)
-			if strings.ToLower(outputFormat) == "json" {
-				fmt.Printf("{\"status\": \"error\", \"vm\": %q, \"message\": \"SSH session failed: %v\"}\n", vm.InstanceName, err)
-			} else {
-				fmt.Printf("❌ SSH session failed for %s: %v\n", vm.InstanceName, err)
-			}
+			log.Debugf("SSH session failed for VM %s: %v", r.VM, err)
+			r.Status, r.Error = DeletionFailed, fmt.Sprintf("SSH session failed: %v", err)
 			continue
 		}
-		cmd := fmt.Sprintf("pvmctl LogicalPartition delete --object-id name=%s", vm.InstanceName)
-		log.Debugf("Executing deletion command for VM %s: %s", vm.InstanceName, cmd)
-		output, err := session.CombinedOutput(cmd)
+		log.Debugf("Executing deletion command for VM %s: %s", r.VM, r.Command)
+		var out []byte
+		err = util.RunSessionContext(ctx, session, func() error {
+			var err error
+			out, err = session.CombinedOutput(r.Command)
+			return err
+		})
 		session.Close()
+		if err != nil && ctx.Err() != nil {
+			// The command may still be running on the hypervisor; its output is not ours to read
+			log.Debugf("Deletion of VM %s interrupted: %v", r.VM, err)
+			r.Status, r.Error = DeletionFailed, fmt.Sprintf("interrupted, check the VM on the hypervisor: %v", err)
+			continue
+		}
 		if err != nil {
-			log.Debugf("Failed to delete VM %s: %v, Output: %s", vm.InstanceName, err, output)
-			if strings.ToLower(outputFormat) == "json" {
-				fmt.Printf("{\"status\": \"error\", \"vm\": %q, \"message\": \"Failed to delete VM: %v, Output: %s\"}\n", vm.InstanceName, err, output)
-			} else {
-				fmt.Printf("❌ Failed to delete VM %s (Tenant: %s): %v, Output: %s\n", vm.InstanceName, vm.TenantName, err, output)
-			}
-		} else {
-			log.Debugf("Successfully deleted VM %s", vm.InstanceName)
-			if strings.ToLower(outputFormat) == "json" {
-				fmt.Printf("{\"status\": \"success\", \"vm\": %q, \"tenant\": %q, \"command\": %q}\n", vm.InstanceName, vm.TenantName, cmd)
-			} else {
-				fmt.Printf(" - VM: %s, Tenant: %s, Status: %s → Command: %s\n", vm.InstanceName, vm.TenantName, vm.Status, cmd)
-			}
+			log.Debugf("Failed to delete VM %s: %v, Output: %s", r.VM, err, out)
+			r.Status, r.Error = DeletionFailed, fmt.Sprintf("%v, output: %s", err, strings.TrimSpace(string(out)))
+			continue
 		}
+		log.Debugf("Successfully deleted VM %s", r.VM)
+		r.Status = DeletionDeleted
 	}
 	log.Debug("Abandoned VM deletion process completed")
+	return results, nil
+}
+
+func markDeletions(results []DeletionResult, status, message string) {
+	for i := range results {
+		results[i].Status, results[i].Error = status, message
+	}
+}
+
+// printDeletions prints the deletion results and returns ErrDeletionFailed when any VM could not be deleted
+func printDeletions(cfg Config, results []DeletionResult) error {
+	result := &output.Result{Headers: []string{"VM", "Tenant", "Status", "Detail"}, Data: results}
+	deleted, failed, aborted := 0, 0, 0
+	for _, r := range results {
+		detail := r.Command
+		if r.Error != "" {
+			detail = r.Error
+		}
+		result.AddRow(r.VM, r.Tenant, r.Status, detail)
+		switch r.Status {
+		case DeletionDeleted:
+			deleted++
+		case DeletionFailed:
+			failed++
+		case DeletionAborted:
+			aborted++
+		}
+	}
+	if cfg.OutputFormat == "table" {
+		if cfg.DryRun {
+			fmt.Println("⚠️ Dry-run mode enabled. VMs that would be deleted:")
+		} else {
+			fmt.Println("Deletion results:")
+		}
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return err
+	}
+	if cfg.OutputFormat == "table" && !cfg.DryRun {
+		fmt.Printf("Deleted: %d, Failed: %d", deleted, failed)
+		if aborted > 0 {
+			fmt.Printf(", Aborted: %d", aborted)
+		}
+		fmt.Println()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrDeletionFailed, failed, len(results))
+	}
+	return nil
 }
@@ -0,0 +1,67 @@
+package cleannovastalevms
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+func TestFetchRemoteVMListSSHCancelledContext(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	cfg := Config{User: "root", Password: "secret", IP: "127.0.0.1", SSHPort: ln.Addr().(*net.TCPAddr).Port, InsecureHostKey: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	vms, err := fetchRemoteVMListSSH(ctx, cfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if vms != nil {
+		t.Errorf("got VMs %v from a cancelled listing", vms)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("fetchRemoteVMListSSH took %s after ctx was cancelled", time.Since(start))
+	}
+}
+
+func TestPrintDeletionsSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+		wantErr  bool
+	}{
+		{name: "all deleted", statuses: []string{DeletionDeleted, DeletionDeleted}, want: "Deleted: 2, Failed: 0\n"},
+		{name: "one failed", statuses: []string{DeletionDeleted, DeletionFailed}, want: "Deleted: 1, Failed: 1\n", wantErr: true},
+		{name: "aborted", statuses: []string{DeletionAborted, DeletionAborted}, want: "Deleted: 0, Failed: 0, Aborted: 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []DeletionResult
+			for _, status := range tt.statuses {
+				results = append(results, DeletionResult{VM: "lpar-1", Tenant: "demo", Status: status})
+			}
+			var err error
+			stdout, _ := testutil.CaptureOutput(t, func() {
+				err = printDeletions(Config{OutputFormat: "table"}, results)
+			})
+			if got := errors.Is(err, ErrDeletionFailed); got != tt.wantErr {
+				t.Errorf("got error %v, want ErrDeletionFailed: %v", err, tt.wantErr)
+			}
+			if !strings.HasSuffix(stdout, tt.want) {
+				t.Errorf("got stdout %q, want it to end with %q", stdout, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package cleannovastalevms
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// inventoryCache is the on-disk format used by --cache-inventory, keyed by hypervisor hostname
+type inventoryCache struct {
+	Hypervisors map[string]cachedInventory `json:"hypervisors"`
+}
+
+// cachedInventory holds the OpenStack instance list of one hypervisor, when it was fetched and
+// which projects it covers
+type cachedInventory struct {
+	FetchedAt time.Time      `json:"fetched_at"`
+	Scope     inventoryScope `json:"scope"`
+	Instances []InstanceInfo `json:"instances"`
+}
+
+// inventoryScope is the set of projects an inventory was fetched from. An inventory of another
+// scope lists other VMs, so it is never reused.
+type inventoryScope struct {
+	IncludeDisabled bool   `json:"include_disabled"`
+	Project         string `json:"project,omitempty"`
+}
+
+// scopeOf returns the inventory scope of a run with cfg
+func scopeOf(cfg Config) inventoryScope {
+	return inventoryScope{IncludeDisabled: cfg.IncludeDisabled, Project: cfg.Project}
+}
+
+func readInventoryCache(path string) (*inventoryCache, error) {
+	cache := &inventoryCache{Hypervisors: make(map[string]cachedInventory)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, err
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return &inventoryCache{Hypervisors: make(map[string]cachedInventory)}, fmt.Errorf("failed to parse inventory cache %s: %v", path, err)
+	}
+	if cache.Hypervisors == nil {
+		cache.Hypervisors = make(map[string]cachedInventory)
+	}
+	return cache, nil
+}
+
+// loadCachedInventory returns the cached instance list for hypervisor when it has the given
+// scope and is younger than ttl
+func loadCachedInventory(path, hypervisor string, scope inventoryScope, ttl time.Duration) ([]InstanceInfo, time.Time, bool) {
+	cache, err := readInventoryCache(path)
+	if err != nil {
+		log.Warnf("Ignoring inventory cache: %v", err)
+		return nil, time.Time{}, false
+	}
+	entry, ok := cache.Hypervisors[hypervisor]
+	if !ok {
+		log.Debugf("No cached inventory for hypervisor %s in %s", hypervisor, path)
+		return nil, time.Time{}, false
+	}
+	if entry.Scope != scope {
+		log.Debugf("Cached inventory for hypervisor %s has scope %+v, not %+v, refetching", hypervisor, entry.Scope, scope)
+		return nil, time.Time{}, false
+	}
+	if age := time.Since(entry.FetchedAt); age > ttl {
+		log.Debugf("Cached inventory for hypervisor %s is %v old (TTL %v), refetching", hypervisor, age.Round(time.Second), ttl)
+		return nil, time.Time{}, false
+	}
+	return entry.Instances, entry.FetchedAt, true
+}
+
+// saveCachedInventory stores the instance list of scope for hypervisor, keeping entries for
+// other hypervisors
+func saveCachedInventory(path, hypervisor string, scope inventoryScope, instances []InstanceInfo) error {
+	cache, err := readInventoryCache(path)
+	if err != nil {
+		log.Warnf("Overwriting unreadable inventory cache: %v", err)
+	}
+	cache.Hypervisors[hypervisor] = cachedInventory{
+		FetchedAt: time.Now().UTC(),
+		Scope:     scope,
+		Instances: instances,
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
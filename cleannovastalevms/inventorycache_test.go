@@ -0,0 +1,35 @@
+package cleannovastalevms
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedInventoryScope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	saved := inventoryScope{}
+	if err := saveCachedInventory(path, "compute-1", saved, []InstanceInfo{{InstanceName: "vm-1"}}); err != nil {
+		t.Fatalf("saveCachedInventory: %v", err)
+	}
+	tests := []struct {
+		name  string
+		scope inventoryScope
+		hit   bool
+	}{
+		{name: "same scope", scope: saved, hit: true},
+		{name: "include disabled", scope: inventoryScope{IncludeDisabled: true}},
+		{name: "project", scope: inventoryScope{Project: "demo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, _, ok := loadCachedInventory(path, "compute-1", tt.scope, time.Hour)
+			if ok != tt.hit {
+				t.Fatalf("got hit %v, want %v", ok, tt.hit)
+			}
+			if ok && (len(instances) != 1 || instances[0].InstanceName != "vm-1") {
+				t.Errorf("got instances %+v, want vm-1", instances)
+			}
+		})
+	}
+}
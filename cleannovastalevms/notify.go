@@ -0,0 +1,121 @@
+package cleannovastalevms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// Summary is the outcome of one comparison, printed with the results and posted to --webhook-url
+type Summary struct {
+	Host         string    `json:"host"`
+	IP           string    `json:"ip"`
+	Time         time.Time `json:"time"` // UTC
+	DryRun       bool      `json:"dry_run"`
+	OpenStackVMs int       `json:"openstack_vms"`
+	RemoteVMs    int       `json:"remote_vms"`
+	StaleCount   int       `json:"stale_count"`
+	StaleVMs     []string  `json:"stale_vms"` // On the hypervisor but unknown to OpenStack
+	GhostCount   int       `json:"ghost_count"`
+	Ghosts       []string  `json:"ghosts"` // In OpenStack but not on the hypervisor
+}
+
+// Values accepted by --webhook-on
+const (
+	WebhookAlways    = "always"
+	WebhookStaleOnly = "stale-only"
+)
+
+// ValidateWebhookOn rejects --webhook-on values other than always and stale-only
+func ValidateWebhookOn(when string) error {
+	if when != WebhookAlways && when != WebhookStaleOnly {
+		return fmt.Errorf("invalid --webhook-on '%s'; valid: %s, %s", when, WebhookAlways, WebhookStaleOnly)
+	}
+	return nil
+}
+
+// buildSummary collects the counts and VM names of a comparison
+func buildSummary(cfg Config, host string, openstackInstances []InstanceInfo, remoteVMs []VM, stale, ghosts []InstanceInfo) Summary {
+	s := Summary{
+		Host:         host,
+		IP:           cfg.IP,
+		Time:         time.Now().UTC(),
+		DryRun:       cfg.DryRun,
+		OpenStackVMs: len(openstackInstances),
+		RemoteVMs:    len(remoteVMs),
+		StaleCount:   len(stale),
+		StaleVMs:     []string{},
+		GhostCount:   len(ghosts),
+		Ghosts:       []string{},
+	}
+	for _, vm := range stale {
+		s.StaleVMs = append(s.StaleVMs, vm.InstanceName)
+	}
+	for _, vm := range ghosts {
+		s.Ghosts = append(s.Ghosts, vm.InstanceName)
+	}
+	return s
+}
+
+// findGhostVMs returns the OpenStack instances that have no matching VM on the hypervisor
+func findGhostVMs(vmInstances []InstanceInfo, remoteVMs []VM) []InstanceInfo {
+	onHost := make(map[string]bool, len(remoteVMs))
+	for _, vm := range remoteVMs {
+		onHost[strings.ToLower(vm.Name)] = true
+	}
+	var ghosts []InstanceInfo
+	for _, instance := range vmInstances {
+		if !onHost[strings.ToLower(instance.InstanceName)] {
+			ghosts = append(ghosts, instance)
+		}
+	}
+	log.Debugf("Found %d ghost VMs", len(ghosts))
+	return ghosts
+}
+
+// notifyWebhook posts summary to cfg.WebhookURL when cfg.WebhookOn asks for it, retrying once.
+// Delivery failures are only logged so they never change the command's exit status.
+func notifyWebhook(ctx context.Context, cfg Config, summary Summary) {
+	if cfg.WebhookURL == "" {
+		return
+	}
+	if cfg.WebhookOn == WebhookStaleOnly && summary.StaleCount == 0 {
+		log.Debug("No stale VMs, skipping webhook")
+		return
+	}
+	body, err := json.Marshal(summary)
+	if err != nil {
+		log.Warnf("Failed to encode webhook summary: %v", err)
+		return
+	}
+	client := &http.Client{Timeout: cfg.WebhookTimeout}
+	err = util.WithRetry(ctx, 2, time.Second, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Failed to deliver webhook: %v", err)
+		return
+	}
+	log.Debugf("Posted summary to webhook (%d stale VMs)", summary.StaleCount)
+}
@@ -0,0 +1,128 @@
+package cleannovastalevms
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pvmctl listings of the LPARs on the host; Nova's own partitions are left out. Older pvmctl
+// versions reject the memory and creation fields, so the basic listing is the fallback. With
+// pipefail a failing pvmctl fails the command instead of being hidden by awk's exit status.
+const (
+	pvmctlListCmd      = "export TERM=xterm; set -o pipefail; pvmctl vm list --display-fields LogicalPartition.name LogicalPartition.state | awk '!/ltc.*-nova/'"
+	pvmctlListCmdFull  = "export TERM=xterm; set -o pipefail; pvmctl vm list --display-fields LogicalPartition.name LogicalPartition.state LogicalPartition.memory LogicalPartition.created | awk '!/ltc.*-nova/'"
+	pvmctlFieldMemory  = "memory"
+	pvmctlFieldCreated = "created"
+)
+
+// pvmctlTimeLayouts are the creation time formats seen in pvmctl output
+var pvmctlTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// parseRemoteVMs parses pvmctl vm list output, one "name=...,state=...[,memory=...][,created=...]"
+// line per LPAR. Lines without a name or state are skipped. Memory (MB) and creation time are
+// optional: hosts whose pvmctl lacks them, or values that do not parse, leave them unset.
+func parseRemoteVMs(out string) []VM {
+	var vms []VM
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		log.Debugf("Processing line: %s", line)
+		vmInfo := make(map[string]string)
+		for _, field := range strings.Split(line, ",") {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) == 2 {
+				vmInfo[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+		name, hasName := vmInfo["name"]
+		state, hasState := vmInfo["state"]
+		if !hasName || !hasState {
+			continue
+		}
+		vm := VM{Name: name, Status: state}
+		if value, ok := vmInfo[pvmctlFieldMemory]; ok {
+			if mb, err := strconv.Atoi(value); err == nil {
+				vm.MemoryMB = mb
+			} else {
+				log.Debugf("Ignoring memory %q of VM %s: %v", value, name, err)
+			}
+		}
+		if value, ok := vmInfo[pvmctlFieldCreated]; ok {
+			if created, ok := parsePvmctlTime(value); ok {
+				vm.Created = &created
+			} else {
+				log.Debugf("Ignoring creation time %q of VM %s", value, name)
+			}
+		}
+		log.Debugf("Adding VM: Name=%s, State=%s, MemoryMB=%d", vm.Name, vm.Status, vm.MemoryMB)
+		vms = append(vms, vm)
+	}
+	return vms
+}
+
+// listRemoteVMs lists the LPARs of host with run, which runs a command there. The full listing
+// is tried first. When it fails, or prints no VM line because pvmctl did not take its fields,
+// the basic listing is used; an empty remote list would report every Nova VM as missing.
+func listRemoteVMs(ctx context.Context, host string, run func(cmd string) ([]byte, error)) ([]VM, error) {
+	output, err := run(pvmctlListCmdFull)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return nil, err
+	case err != nil:
+		log.Warnf("pvmctl on %s does not list memory and creation time (%v); listing names and states only", host, err)
+	default:
+		log.Debugf("Command output: %s", string(output))
+		if vms := parseRemoteVMs(string(output)); len(vms) > 0 {
+			return vms, nil
+		}
+		if out := strings.TrimSpace(string(output)); out != "" {
+			log.Warnf("pvmctl on %s listed no VMs with memory and creation time (%s); listing names and states only", host, out)
+		} else {
+			log.Debugf("Full pvmctl listing on %s is empty; listing names and states only", host)
+		}
+	}
+	output, err = run(pvmctlListCmd)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Command output: %s", string(output))
+	return parseRemoteVMs(string(output)), nil
+}
+
+func parsePvmctlTime(value string) (time.Time, bool) {
+	for _, layout := range pvmctlTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatMemory shows the memory of a stale VM, or "-" when pvmctl did not report it
+func formatMemory(mb int) string {
+	if mb <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d MB", mb)
+}
+
+// formatAge shows how long ago a stale VM was created in days, hours or minutes, or "-"
+// when pvmctl did not report it
+func formatAge(created *time.Time) string {
+	if created == nil {
+		return "-"
+	}
+	d := time.Since(*created)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
@@ -0,0 +1,84 @@
+package cleannovastalevms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestListRemoteVMsFallback(t *testing.T) {
+	basic := "name=lpar-1,state=running\nname=lpar-2,state=not activated\n"
+	tests := []struct {
+		name      string
+		fullOut   string
+		fullErr   error
+		wantCmds  []string
+		wantNames []string
+		wantMB    int
+	}{
+		{
+			name:      "full listing",
+			fullOut:   "name=lpar-1,state=running,memory=4096,created=2026-01-02 03:04:05\n",
+			wantCmds:  []string{pvmctlListCmdFull},
+			wantNames: []string{"lpar-1"},
+			wantMB:    4096,
+		},
+		{
+			name:      "full listing rejected",
+			fullErr:   errors.New("Process exited with status 2"),
+			wantCmds:  []string{pvmctlListCmdFull, pvmctlListCmd},
+			wantNames: []string{"lpar-1", "lpar-2"},
+		},
+		{
+			name:      "full listing without VM lines",
+			fullOut:   "ERROR: Unknown field LogicalPartition.memory\n",
+			wantCmds:  []string{pvmctlListCmdFull, pvmctlListCmd},
+			wantNames: []string{"lpar-1", "lpar-2"},
+		},
+		{
+			name:      "full listing empty",
+			wantCmds:  []string{pvmctlListCmdFull, pvmctlListCmd},
+			wantNames: []string{"lpar-1", "lpar-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cmds []string
+			run := func(cmd string) ([]byte, error) {
+				cmds = append(cmds, cmd)
+				if cmd == pvmctlListCmdFull {
+					return []byte(tt.fullOut), tt.fullErr
+				}
+				return []byte(basic), nil
+			}
+			vms, err := listRemoteVMs(context.Background(), "10.0.0.1", run)
+			if err != nil {
+				t.Fatalf("listRemoteVMs: %v", err)
+			}
+			if fmt.Sprint(cmds) != fmt.Sprint(tt.wantCmds) {
+				t.Errorf("ran %q, want %q", cmds, tt.wantCmds)
+			}
+			var names []string
+			for _, vm := range vms {
+				names = append(names, vm.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantNames) {
+				t.Errorf("got VMs %v, want %v", names, tt.wantNames)
+			}
+			if vms[0].MemoryMB != tt.wantMB {
+				t.Errorf("got memory %d MB, want %d", vms[0].MemoryMB, tt.wantMB)
+			}
+		})
+	}
+}
+
+func TestListRemoteVMsBasicListingFails(t *testing.T) {
+	failure := errors.New("pvmctl: command not found")
+	run := func(cmd string) ([]byte, error) {
+		return nil, failure
+	}
+	if _, err := listRemoteVMs(context.Background(), "10.0.0.1", run); !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
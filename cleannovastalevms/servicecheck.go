@@ -0,0 +1,65 @@
+package cleannovastalevms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/services"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// ComputeServiceState is the nova-compute service of the hypervisor, as reported by os-services
+type ComputeServiceState struct {
+	Host                string `json:"host"`
+	State               string `json:"state"`  // up or down
+	Status              string `json:"status"` // enabled or disabled
+	HeartbeatAgeSeconds int64  `json:"heartbeat_age_seconds"`
+	NeverReported       bool   `json:"never_reported,omitempty"` // os-services has no heartbeat time for the service
+}
+
+// fetchComputeService looks up the nova-compute service running the hypervisor
+func fetchComputeService(ctx context.Context, client *auth.Client, hypervisor hypervisors.Hypervisor) (*ComputeServiceState, error) {
+	host := hypervisor.Service.Host
+	if host == "" {
+		host = hypervisor.HypervisorHostname
+	}
+	log.Debugf("Fetching nova-compute service state for host %s", host)
+	allPages, err := services.List(client.Compute, services.ListOpts{Binary: "nova-compute", Host: host}).AllPages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list compute services: %v", err)
+	}
+	serviceList, err := services.ExtractServices(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract compute services: %v", err)
+	}
+	if len(serviceList) == 0 {
+		return nil, fmt.Errorf("no nova-compute service found for host %s", host)
+	}
+	s := serviceList[0]
+	state := &ComputeServiceState{Host: s.Host, State: s.State, Status: s.Status}
+	if s.UpdatedAt.IsZero() {
+		state.NeverReported = true
+	} else {
+		state.HeartbeatAgeSeconds = int64(time.Since(s.UpdatedAt).Seconds())
+	}
+	log.Debugf("nova-compute on %s: state=%s, status=%s, heartbeat age=%ds", s.Host, s.State, s.Status, state.HeartbeatAgeSeconds)
+	return state, nil
+}
+
+// staleReason explains why the OpenStack inventory of the hypervisor cannot be trusted, or
+// returns "" when the service is up and its last heartbeat is within maxAge. A service that
+// never reported a heartbeat is stale whatever maxAge is.
+func (s *ComputeServiceState) staleReason(maxAge time.Duration) string {
+	if s.State != "up" {
+		return fmt.Sprintf("nova-compute on %s is %s", s.Host, s.State)
+	}
+	if s.NeverReported {
+		return fmt.Sprintf("nova-compute on %s has never reported a heartbeat", s.Host)
+	}
+	if age := time.Duration(s.HeartbeatAgeSeconds) * time.Second; maxAge > 0 && age > maxAge {
+		return fmt.Sprintf("nova-compute on %s last reported %v ago (threshold %v)", s.Host, age, maxAge)
+	}
+	return ""
+}
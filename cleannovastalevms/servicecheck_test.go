@@ -0,0 +1,27 @@
+package cleannovastalevms
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		state ComputeServiceState
+		stale bool
+	}{
+		{name: "fresh", state: ComputeServiceState{Host: "compute-1", State: "up", HeartbeatAgeSeconds: 30}},
+		{name: "old heartbeat", state: ComputeServiceState{Host: "compute-1", State: "up", HeartbeatAgeSeconds: 600}, stale: true},
+		{name: "down", state: ComputeServiceState{Host: "compute-1", State: "down"}, stale: true},
+		{name: "never reported", state: ComputeServiceState{Host: "compute-1", State: "up", NeverReported: true}, stale: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.state.staleReason(2 * time.Minute)
+			if got := reason != ""; got != tt.stale {
+				t.Errorf("got reason %q, want stale: %v", reason, tt.stale)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// DependentVM is a server booted from the image being deleted
+type DependentVM struct {
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Project string `json:"project"`
+	Status  string `json:"status"`
+}
+
+// deleteImage deletes cfg.Image after a typed confirmation or cfg.Yes. An image that is still
+// the boot source of VMs is refused unless cfg.Force, because those VMs could no longer be
+// rebuilt; the dependent VMs are listed either way.
+func deleteImage(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, cfg Config) error {
+	if !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for delete; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
+	img, err := findImage(ctx, imageClient, cfg.Image)
+	if err != nil {
+		return err
+	}
+	dependents, err := imageDependents(ctx, authClient, img.ID)
+	if err != nil {
+		return err
+	}
+	// With --force, JSON and YAML give the dependents in the delete result, so stdout holds a
+	// single document
+	if len(dependents) > 0 && (cfg.OutputFormat == "table" || !cfg.Force) {
+		result := &output.Result{
+			Headers: []string{"VM", "VM ID", "Project", "Status"},
+			Data:    dependents,
+		}
+		for _, vm := range dependents {
+			result.AddRow(vm.Name, vm.ID, vm.Project, vm.Status)
+		}
+		if cfg.OutputFormat == "table" {
+			fmt.Printf("Image %s (ID: %s) is the boot source of %d VM(s):\n", img.Name, img.ID, len(dependents))
+		}
+		if err := output.Print(cfg.OutputFormat, result); err != nil {
+			return errors.Wrap(err, "failed to print dependent VMs")
+		}
+		if !cfg.Force {
+			return fmt.Errorf("image '%s' (ID: %s) is in use by %d VM(s); use --force to delete it anyway", img.Name, img.ID, len(dependents))
+		}
+	}
+	if len(dependents) > 0 {
+		log.Warnf("Deleting image %s although %d VM(s) were booted from it (--force)", img.ID, len(dependents))
+	}
+
+	if !cfg.Yes && !confirmImageChange(fmt.Sprintf("Image %s (ID: %s) will be deleted.", img.Name, img.ID)) {
+		log.Info("Delete aborted by user")
+		return nil
+	}
+	if err := images.Delete(ctx, imageClient, img.ID).ExtractErr(); err != nil {
+		return errors.Wrapf(err, "failed to delete image '%s' (ID: %s)", img.Name, img.ID)
+	}
+	result := &output.Result{
+		Headers: []string{"Name", "ID", "Outcome", "Dependent VMs"},
+		Data: struct {
+			Name         string        `json:"name"`
+			ID           string        `json:"id"`
+			Outcome      string        `json:"outcome"`
+			DependentVMs []DependentVM `json:"dependent_vms"`
+		}{img.Name, img.ID, "deleted", dependents},
+	}
+	result.AddRow(img.Name, img.ID, "deleted", len(dependents))
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print delete result")
+	}
+	return nil
+}
+
+// imageDependents lists the servers booted from imageID. Servers of every project are scanned;
+// when the token may not list other projects, only the token's own project is.
+func imageDependents(ctx context.Context, authClient *auth.Client, imageID string) ([]DependentVM, error) {
+	allPages, err := servers.List(authClient.Compute, servers.ListOpts{AllTenants: true, Image: imageID}).AllPages(ctx)
+	if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		log.Warnf("Not allowed to list servers of all projects; checking only the current project for VMs using image %s", imageID)
+		allPages, err = servers.List(authClient.Compute, servers.ListOpts{Image: imageID}).AllPages(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list servers using image %s", imageID)
+	}
+	serverList, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract servers")
+	}
+
+	projectNames, err := fetchProjectNames(ctx, authClient.Identity)
+	if err != nil {
+		log.Warnf("Failed to fetch project names: %v, using project IDs", err)
+	}
+	dependents := []DependentVM{}
+	for _, s := range serverList {
+		// Volume-backed servers have no image; the filter should exclude them, but check anyway
+		if id, ok := s.Image["id"].(string); !ok || id != imageID {
+			continue
+		}
+		project := projectNames[s.TenantID]
+		if project == "" {
+			project = s.TenantID
+		}
+		dependents = append(dependents, DependentVM{Name: s.Name, ID: s.ID, Project: project, Status: s.Status})
+	}
+	sort.Slice(dependents, func(i, j int) bool {
+		if dependents[i].Project != dependents[j].Project {
+			return dependents[i].Project < dependents[j].Project
+		}
+		return strings.ToLower(dependents[i].Name) < strings.ToLower(dependents[j].Name)
+	})
+	log.Debugf("Found %d VMs booted from image %s", len(dependents), imageID)
+	return dependents, nil
+}
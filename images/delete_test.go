@@ -0,0 +1,73 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+// newFakeImageCloud serves image img-1, which the VM build-1 was booted from, and accepts its
+// deletion
+func newFakeImageCloud(t *testing.T) (*auth.Client, *gophercloud.ServiceClient) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/images/img-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "img-1", "name": "rhel-8-old", "status": "active"}`)
+	})
+	mux.HandleFunc("/compute/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"servers": [{"id": "vm-101", "name": "build-1", "status": "ACTIVE", "tenant_id": "p1", "image": {"id": "img-1"}}]}`)
+	})
+	mux.HandleFunc("/identity/v3/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"projects": [{"id": "p1", "name": "proj1"}], "links": {}}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	authClient := &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+		Compute:  &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/compute/"},
+	}
+	return authClient, &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/v2/"}
+}
+
+func TestDeleteImageForceJSON(t *testing.T) {
+	authClient, imageClient := newFakeImageCloud(t)
+	cfg := Config{Image: "img-1", Force: true, Yes: true, OutputFormat: "json"}
+	var err error
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		err = deleteImage(context.Background(), authClient, imageClient, cfg)
+	})
+	if err != nil {
+		t.Fatalf("deleteImage: %v", err)
+	}
+	var result struct {
+		Outcome      string        `json:"outcome"`
+		DependentVMs []DependentVM `json:"dependent_vms"`
+	}
+	dec := json.NewDecoder(strings.NewReader(stdout))
+	if err := dec.Decode(&result); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if dec.More() {
+		t.Errorf("stdout holds more than one JSON document:\n%s", stdout)
+	}
+	if result.Outcome != "deleted" || len(result.DependentVMs) != 1 || result.DependentVMs[0].Name != "build-1" {
+		t.Errorf("got result %+v, want deleted with dependent VM build-1", result)
+	}
+}
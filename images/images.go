@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
-	"text/tabwriter"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
@@ -19,6 +20,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 // Logger for structured logging
@@ -31,8 +34,21 @@ type Config struct {
 	OutputFormat string
 	Action       string
 	Timeout      time.Duration
-	Limit        int  // Limit number of images to fetch
-	Long         bool // Show WWN and Size in table output
+	Limit        int           // Stop pagination after this many images for list and list-all (0 for no cap)
+	PageSize     int           // Images requested per Glance page (0 for the server default)
+	Long         bool          // Show WWN and Size in table output
+	Summary      bool          // Print image count and Glance size per project after list-all
+	OlderThan    time.Duration // Only images created more than this long ago, for list, list-all and usage (0 for all)
+	Image        string        // Image name or ID for set-visibility, set-owner, update and delete
+	Visibility   string        // New visibility for set-visibility: public, private, shared or community
+	ToProject    string        // Name of the new owner project for set-owner
+	NewName      string        // update: new image name, "" to keep it
+	Description  *string       // update: new description, "" to remove it; nil to keep it
+	MinDisk      *int          // update: new minimum disk in GB; nil to keep it
+	MinRAM       *int          // update: new minimum RAM in MB; nil to keep it
+	Force        bool          // delete: delete an image that VMs were booted from
+	Yes          bool          // delete and set-visibility to public: skip the confirmation prompt
+	Strict       bool          // list, list-all and usage: fail when volume details of any image could not be fetched
 }
 
 // ImageDetails holds the details of an image for output
@@ -42,17 +58,57 @@ type ImageDetails struct {
 	Size        int    `json:"size"`
 	WWN         string `json:"wwn"`
 	ProjectName string `json:"project_name"`
+	// Problems fetching the backing volume, which leave VolumeName, WWN and Size incomplete
+	Warnings  []string `json:"warnings"`
+	ownerID   string   // Image owner project ID, the grouping key for the usage action
+	sizeBytes int64    // Image data size in the Glance store, summed by list-all --summary
+}
+
+// BrokenImage describes a block_device_mapping reference to a volume that is missing or in error
+// state (status broken), or whose volume could not be looked up (status unknown)
+type BrokenImage struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	ProjectName string `json:"project_name"`
+	VolumeID    string `json:"volume_id"`
+	Status      string `json:"status"`
+	Problem     string `json:"problem"`
+}
+
+// Values of BrokenImage.Status
+const (
+	ImageBroken  = "broken"
+	ImageUnknown = "unknown"
+)
+
+// gib is the number of bytes in the GB unit used for volume sizes
+const gib = 1 << 30
+
+// ErrBrokenImages is returned by the validate action when at least one broken image was found
+var ErrBrokenImages = errors.New("broken images found")
+
+// strictCheck returns an error for --strict when any image has warnings
+func strictCheck(imageDetails []ImageDetails, strict bool) error {
+	if !strict {
+		return nil
+	}
+	withWarnings := 0
+	for _, img := range imageDetails {
+		if len(img.Warnings) > 0 {
+			withWarnings++
+		}
+	}
+	if withWarnings > 0 {
+		return fmt.Errorf("%d image(s) have incomplete volume details (--strict)", withWarnings)
+	}
+	return nil
 }
 
 // Run executes the image management logic
 func Run(ctx context.Context, client *auth.Client, cfg Config) error {
-	log.Debugf("Starting image management with config: Verbose=%v, ProjectName=%s, OutputFormat=%s, Action=%s, Timeout=%v, Long=%v, Limit=%d",
-		cfg.Verbose, cfg.ProjectName, cfg.OutputFormat, cfg.Action, cfg.Timeout, cfg.Long, cfg.Limit)
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	if cfg.Verbose {
-		log.SetLevel(logrus.DebugLevel)
-	}
+	log.Debugf("Starting image management with config: Verbose=%v, ProjectName=%s, OutputFormat=%s, Action=%s, Timeout=%v, Long=%v, Limit=%d, PageSize=%d",
+		cfg.Verbose, cfg.ProjectName, cfg.OutputFormat, cfg.Action, cfg.Timeout, cfg.Long, cfg.Limit, cfg.PageSize)
+	util.SetupLogger(log, cfg.Verbose)
 
 	// Apply timeout to context
 	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
@@ -60,21 +116,21 @@ func Run(ctx context.Context, client *auth.Client, cfg Config) error {
 
 	// Initialize image service client
 	log.Debug("Initializing image service client")
-	imageClient, err := newImageClient(client.Provider)
+	imageClient, err := newImageClient(client)
 	if err != nil {
 		log.Debugf("Failed to initialize image client: %v", err)
 		return errors.Wrap(err, "failed to initialize image service client")
 	}
 
 	// Validate action
-	validActions := []string{"list", "list-all"}
+	validActions := []string{"list", "list-all", "validate", "usage", "list-shared", "set-visibility", "set-owner", "update", "delete"}
 	if !contains(validActions, cfg.Action) {
 		log.Debugf("Invalid action detected: %s", cfg.Action)
 		return fmt.Errorf("invalid action: %s; valid actions: %v", cfg.Action, validActions)
 	}
 
 	switch cfg.Action {
-	case "list":
+	case "list", "list-shared":
 		if cfg.ProjectName == "" {
 			cfg.ProjectName = os.Getenv("OS_PROJECT_NAME")
 			if cfg.ProjectName == "" {
@@ -82,11 +138,33 @@ func Run(ctx context.Context, client *auth.Client, cfg Config) error {
 				return fmt.Errorf("project name must be provided via --project or OS_PROJECT_NAME")
 			}
 		}
+		if cfg.Action == "list-shared" {
+			log.Debugf("Executing list-shared action for project: %s", cfg.ProjectName)
+			return listSharedImages(ctx, client, imageClient, cfg.ProjectName, cfg.OutputFormat, cfg.Limit, cfg.PageSize)
+		}
 		log.Debugf("Executing list action for project: %s", cfg.ProjectName)
-		return listImages(ctx, client, imageClient, cfg.ProjectName, cfg.OutputFormat, cfg.Limit, cfg.Long)
+		return listImages(ctx, client, imageClient, cfg.ProjectName, cfg.OutputFormat, cfg.Limit, cfg.PageSize, cfg.Long, cfg.OlderThan, cfg.Strict)
 	case "list-all":
 		log.Debug("Executing list-all action")
-		return listAllImages(ctx, client, imageClient, cfg.OutputFormat, cfg.Limit, cfg.Long)
+		return listAllImages(ctx, client, imageClient, cfg.OutputFormat, cfg.Limit, cfg.PageSize, cfg.Long, cfg.Summary, cfg.OlderThan, cfg.Strict)
+	case "validate":
+		log.Debug("Executing validate action")
+		return validateImages(ctx, client, imageClient, cfg.ProjectName, cfg.OutputFormat, cfg.PageSize)
+	case "usage":
+		log.Debug("Executing usage action")
+		return imageUsage(ctx, client, imageClient, cfg.OutputFormat, cfg.Limit, cfg.PageSize, cfg.OlderThan, cfg.Strict)
+	case "set-visibility":
+		log.Debugf("Executing set-visibility action for image: %s", cfg.Image)
+		return setVisibility(ctx, imageClient, cfg)
+	case "set-owner":
+		log.Debugf("Executing set-owner action for image: %s", cfg.Image)
+		return setOwner(ctx, client, imageClient, cfg)
+	case "update":
+		log.Debugf("Executing update action for image: %s", cfg.Image)
+		return updateImage(ctx, imageClient, cfg)
+	case "delete":
+		log.Debugf("Executing delete action for image: %s", cfg.Image)
+		return deleteImage(ctx, client, imageClient, cfg)
 	default:
 		log.Debugf("Unsupported action encountered: %s", cfg.Action)
 		return fmt.Errorf("unsupported action: %s", cfg.Action)
@@ -105,12 +183,13 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func newImageClient(provider *gophercloud.ProviderClient) (*gophercloud.ServiceClient, error) {
+func newImageClient(client *auth.Client) (*gophercloud.ServiceClient, error) {
 	log.Debug("Creating new Image V2 client")
 	endpointOpts := gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: client.Availability,
 	}
-	imageClient, err := openstack.NewImageV2(provider, endpointOpts)
+	imageClient, err := openstack.NewImageV2(client.Provider, endpointOpts)
 	if err != nil {
 		log.Debugf("Failed to create image v2 client: %v", err)
 		return nil, errors.Wrap(err, "failed to create image v2 client")
@@ -176,8 +255,8 @@ func fetchProjectNames(ctx context.Context, identityClient *gophercloud.ServiceC
 	return projectMap, nil
 }
 
-func listImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, projectName, outputFormat string, limit int, long bool) error {
-	log.Debugf("Listing images for project: %s, OutputFormat: %s, Limit: %d, Long: %v", projectName, outputFormat, limit, long)
+func listImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, projectName, outputFormat string, limit, pageSize int, long bool, olderThan time.Duration, strict bool) error {
+	log.Debugf("Listing images for project: %s, OutputFormat: %s, Limit: %d, PageSize: %d, Long: %v", projectName, outputFormat, limit, pageSize, long)
 	// Get project ID
 	projectID, err := getProjectID(ctx, authClient, projectName)
 	if err != nil {
@@ -190,27 +269,20 @@ func listImages(ctx context.Context, authClient *auth.Client, imageClient *gophe
 	log.Debug("Initializing volume client")
 	volumeClient, err := auth.NewBlockStorageV3Client(authClient)
 	if err != nil {
+		if strict {
+			return errors.Wrap(err, "failed to initialize volume client (--strict)")
+		}
 		log.Warnf("Failed to initialize volume client: %v, proceeding without volume details", err)
 	}
 
 	// List images for the specific project
-	log.Debugf("Listing images with opts: Owner=%s, Limit=%d", projectID, limit)
+	log.Debugf("Listing images with opts: Owner=%s, PageSize=%d", projectID, pageSize)
 	listOpts := images.ListOpts{
-		Owner: projectID,
-		Limit: limit,
+		Owner:          projectID,
+		Limit:          pageSize,
+		CreatedAtQuery: createdBefore(olderThan),
 	}
-	var projectImages []images.Image
-	err = images.List(imageClient, listOpts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
-		log.Debug("Processing image list page")
-		imageList, err := images.ExtractImages(page)
-		if err != nil {
-			log.Debugf("Failed to extract images from page: %v", err)
-			return false, err
-		}
-		log.Debugf("Extracted %d images from page", len(imageList))
-		projectImages = append(projectImages, imageList...)
-		return true, nil
-	})
+	projectImages, err := collectImages(ctx, images.List(imageClient, listOpts), limit)
 	if err != nil {
 		log.Debugf("Failed to list images for project %s: %v", projectName, err)
 		return errors.Wrapf(err, "failed to list images for project %s", projectName)
@@ -221,52 +293,22 @@ func listImages(ctx context.Context, authClient *auth.Client, imageClient *gophe
 	log.Debug("Processing images concurrently")
 	imageDetails := processImages(ctx, volumeClient, projectImages, projectName, nil)
 
-	// Output results
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output")
-		data, err := json.MarshalIndent(imageDetails, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
-		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		if long {
-			fmt.Fprintln(w, "Name\tVolume Name\tSize\tWWN\tProject Name")
-		} else {
-			fmt.Fprintln(w, "Name\tVolume Name\tProject Name")
-		}
-		for _, img := range imageDetails {
-			volumeName := img.VolumeName
-			if volumeName == "" {
-				volumeName = "N/A"
-			}
-			if long {
-				wwn := img.WWN
-				if wwn == "" {
-					wwn = "N/A"
-				}
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
-					img.Name, volumeName, img.Size, wwn, img.ProjectName)
-			} else {
-				fmt.Fprintf(w, "%s\t%s\t%s\n",
-					img.Name, volumeName, img.ProjectName)
-			}
-		}
-		w.Flush()
+	if err := printImages(imageDetails, outputFormat, long, nil, fmt.Sprintf("No images found in project %s.", projectName)); err != nil {
+		return err
 	}
 	log.Debug("Image listing completed")
-	return nil
+	return strictCheck(imageDetails, strict)
 }
 
-func listAllImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, outputFormat string, limit int, long bool) error {
-	log.Debugf("Listing all images with OutputFormat: %s, Limit: %d, Long: %v", outputFormat, limit, long)
+func listAllImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, outputFormat string, limit, pageSize int, long, summary bool, olderThan time.Duration, strict bool) error {
+	log.Debugf("Listing all images with OutputFormat: %s, Limit: %d, PageSize: %d, Long: %v", outputFormat, limit, pageSize, long)
 	// Initialize volume client
 	log.Debug("Initializing volume client for all images")
 	volumeClient, err := auth.NewBlockStorageV3Client(authClient)
 	if err != nil {
+		if strict {
+			return errors.Wrap(err, "failed to initialize volume client (--strict)")
+		}
 		log.Warnf("Failed to initialize volume client: %v, proceeding without volume details", err)
 	}
 
@@ -278,22 +320,12 @@ func listAllImages(ctx context.Context, authClient *auth.Client, imageClient *go
 	}
 
 	// List all images
-	log.Debugf("Listing all images with limit: %d", limit)
+	log.Debugf("Listing all images with page size: %d", pageSize)
 	listOpts := images.ListOpts{
-		Limit: limit,
+		Limit:          pageSize,
+		CreatedAtQuery: createdBefore(olderThan),
 	}
-	var allImages []images.Image
-	err = images.List(imageClient, listOpts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
-		log.Debug("Processing all images page")
-		imageList, err := images.ExtractImages(page)
-		if err != nil {
-			log.Debugf("Failed to extract images from page: %v", err)
-			return false, err
-		}
-		log.Debugf("Extracted %d images from page", len(imageList))
-		allImages = append(allImages, imageList...)
-		return true, nil
-	})
+	allImages, err := collectImages(ctx, images.List(imageClient, listOpts), limit)
 	if err != nil {
 		log.Debugf("Failed to list all images: %v", err)
 		return errors.Wrap(err, "failed to list all images")
@@ -304,43 +336,79 @@ func listAllImages(ctx context.Context, authClient *auth.Client, imageClient *go
 	log.Debug("Processing all images concurrently")
 	imageDetails := processImages(ctx, volumeClient, allImages, "", projectNames)
 
-	// Output results
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for all images")
-		data, err := json.MarshalIndent(imageDetails, "", "  ")
+	var totals *imageTotals
+	if summary {
+		totals = summarizeImages(imageDetails)
+	}
+	if err := printImages(imageDetails, outputFormat, long, totals, "No images found."); err != nil {
+		return err
+	}
+	log.Debug("All images listing completed")
+	return strictCheck(imageDetails, strict)
+}
+
+// collectImages walks pager and returns up to limit of its images (0 for no limit). It stops at
+// the page that brings the total past limit, so at most one page beyond limit is requested, and
+// only warns about truncated results when that page held more images.
+func collectImages(ctx context.Context, pager pagination.Pager, limit int) ([]images.Image, error) {
+	var collected []images.Image
+	truncated := false
+	err := pager.EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		imageList, err := images.ExtractImages(page)
 		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
+			log.Debugf("Failed to extract images from page: %v", err)
+			return false, err
 		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for all images")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		if long {
-			fmt.Fprintln(w, "Name\tVolume Name\tSize\tWWN\tProject Name")
-		} else {
-			fmt.Fprintln(w, "Name\tVolume Name\tProject Name")
+		log.Debugf("Extracted %d images from page", len(imageList))
+		collected = append(collected, imageList...)
+		if limit > 0 && len(collected) > limit {
+			collected = collected[:limit]
+			truncated = true
+			return false, nil
 		}
-		for _, img := range imageDetails {
-			volumeName := img.VolumeName
-			if volumeName == "" {
-				volumeName = "N/A"
-			}
-			if long {
-				wwn := img.WWN
-				if wwn == "" {
-					wwn = "N/A"
-				}
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
-					img.Name, volumeName, img.Size, wwn, img.ProjectName)
-			} else {
-				fmt.Fprintf(w, "%s\t%s\t%s\n",
-					img.Name, volumeName, img.ProjectName)
+		return true, nil
+	})
+	if truncated {
+		log.Warnf("Stopped listing after %d images (--limit or --max-results); more images exist", limit)
+	}
+	return collected, err
+}
+
+// printImages prints image details; the long form adds Size and WWN columns. Tables print
+// empty instead of an empty listing, and totals, when given, after the listing.
+func printImages(imageDetails []ImageDetails, outputFormat string, long bool, totals *imageTotals, empty string) error {
+	log.Debugf("Preparing %s output for %d images", outputFormat, len(imageDetails))
+	result := &output.Result{Headers: []string{"Name", "Volume Name", "Project Name", "Warnings"}, Data: imageDetails, Empty: empty}
+	if long {
+		result.Headers = []string{"Name", "Volume Name", "Size", "WWN", "Project Name", "Warnings"}
+	}
+	for _, img := range imageDetails {
+		volumeName := img.VolumeName
+		if volumeName == "" {
+			volumeName = "N/A"
+		}
+		if long {
+			wwn := img.WWN
+			if wwn == "" {
+				wwn = "N/A"
 			}
+			result.AddRow(img.Name, volumeName, img.Size, wwn, img.ProjectName, len(img.Warnings))
+		} else {
+			result.AddRow(img.Name, volumeName, img.ProjectName, len(img.Warnings))
 		}
-		w.Flush()
 	}
-	log.Debug("All images listing completed")
+	if totals != nil {
+		result.Data = struct {
+			Images  []ImageDetails `json:"images"`
+			Summary *imageTotals   `json:"summary"`
+		}{append([]ImageDetails{}, imageDetails...), totals}
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print images")
+	}
+	if totals != nil && outputFormat == "table" {
+		return printImageTotals(totals, outputFormat)
+	}
 	return nil
 }
 
@@ -357,7 +425,17 @@ func processImages(ctx context.Context, volumeClient *gophercloud.ServiceClient,
 			defer wg.Done()
 			log.Debugf("Processing image: %s (ID: %s)", img.Name, img.ID)
 			detail := ImageDetails{
-				Name: img.Name,
+				Name:      img.Name,
+				Warnings:  []string{},
+				ownerID:   img.Owner,
+				sizeBytes: img.SizeBytes,
+			}
+			// warn logs a problem with this image and records it in its details; every goroutine
+			// appends only to its own detail, so no locking is needed
+			warn := func(format string, args ...interface{}) {
+				message := fmt.Sprintf(format, args...)
+				log.Warn(message)
+				detail.Warnings = append(detail.Warnings, message)
 			}
 
 			// Assign project name
@@ -380,9 +458,9 @@ func processImages(ctx context.Context, volumeClient *gophercloud.ServiceClient,
 			// Get volume details
 			if volumeClient != nil {
 				log.Debugf("Fetching volume details for image %s", img.Name)
-				volumeName, volumeWwn, volSize, err := getAssociatedVolumeName(ctx, volumeClient, img, &volumeCache)
+				volumeName, volumeWwn, volSize, err := getAssociatedVolumeName(ctx, volumeClient, img, &volumeCache, warn)
 				if err != nil {
-					log.Warnf("Failed to get volume for image %s: %v", img.Name, err)
+					warn("Failed to get volume for image %s: %v", img.Name, err)
 				} else if volumeName != "" {
 					log.Debugf("Found volume details: Name=%s, WWN=%s, Size=%d", volumeName, volumeWwn, volSize)
 					detail.VolumeName = volumeName
@@ -390,6 +468,11 @@ func processImages(ctx context.Context, volumeClient *gophercloud.ServiceClient,
 					detail.Size = volSize
 				}
 			}
+			if detail.Size == 0 && img.VirtualSize > 0 {
+				// No backing volume: fall back to the image's virtual size, rounded up to whole GB
+				detail.Size = int((img.VirtualSize + gib - 1) / gib)
+				log.Debugf("Using virtual size of image %s: %d GB", img.Name, detail.Size)
+			}
 
 			log.Debugf("Completed processing image %s", img.Name)
 			imageDetailsChan <- detail
@@ -413,7 +496,7 @@ func processImages(ctx context.Context, volumeClient *gophercloud.ServiceClient,
 	return imageDetails
 }
 
-func getAssociatedVolumeName(ctx context.Context, volumeClient *gophercloud.ServiceClient, img images.Image, volumeCache *sync.Map) (string, string, int, error) {
+func getAssociatedVolumeName(ctx context.Context, volumeClient *gophercloud.ServiceClient, img images.Image, volumeCache *sync.Map, warn func(format string, args ...interface{})) (string, string, int, error) {
 	log.Debugf("Looking for volume associated with image %s (ID: %s)", img.Name, img.ID)
 	// Check if block_device_mapping exists
 	blockMappingRaw, exists := img.Properties["block_device_mapping"]
@@ -423,14 +506,14 @@ func getAssociatedVolumeName(ctx context.Context, volumeClient *gophercloud.Serv
 	}
 	blockMappingStr, ok := blockMappingRaw.(string)
 	if !ok {
-		log.Warnf("block_device_mapping for image %s is not a string: %v", img.Name, blockMappingRaw)
+		warn("block_device_mapping for image %s is not a string: %v", img.Name, blockMappingRaw)
 		return "", "", 0, nil
 	}
 
 	var volID string
 	var blockMappings []map[string]interface{}
 	if err := json.Unmarshal([]byte(blockMappingStr), &blockMappings); err != nil {
-		log.Warnf("Failed to unmarshal block_device_mapping for image %s: %v", img.Name, err)
+		warn("Failed to unmarshal block_device_mapping for image %s: %v", img.Name, err)
 		return "", "", 0, nil
 	}
 	if len(blockMappings) > 0 {
@@ -459,7 +542,7 @@ func getAssociatedVolumeName(ctx context.Context, volumeClient *gophercloud.Serv
 	log.Debugf("Querying volume with ID: %s", volID)
 	vol, err := volumes.Get(ctx, volumeClient, volID).Extract()
 	if err != nil {
-		log.Warnf("Failed to get volume %s for image %s: %v", volID, img.Name, err)
+		warn("Failed to get volume %s for image %s: %v", volID, img.Name, err)
 		return "", "", 0, nil
 	}
 
@@ -468,9 +551,189 @@ func getAssociatedVolumeName(ctx context.Context, volumeClient *gophercloud.Serv
 	volumeCache.Store(volID, vol)
 	wwn, ok := vol.Metadata["volume_wwn"]
 	if !ok {
-		log.Warnf("No volume_wwn found in metadata for volume %s", vol.Name)
+		warn("No volume_wwn found in metadata for volume %s", vol.Name)
 		wwn = ""
 	}
 	log.Debugf("Matched volume %s to image %s via volume_id", vol.Name, img.Name)
 	return vol.Name, wwn, vol.Size, nil
 }
+
+// blockDeviceVolumeIDs returns the volume IDs referenced by an image's block_device_mapping
+func blockDeviceVolumeIDs(img images.Image) ([]string, error) {
+	blockMappingRaw, exists := img.Properties["block_device_mapping"]
+	if !exists {
+		return nil, nil
+	}
+	blockMappingStr, ok := blockMappingRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("block_device_mapping is not a string: %v", blockMappingRaw)
+	}
+	var blockMappings []map[string]interface{}
+	if err := json.Unmarshal([]byte(blockMappingStr), &blockMappings); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal block_device_mapping")
+	}
+	var ids []string
+	for _, mapping := range blockMappings {
+		if id, ok := mapping["volume_id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// checkVolume returns a description of the problem with a referenced volume, or "" when it is usable
+func checkVolume(ctx context.Context, volumeClient *gophercloud.ServiceClient, volID string) (string, error) {
+	vol, err := volumes.Get(ctx, volumeClient, volID).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return "volume not found", nil
+		}
+		return "", err
+	}
+	if strings.HasPrefix(vol.Status, "error") {
+		return fmt.Sprintf("volume in %s state", vol.Status), nil
+	}
+	return "", nil
+}
+
+// validateImages reports images whose block_device_mapping references missing or errored volumes
+func validateImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, projectName, outputFormat string, pageSize int) error {
+	log.Debugf("Validating images for project: %q, OutputFormat: %s, PageSize: %d", projectName, outputFormat, pageSize)
+	volumeClient, err := auth.NewBlockStorageV3Client(authClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize volume client")
+	}
+
+	listOpts := images.ListOpts{Limit: pageSize}
+	if projectName != "" {
+		projectID, err := getProjectID(ctx, authClient, projectName)
+		if err != nil {
+			return err
+		}
+		listOpts.Owner = projectID
+	}
+	projectNames, err := fetchProjectNames(ctx, authClient.Identity)
+	if err != nil {
+		log.Warnf("Failed to fetch project names: %v, using 'Unknown' as fallback", err)
+	}
+
+	var imageList []images.Image
+	err = images.List(imageClient, listOpts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		pageImages, err := images.ExtractImages(page)
+		if err != nil {
+			return false, err
+		}
+		imageList = append(imageList, pageImages...)
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list images")
+	}
+	log.Debugf("Validating %d images", len(imageList))
+
+	// Check each referenced volume once, concurrently
+	refs := make(map[string][]images.Image)
+	for _, img := range imageList {
+		ids, err := blockDeviceVolumeIDs(img)
+		if err != nil {
+			log.Warnf("Skipping image %s: %v", img.Name, err)
+			continue
+		}
+		for _, id := range ids {
+			refs[id] = append(refs[id], img)
+		}
+	}
+	volIDs := make([]string, 0, len(refs))
+	for volID := range refs {
+		volIDs = append(volIDs, volID)
+	}
+	problems := checkVolumes(ctx, volumeClient, volIDs)
+
+	broken := []BrokenImage{}
+	unknown := 0
+	for volID, problem := range problems {
+		for _, img := range refs[volID] {
+			owner := "Unknown"
+			if name, ok := projectNames[img.Owner]; ok {
+				owner = name
+			}
+			broken = append(broken, BrokenImage{Name: img.Name, ID: img.ID, ProjectName: owner, VolumeID: volID, Status: problem.status, Problem: problem.message})
+			if problem.status == ImageUnknown {
+				unknown++
+			}
+		}
+	}
+	sort.Slice(broken, func(i, j int) bool {
+		if broken[i].Name != broken[j].Name {
+			return broken[i].Name < broken[j].Name
+		}
+		return broken[i].VolumeID < broken[j].VolumeID
+	})
+
+	result := &output.Result{
+		Headers: []string{"Name", "ID", "Project Name", "Volume ID", "Status", "Problem"},
+		Data: struct {
+			ImagesChecked int           `json:"images_checked"`
+			BrokenImages  []BrokenImage `json:"broken_images"`
+		}{len(imageList), broken},
+		Empty: fmt.Sprintf("✅ No broken images found (%d images checked)", len(imageList)),
+	}
+	for _, b := range broken {
+		result.AddRow(b.Name, b.ID, b.ProjectName, b.VolumeID, b.Status, b.Problem)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print broken images")
+	}
+	if outputFormat == "table" && len(broken) > 0 {
+		fmt.Printf("Broken images: %d of %d checked\n", len(broken)-unknown, len(imageList))
+		if unknown > 0 {
+			fmt.Printf("Unknown images: %d (volume lookup failed)\n", unknown)
+		}
+	}
+	if len(broken) > unknown {
+		return ErrBrokenImages
+	}
+	if unknown > 0 {
+		return fmt.Errorf("could not validate %d image(s): volume lookup failed", unknown)
+	}
+	return nil
+}
+
+// volumeProblem is what checkVolumes found for one volume
+type volumeProblem struct {
+	status  string // ImageBroken or ImageUnknown
+	message string
+}
+
+// checkVolumes looks up each volume once, concurrently, and returns the problems by volume ID.
+// A volume whose lookup fails for any reason other than not found is reported as unknown; only
+// volumes that were fetched and found healthy are left out.
+func checkVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient, volIDs []string) map[string]volumeProblem {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	problems := make(map[string]volumeProblem)
+	for _, volID := range volIDs {
+		wg.Add(1)
+		go func(volID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			problem, err := checkVolume(ctx, volumeClient, volID)
+			if err != nil {
+				log.Warnf("Failed to check volume %s: %v", volID, err)
+				mu.Lock()
+				problems[volID] = volumeProblem{ImageUnknown, fmt.Sprintf("volume lookup failed: %v", err)}
+				mu.Unlock()
+				return
+			}
+			if problem != "" {
+				mu.Lock()
+				problems[volID] = volumeProblem{ImageBroken, problem}
+				mu.Unlock()
+			}
+		}(volID)
+	}
+	wg.Wait()
+	return problems
+}
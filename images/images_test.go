@@ -0,0 +1,198 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+)
+
+// newFakeGlance serves pages of two images each from a fake Glance and records which pages
+// were requested
+func newFakeGlance(t *testing.T, pages int) (*requestedPages, *gophercloud.ServiceClient) {
+	t.Helper()
+	requested := &requestedPages{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if marker := r.URL.Query().Get("marker"); marker != "" {
+			fmt.Sscanf(marker, "page-%d", &page)
+		}
+		requested.add(page)
+		next := ""
+		if page < pages {
+			next = fmt.Sprintf(`"next": "/v2/images?marker=page-%d",`, page+1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{%s "images": [{"id": "%d-a", "name": "image-%d-a"}, {"id": "%d-b", "name": "image-%d-b"}]}`,
+			next, page, page, page, page)
+	}))
+	t.Cleanup(srv.Close)
+	client := &gophercloud.ServiceClient{ProviderClient: &gophercloud.ProviderClient{}, Endpoint: srv.URL + "/v2/"}
+	return requested, client
+}
+
+// requestedPages records the pages the fake Glance served, in order
+type requestedPages struct {
+	mu    sync.Mutex
+	pages []int
+}
+
+func (p *requestedPages) add(page int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pages = append(p.pages, page)
+}
+
+func TestCollectImagesStopsAtLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		wantCount     int
+		wantPages     []int
+		wantTruncated bool
+	}{
+		{name: "no limit", limit: 0, wantCount: 8, wantPages: []int{1, 2, 3, 4}},
+		{name: "limit within first page", limit: 1, wantCount: 1, wantPages: []int{1}, wantTruncated: true},
+		{name: "limit at page boundary", limit: 2, wantCount: 2, wantPages: []int{1, 2}, wantTruncated: true},
+		{name: "limit within second page", limit: 3, wantCount: 3, wantPages: []int{1, 2}, wantTruncated: true},
+		{name: "limit of all images", limit: 8, wantCount: 8, wantPages: []int{1, 2, 3, 4}},
+		{name: "limit beyond all pages", limit: 20, wantCount: 8, wantPages: []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged strings.Builder
+			log.SetOutput(&logged)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+			requested, client := newFakeGlance(t, 4)
+			got, err := collectImages(context.Background(), images.List(client, images.ListOpts{Limit: 2}), tt.limit)
+			if err != nil {
+				t.Fatalf("collectImages: %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("got %d images, want %d", len(got), tt.wantCount)
+			}
+			if pages := requested.pages; fmt.Sprint(pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("requested pages %v, want %v", pages, tt.wantPages)
+			}
+			if got := strings.Contains(logged.String(), "more images exist"); got != tt.wantTruncated {
+				t.Errorf("got truncation warning %v, want %v", got, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+// newFakeCinder serves volumes vol-0 to vol-3; vol-3 has no WWN, vol-error is in error state,
+// looking up vol-broken-api fails with a server error and any other ID is not found
+func newFakeCinder(t *testing.T) *gophercloud.ServiceClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/volume/v3/volumes/")
+		w.Header().Set("Content-Type", "application/json")
+		switch id {
+		case "vol-0", "vol-1", "vol-2":
+			fmt.Fprintf(w, `{"volume": {"id": %q, "name": "name-%s", "size": 10, "metadata": {"volume_wwn": "wwn-%s"}}}`, id, id, id)
+		case "vol-3":
+			fmt.Fprintf(w, `{"volume": {"id": %q, "name": "name-%s", "size": 10, "metadata": {}}}`, id, id)
+		case "vol-error":
+			fmt.Fprintf(w, `{"volume": {"id": %q, "name": "name-%s", "size": 10, "status": "error"}}`, id, id)
+		case "vol-broken-api":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"itemNotFound": {"message": "Volume could not be found."}}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &gophercloud.ServiceClient{ProviderClient: &gophercloud.ProviderClient{}, Endpoint: srv.URL + "/volume/v3/"}
+}
+
+// imageWithVolume returns an image whose block_device_mapping references volID
+func imageWithVolume(name, volID string) images.Image {
+	return images.Image{
+		ID:         "id-" + name,
+		Name:       name,
+		Owner:      "p1",
+		Properties: map[string]interface{}{"block_device_mapping": fmt.Sprintf(`[{"volume_id": %q}]`, volID)},
+	}
+}
+
+// TestProcessImagesWarningsConcurrent processes many images at once that share volumes, so the
+// volume cache and the warnings recorded per image are exercised concurrently; run with -race
+func TestProcessImagesWarningsConcurrent(t *testing.T) {
+	volumeClient := newFakeCinder(t)
+	var imageList []images.Image
+	wantWarnings := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("image-%03d", i)
+		switch i % 5 {
+		case 0, 1, 2:
+			imageList = append(imageList, imageWithVolume(name, fmt.Sprintf("vol-%d", i%3)))
+		case 3:
+			imageList = append(imageList, imageWithVolume(name, "vol-3")) // No WWN
+			wantWarnings[name] = 1
+		case 4:
+			imageList = append(imageList, imageWithVolume(name, "vol-missing"))
+			wantWarnings[name] = 1
+		}
+	}
+	malformed := images.Image{ID: "id-malformed", Name: "malformed", Properties: map[string]interface{}{"block_device_mapping": "not json"}}
+	imageList = append(imageList, malformed)
+	wantWarnings["malformed"] = 1
+
+	details := processImages(context.Background(), volumeClient, imageList, "demo", nil)
+	if len(details) != len(imageList) {
+		t.Fatalf("got %d image details, want %d", len(details), len(imageList))
+	}
+	for _, d := range details {
+		if len(d.Warnings) != wantWarnings[d.Name] {
+			t.Errorf("image %s has warnings %q, want %d", d.Name, d.Warnings, wantWarnings[d.Name])
+		}
+		for _, w := range d.Warnings {
+			if strings.Contains(w, "image ") && !strings.Contains(w, "image "+d.Name+":") && !strings.HasSuffix(w, "image "+d.Name) {
+				t.Errorf("image %s has a warning of another image: %q", d.Name, w)
+			}
+		}
+		if d.ProjectName != "demo" {
+			t.Errorf("image %s has project %q, want demo", d.Name, d.ProjectName)
+		}
+	}
+}
+
+func TestCheckVolumes(t *testing.T) {
+	volumeClient := newFakeCinder(t)
+	problems := checkVolumes(context.Background(), volumeClient, []string{"vol-0", "vol-error", "vol-missing", "vol-broken-api"})
+	want := map[string]string{
+		"vol-error":      ImageBroken,
+		"vol-missing":    ImageBroken,
+		"vol-broken-api": ImageUnknown,
+	}
+	if len(problems) != len(want) {
+		t.Errorf("got problems %v, want %v", problems, want)
+	}
+	for volID, status := range want {
+		if problems[volID].status != status {
+			t.Errorf("volume %s has status %q (%s), want %q", volID, problems[volID].status, problems[volID].message, status)
+		}
+	}
+}
+
+func TestCollectImagesCancelledContext(t *testing.T) {
+	requested, client := newFakeGlance(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := collectImages(ctx, images.List(client, images.ListOpts{Limit: 2}), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if len(got) != 0 || len(requested.pages) != 0 {
+		t.Errorf("got %d images from pages %v, want none requested", len(got), requested.pages)
+	}
+}
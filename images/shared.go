@@ -0,0 +1,101 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/members"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// SharedImage is an image another project shared with the listed project
+type SharedImage struct {
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OwnerProject string `json:"owner_project"`
+	OwnerID      string `json:"owner_id"`
+	MemberStatus string `json:"member_status"` // accepted or pending
+}
+
+// listSharedImages lists the images of other projects on which projectName is a member with
+// status accepted or pending. Glance's member_status filter only applies to the token's own
+// project, so the membership of projectName is read from the members API for each shared image.
+func listSharedImages(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, projectName, outputFormat string, limit, pageSize int) error {
+	log.Debugf("Listing images shared with project: %s", projectName)
+	projectID, err := getProjectID(ctx, authClient, projectName)
+	if err != nil {
+		return err
+	}
+
+	listOpts := images.ListOpts{
+		Visibility:   images.ImageVisibilityShared,
+		MemberStatus: images.ImageMemberStatusAll,
+		Limit:        pageSize,
+	}
+	sharedImages, err := collectImages(ctx, images.List(imageClient, listOpts), limit)
+	if err != nil {
+		return errors.Wrap(err, "failed to list shared images")
+	}
+	log.Debugf("Fetched %d shared images", len(sharedImages))
+
+	projectNames, err := fetchProjectNames(ctx, authClient.Identity)
+	if err != nil {
+		log.Warnf("Failed to fetch project names: %v, using 'Unknown' as fallback", err)
+	}
+
+	result := []SharedImage{}
+	for _, img := range sharedImages {
+		if img.Owner == projectID {
+			continue
+		}
+		member, err := members.Get(ctx, imageClient, img.ID, projectID).Extract()
+		if err != nil {
+			if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				continue // Shared with other projects only
+			}
+			log.Warnf("Failed to get membership of image %s: %v", img.ID, err)
+			continue
+		}
+		if member.Status != string(images.ImageMemberStatusAccepted) && member.Status != string(images.ImageMemberStatusPending) {
+			continue
+		}
+		owner := projectNames[img.Owner]
+		if owner == "" {
+			owner = "Unknown"
+		}
+		result = append(result, SharedImage{
+			Name:         img.Name,
+			ID:           img.ID,
+			Status:       string(img.Status),
+			OwnerProject: owner,
+			OwnerID:      img.Owner,
+			MemberStatus: member.Status,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].OwnerProject != result[j].OwnerProject {
+			return result[i].OwnerProject < result[j].OwnerProject
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	out := &output.Result{
+		Headers: []string{"Name", "ID", "Status", "Owner Project", "Member Status"},
+		Data:    result,
+		Empty:   fmt.Sprintf("No images shared with project %s.", projectName),
+	}
+	for _, s := range result {
+		out.AddRow(s.Name, s.ID, s.Status, s.OwnerProject, s.MemberStatus)
+	}
+	if err := output.Print(outputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print shared images")
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// ImageChange reports one property changed by set-visibility, set-owner or update
+type ImageChange struct {
+	Name  string `json:"name"`
+	ID    string `json:"id"`
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// validVisibilities are the --visibility values accepted by set-visibility
+var validVisibilities = []images.ImageVisibility{
+	images.ImageVisibilityPublic,
+	images.ImageVisibilityPrivate,
+	images.ImageVisibilityShared,
+	images.ImageVisibilityCommunity,
+}
+
+// ValidateVisibility rejects --visibility values Glance does not know
+func ValidateVisibility(visibility string) error {
+	for _, v := range validVisibilities {
+		if string(v) == visibility {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid visibility '%s'; valid: public, private, shared, community", visibility)
+}
+
+// replaceImageOwner is the Glance patch that moves an image to another project; gophercloud
+// has no patch type for /owner
+type replaceImageOwner struct {
+	owner string
+}
+
+func (r replaceImageOwner) ToImagePatchMap() map[string]any {
+	return map[string]any{
+		"op":    "replace",
+		"path":  "/owner",
+		"value": r.owner,
+	}
+}
+
+// findImage resolves --image as an image ID, or else as a unique image name
+func findImage(ctx context.Context, imageClient *gophercloud.ServiceClient, nameOrID string) (*images.Image, error) {
+	img, err := images.Get(ctx, imageClient, nameOrID).Extract()
+	if err == nil {
+		return img, nil
+	}
+	if !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return nil, errors.Wrapf(err, "failed to get image %s", nameOrID)
+	}
+	log.Debugf("No image with ID %s, looking it up by name", nameOrID)
+	matches, err := collectImages(ctx, images.List(imageClient, images.ListOpts{Name: nameOrID}), 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list images named %s", nameOrID)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no image found with name or ID '%s'", nameOrID)
+	case 1:
+		return &matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, m := range matches {
+			ids = append(ids, m.ID)
+		}
+		return nil, fmt.Errorf("%d images are named '%s' (%s); use the image ID", len(matches), nameOrID, strings.Join(ids, ", "))
+	}
+}
+
+// setVisibility changes the visibility of an image. Making an image public exposes it to every
+// project in the cloud, so that requires a typed confirmation or cfg.Yes, and Glance only lets
+// admins do it by default.
+func setVisibility(ctx context.Context, imageClient *gophercloud.ServiceClient, cfg Config) error {
+	if err := ValidateVisibility(cfg.Visibility); err != nil {
+		return err
+	}
+	public := cfg.Visibility == string(images.ImageVisibilityPublic)
+	if public && !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for set-visibility to public; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
+	img, err := findImage(ctx, imageClient, cfg.Image)
+	if err != nil {
+		return err
+	}
+	change := ImageChange{Name: img.Name, ID: img.ID, Field: "visibility", Old: string(img.Visibility), New: cfg.Visibility}
+	if change.Old == change.New {
+		log.Infof("Image %s (ID: %s) is already %s", img.Name, img.ID, cfg.Visibility)
+		return printImageChange(change, cfg.OutputFormat)
+	}
+	if public && !cfg.Yes && !confirmImageChange(fmt.Sprintf("Image %s (ID: %s) will become visible to all projects.", img.Name, img.ID)) {
+		log.Info("Visibility change aborted by user")
+		return nil
+	}
+	log.Debugf("Changing visibility of image %s from %s to %s", img.ID, change.Old, change.New)
+	updated, err := images.Update(ctx, imageClient, img.ID, images.UpdateOpts{
+		images.UpdateVisibility{Visibility: images.ImageVisibility(cfg.Visibility)},
+	}).Extract()
+	if public && gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return fmt.Errorf("not allowed to make image '%s' (ID: %s) public; publicizing images requires the admin role", img.Name, img.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to set visibility of image '%s' (ID: %s)", img.Name, img.ID)
+	}
+	change.New = string(updated.Visibility)
+	return printImageChange(change, cfg.OutputFormat)
+}
+
+// setOwner moves an image to the project cfg.ToProject. Glance only lets admins change the
+// owner, so a 403 is reported as such.
+func setOwner(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, cfg Config) error {
+	projectID, err := getProjectID(ctx, authClient, cfg.ToProject)
+	if err != nil {
+		return err
+	}
+	img, err := findImage(ctx, imageClient, cfg.Image)
+	if err != nil {
+		return err
+	}
+	oldOwner := img.Owner
+	if project, err := projects.Get(ctx, authClient.Identity, img.Owner).Extract(); err == nil {
+		oldOwner = project.Name
+	} else {
+		log.Warnf("Failed to get project name for ID %s: %v", img.Owner, err)
+	}
+	change := ImageChange{Name: img.Name, ID: img.ID, Field: "owner", Old: oldOwner, New: cfg.ToProject}
+	if img.Owner == projectID {
+		log.Infof("Image %s (ID: %s) is already owned by %s", img.Name, img.ID, cfg.ToProject)
+		return printImageChange(change, cfg.OutputFormat)
+	}
+	log.Debugf("Changing owner of image %s from %s to %s", img.ID, img.Owner, projectID)
+	_, err = images.Update(ctx, imageClient, img.ID, images.UpdateOpts{replaceImageOwner{owner: projectID}}).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return fmt.Errorf("not allowed to change the owner of image '%s' (ID: %s); set-owner requires the admin role", img.Name, img.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner of image '%s' (ID: %s)", img.Name, img.ID)
+	}
+	return printImageChange(change, cfg.OutputFormat)
+}
+
+// updateImage changes the name, description, minimum disk and minimum RAM of an image in one
+// Glance PATCH. Only the fields that are set and differ from the image are sent; an image
+// that already has all the given values is left alone.
+func updateImage(ctx context.Context, imageClient *gophercloud.ServiceClient, cfg Config) error {
+	img, err := findImage(ctx, imageClient, cfg.Image)
+	if err != nil {
+		return err
+	}
+	var patch images.UpdateOpts
+	changes := []ImageChange{}
+	change := func(field, old, new string, p images.Patch) {
+		if old == new {
+			log.Infof("Image %s (ID: %s) already has %s %q", img.Name, img.ID, field, new)
+			return
+		}
+		changes = append(changes, ImageChange{Name: img.Name, ID: img.ID, Field: field, Old: old, New: new})
+		patch = append(patch, p)
+	}
+	if cfg.NewName != "" {
+		change("name", img.Name, cfg.NewName, images.ReplaceImageName{NewName: cfg.NewName})
+	}
+	if cfg.Description != nil {
+		old, _ := img.Properties["description"].(string)
+		p := images.UpdateImageProperty{Op: images.AddOp, Name: "description", Value: *cfg.Description}
+		if *cfg.Description == "" {
+			p = images.UpdateImageProperty{Op: images.RemoveOp, Name: "description"}
+		}
+		change("description", old, *cfg.Description, p)
+	}
+	if cfg.MinDisk != nil {
+		change("min_disk", strconv.Itoa(img.MinDiskGigabytes), strconv.Itoa(*cfg.MinDisk), images.ReplaceImageMinDisk{NewMinDisk: *cfg.MinDisk})
+	}
+	if cfg.MinRAM != nil {
+		change("min_ram", strconv.Itoa(img.MinRAMMegabytes), strconv.Itoa(*cfg.MinRAM), images.ReplaceImageMinRam{NewMinRam: *cfg.MinRAM})
+	}
+	if len(patch) == 0 {
+		return printImageChanges(changes, cfg.OutputFormat)
+	}
+
+	log.Debugf("Updating %d fields of image %s", len(patch), img.ID)
+	updated, err := images.Update(ctx, imageClient, img.ID, patch).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return fmt.Errorf("not allowed to update image '%s' (ID: %s); only its owner or an admin may", img.Name, img.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to update image '%s' (ID: %s)", img.Name, img.ID)
+	}
+	// Report what Glance stored rather than what was asked for
+	for i := range changes {
+		switch changes[i].Field {
+		case "name":
+			changes[i].New = updated.Name
+		case "description":
+			changes[i].New, _ = updated.Properties["description"].(string)
+		case "min_disk":
+			changes[i].New = strconv.Itoa(updated.MinDiskGigabytes)
+		case "min_ram":
+			changes[i].New = strconv.Itoa(updated.MinRAMMegabytes)
+		}
+	}
+	return printImageChanges(changes, cfg.OutputFormat)
+}
+
+func confirmImageChange(warning string) bool {
+	fmt.Printf("%s Type 'confirm' to continue: ", warning)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "confirm"
+}
+
+// printImageChange prints the old and new value of the changed property
+func printImageChange(change ImageChange, outputFormat string) error {
+	result := &output.Result{
+		Headers: []string{"Name", "ID", "Field", "Change"},
+		Data:    change,
+	}
+	result.AddRow(change.Name, change.ID, change.Field, fmt.Sprintf("%s → %s", change.Old, change.New))
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print image change")
+	}
+	return nil
+}
+
+// printImageChanges prints the old and new value of every property update changed
+func printImageChanges(changes []ImageChange, outputFormat string) error {
+	result := &output.Result{
+		Headers: []string{"Name", "ID", "Field", "Change"},
+		Data:    changes,
+		Empty:   "Nothing to change.",
+	}
+	for _, c := range changes {
+		result.AddRow(c.Name, c.ID, c.Field, fmt.Sprintf("%s → %s", c.Old, c.New))
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print image changes")
+	}
+	return nil
+}
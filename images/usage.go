@@ -0,0 +1,171 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// ProjectUsage is the image storage billed to one owner project
+type ProjectUsage struct {
+	ProjectName string `json:"project_name"`
+	ProjectID   string `json:"project_id"`
+	ImageCount  int    `json:"image_count"`
+	TotalGB     int    `json:"total_gb"`
+}
+
+// imageUsage sums image sizes per owner project, largest first. Sizes are those of list-all:
+// the associated volume's size, or else the image's virtual size.
+func imageUsage(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, outputFormat string, limit, pageSize int, olderThan time.Duration, strict bool) error {
+	log.Debugf("Computing image usage with OutputFormat: %s, Limit: %d, PageSize: %d", outputFormat, limit, pageSize)
+	volumeClient, err := auth.NewBlockStorageV3Client(authClient)
+	if err != nil {
+		if strict {
+			return errors.Wrap(err, "failed to initialize volume client (--strict)")
+		}
+		log.Warnf("Failed to initialize volume client: %v, using image virtual sizes only", err)
+	}
+	projectNames, err := fetchProjectNames(ctx, authClient.Identity)
+	if err != nil {
+		log.Warnf("Failed to fetch project names: %v, using 'Unknown' as fallback", err)
+	}
+
+	allImages, err := collectImages(ctx, images.List(imageClient, images.ListOpts{Limit: pageSize, CreatedAtQuery: createdBefore(olderThan)}), limit)
+	if err != nil {
+		return errors.Wrap(err, "failed to list all images")
+	}
+	log.Debugf("Total images fetched: %d", len(allImages))
+	imageDetails := processImages(ctx, volumeClient, allImages, "", projectNames)
+
+	byProject := make(map[string]*ProjectUsage)
+	for _, img := range imageDetails {
+		u, ok := byProject[img.ownerID]
+		if !ok {
+			u = &ProjectUsage{ProjectName: img.ProjectName, ProjectID: img.ownerID}
+			byProject[img.ownerID] = u
+		}
+		u.ImageCount++
+		u.TotalGB += img.Size
+	}
+	usage := make([]ProjectUsage, 0, len(byProject))
+	totalGB := 0
+	for _, u := range byProject {
+		usage = append(usage, *u)
+		totalGB += u.TotalGB
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].TotalGB != usage[j].TotalGB {
+			return usage[i].TotalGB > usage[j].TotalGB
+		}
+		return usage[i].ProjectName < usage[j].ProjectName
+	})
+
+	result := &output.Result{
+		Headers: []string{"Project", "Images", "Total GB"},
+		Data:    usage,
+		Empty:   "No images found.",
+	}
+	for _, u := range usage {
+		result.AddRow(u.ProjectName, u.ImageCount, u.TotalGB)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print image usage")
+	}
+	if outputFormat == "table" && len(usage) > 0 {
+		fmt.Printf("\nTotal: %d images, %d GB in %d projects\n", len(imageDetails), totalGB, len(usage))
+	}
+	return strictCheck(imageDetails, strict)
+}
+
+// imageTotals is the list-all --summary: image count and Glance store size per owner project
+type imageTotals struct {
+	Projects       []projectImageTotals `json:"projects"`
+	TotalImages    int                  `json:"total_images"`
+	TotalSizeBytes int64                `json:"total_size_bytes"`
+}
+
+// projectImageTotals is one project's line of imageTotals
+type projectImageTotals struct {
+	ProjectName string `json:"project_name"`
+	ProjectID   string `json:"project_id"`
+	ImageCount  int    `json:"image_count"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// summarizeImages totals the Glance size of details per owner project, largest first. Unlike
+// the usage action it counts only image data stored in Glance, not backing volumes.
+func summarizeImages(details []ImageDetails) *imageTotals {
+	byProject := make(map[string]*projectImageTotals)
+	totals := &imageTotals{Projects: []projectImageTotals{}}
+	for _, img := range details {
+		pt, ok := byProject[img.ownerID]
+		if !ok {
+			pt = &projectImageTotals{ProjectName: img.ProjectName, ProjectID: img.ownerID}
+			byProject[img.ownerID] = pt
+		}
+		pt.ImageCount++
+		pt.SizeBytes += img.sizeBytes
+		totals.TotalImages++
+		totals.TotalSizeBytes += img.sizeBytes
+	}
+	for _, pt := range byProject {
+		totals.Projects = append(totals.Projects, *pt)
+	}
+	sort.Slice(totals.Projects, func(i, j int) bool {
+		if totals.Projects[i].SizeBytes != totals.Projects[j].SizeBytes {
+			return totals.Projects[i].SizeBytes > totals.Projects[j].SizeBytes
+		}
+		return totals.Projects[i].ProjectName < totals.Projects[j].ProjectName
+	})
+	return totals
+}
+
+// printImageTotals prints the per-project summary table and grand total of list-all --summary
+// after a table listing; JSON and YAML carry the totals in the listing itself
+func printImageTotals(totals *imageTotals, outputFormat string) error {
+	fmt.Println()
+	if len(totals.Projects) > 0 {
+		result := &output.Result{Headers: []string{"Project", "Images", "Size (GB)"}, Data: totals.Projects}
+		for _, pt := range totals.Projects {
+			result.AddRow(pt.ProjectName, pt.ImageCount, fmt.Sprintf("%.2f", float64(pt.SizeBytes)/gib))
+		}
+		if err := output.Print(outputFormat, result); err != nil {
+			return errors.Wrap(err, "failed to print image totals")
+		}
+	}
+	fmt.Printf("Total: %d images, %.2f GB in %d projects\n", totals.TotalImages, float64(totals.TotalSizeBytes)/gib, len(totals.Projects))
+	return nil
+}
+
+// createdBefore returns the Glance filter for images created more than olderThan ago, or nil for 0
+func createdBefore(olderThan time.Duration) *images.ImageDateQuery {
+	if olderThan <= 0 {
+		return nil
+	}
+	return &images.ImageDateQuery{Date: time.Now().Add(-olderThan), Filter: images.FilterLT}
+}
+
+// ParseAge parses an --older-than value: a number of days such as "365d", or a Go duration such as "72h"
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s': use days such as 365d or a duration such as 72h", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s': use days such as 365d or a duration such as 72h", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,37 @@
+// Package testutil holds helpers shared by the tests of the command packages.
+package testutil
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// CaptureOutput returns what fn writes to os.Stdout and os.Stderr
+func CaptureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	// redirect points f at a pipe and returns a function that restores f and returns what
+	// was written to the pipe
+	redirect := func(f **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := *f
+		*f = w
+		done := make(chan string)
+		go func() {
+			out, _ := io.ReadAll(r)
+			done <- string(out)
+		}()
+		return func() string {
+			*f = saved
+			w.Close()
+			return <-done
+		}
+	}
+	restoreStdout := redirect(&os.Stdout)
+	restoreStderr := redirect(&os.Stderr)
+	fn()
+	return restoreStdout(), restoreStderr()
+}
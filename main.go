@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/check"
 	"github.com/sudeeshjohn/openstack-tool/cleannovastalevms"
 	"github.com/sudeeshjohn/openstack-tool/images"
+	"github.com/sudeeshjohn/openstack-tool/output"
 	"github.com/sudeeshjohn/openstack-tool/storage"
 	"github.com/sudeeshjohn/openstack-tool/user"
+	"github.com/sudeeshjohn/openstack-tool/util"
 	"github.com/sudeeshjohn/openstack-tool/vm"
 	"github.com/sudeeshjohn/openstack-tool/volume"
 )
@@ -20,45 +26,138 @@ func main() {
 	// Define subcommands
 	vmInfoCmd := pflag.NewFlagSet("vm info", pflag.ExitOnError)
 	verbose := vmInfoCmd.Bool("verbose", false, "Enable verbose logging")
-	filter := vmInfoCmd.String("filter", "", "Filter VMs (e.g., host=host1,email=user@example.com)")
-	output := vmInfoCmd.String("output", "table", "Output format (table or json)")
+	filter := vmInfoCmd.String("filter", "", "Filter VMs (e.g., host=host1,az=zone1,email=user@example.com,user=svc-backup); @path reads conditions from a file")
+	output := vmInfoCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(vmInfoCmd)
 	useFlavorCache := vmInfoCmd.Bool("use-flavor-cache", false, "Use flavor cache")
+	infoLong := vmInfoCmd.Bool("long", false, "Add the Availability Zone column to table and CSV output")
+	infoResolveFIPs := vmInfoCmd.Bool("resolve-fips", false, "List floating IPs from Neutron and add them to the Fixed IP column marked (fip)")
+	infoNoEmail := vmInfoCmd.Bool("no-email", false, "Only list VMs whose owner has no email on file (combines with --filter)")
+	infoDeleted := vmInfoCmd.Bool("deleted", false, "Also list deleted VMs, from a second query, with a Deleted At column (admin only; none if the deployment purges deleted rows)")
+	infoOutputFile := vmInfoCmd.String("output-file", "", "Also write the inventory as JSON to this file, for a later --diff-against")
+	infoDiffAgainst := vmInfoCmd.String("diff-against", "", "Print VMs added, removed or changed since this earlier --output-file inventory")
+	infoWatch := vmInfoCmd.Bool("watch", false, "Rerun the query every --watch-interval until interrupted, clearing the screen on a terminal")
+	infoWatchInterval := vmInfoCmd.Duration("watch-interval", 30*time.Second, "Time between runs with --watch")
+	timeZone := vmInfoCmd.String("time-zone", "Local", "IANA time zone for Created and Updated in table output (e.g., UTC, Europe/Berlin)")
 	timeout := vmInfoCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	infoAuth := addAuthFlags(vmInfoCmd).addMaxRetriesFlag(vmInfoCmd)
 
 	vmManageCmd := pflag.NewFlagSet("vm manage", pflag.ExitOnError)
 	manageVerbose := vmManageCmd.Bool("verbose", false, "Enable verbose logging")
 	manageVM := vmManageCmd.String("vm", "", "VM name(s) or ID(s), comma-separated (e.g., vm1,vm2)")
 	manageProject := vmManageCmd.String("project", "", "Project name")
+	manageFilter := vmManageCmd.String("filter", "", "Select the project's VMs with a vm info filter (e.g., days>60,status=ACTIVE) instead of --vm")
 	manageDryRun := vmManageCmd.Bool("dry-run", false, "Perform a dry run without making changes")
-	manageOutput := vmManageCmd.String("output", "table", "Output format (table or json)")
+	manageOutput := vmManageCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(vmManageCmd)
 	manageTimeout := vmManageCmd.Int("timeout", 300, "Timeout in seconds for API operations")
 	manageState := vmManageCmd.String("state", "", "Desired state for set-state action (ACTIVE or ERROR)")
+	manageLines := vmManageCmd.Int("lines", 50, "Number of console lines to fetch for console-log (0 for the whole log)")
+	manageConsoleType := vmManageCmd.String("console-type", "novnc", "Console type for console-url (novnc or serial)")
+	manageMetadata := vmManageCmd.StringArray("metadata", nil, "Metadata key=value for set-metadata, or key for unset-metadata (repeatable)")
+	manageConcurrency := vmManageCmd.Int("concurrency", 5, "Number of VMs processed in parallel (1 processes VMs in the given order)")
+	manageYes := vmManageCmd.Bool("yes", false, "Skip the confirmation prompt before delete, force-delete, set-state and --filter actions")
+	manageProtectedFile := vmManageCmd.String("protected-file", "", "File listing protected VM names, IDs or glob patterns (default ~/.config/openstack-tool/protected-vms.yaml)")
+	manageOverrideProtection := vmManageCmd.Bool("override-protection", false, "Allow destructive actions on protected VMs after an extra typed confirmation")
+	manageShowTiming := vmManageCmd.Bool("show-timing", false, "Show when each action started and how long it took in table and CSV output")
+	manageShowRequestID := vmManageCmd.Bool("show-request-id", false, "Show the Nova request ID (x-openstack-request-id) of each action in table and CSV output")
+	manageMaxRetries := vmManageCmd.Int("max-retries", 3, "Retries per VM after a transient failure (5xx or timeout); 4xx errors are never retried")
+	manageRetryDelay := vmManageCmd.Duration("retry-delay", 2*time.Second, "Delay before the first retry; each further retry waits one delay longer")
+	manageForce := vmManageCmd.Bool("force", false, "Run set-state on VMs that have a task in progress")
+	manageFailFast := vmManageCmd.Bool("fail-fast", false, "Stop at the first VM that cannot be found or fails, skipping the rest")
+	manageWait := vmManageCmd.Bool("wait", false, "Wait until each VM reaches the status of the action (e.g., SHUTOFF for stop)")
+	manageWaitTimeout := vmManageCmd.Duration("wait-timeout", 5*time.Minute, "Longest wait for one VM with --wait before marking it timeout (0 for only --timeout)")
+	manageAuth := addAuthFlags(vmManageCmd)
 
 	cleanNovaStaleVmsCmd := pflag.NewFlagSet("clean-nova-stale-vms", pflag.ExitOnError)
 	cleanVerbose := cleanNovaStaleVmsCmd.Bool("verbose", false, "Enable verbose logging")
 	userFlag := cleanNovaStaleVmsCmd.String("user", "", "SSH username")
-	passFlag := cleanNovaStaleVmsCmd.String("password", "", "SSH password")
+	passFlag := cleanNovaStaleVmsCmd.String("password", "", "SSH password; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt, as flags show in ps (optional when ssh-agent holds a key)")
 	ipFlag := cleanNovaStaleVmsCmd.String("ip", "", "Hypervisor IP address")
+	sshPortClean := cleanNovaStaleVmsCmd.Int("ssh-port", 22, "SSH port of the hypervisor")
+	sshBastionClean := cleanNovaStaleVmsCmd.String("ssh-bastion", "", "Connect to the hypervisor through this jump host (user@host[:port])")
 	dryRunClean := cleanNovaStaleVmsCmd.Bool("dry-run", false, "Perform a dry run without deleting VMs")
-	outputClean := cleanNovaStaleVmsCmd.String("output", "table", "Output format (table or json)")
+	outputClean := cleanNovaStaleVmsCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(cleanNovaStaleVmsCmd)
 	timeoutClean := cleanNovaStaleVmsCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	insecureHostKeyClean := cleanNovaStaleVmsCmd.Bool("insecure-host-key", false, "Skip SSH host key verification for the hypervisor (does not affect OpenStack TLS)")
+	cacheInventoryClean := cleanNovaStaleVmsCmd.String("cache-inventory", "", "Cache the OpenStack inventory of the hypervisor in this file for repeated runs")
+	cacheTTLClean := cleanNovaStaleVmsCmd.Duration("cache-ttl", 10*time.Minute, "Maximum age of cached inventory before it is refetched")
+	refreshClean := cleanNovaStaleVmsCmd.Bool("refresh", false, "Ignore cached inventory and refetch it from OpenStack")
+	checkServiceClean := cleanNovaStaleVmsCmd.Bool("check-service", false, "Refuse to compare when the hypervisor's nova-compute service is down or its heartbeat is stale")
+	maxHeartbeatAgeClean := cleanNovaStaleVmsCmd.Duration("max-heartbeat-age", 2*time.Minute, "Oldest nova-compute heartbeat accepted by --check-service")
+	quietClean := cleanNovaStaleVmsCmd.Bool("quiet", false, "Suppress progress messages on stderr")
+	projectClean := cleanNovaStaleVmsCmd.String("project", "", "Only list the servers of this project (name or ID) instead of every project")
+	includeDisabledClean := cleanNovaStaleVmsCmd.Bool("include-disabled", false, "Also list the servers of disabled projects with the OpenStack inventory")
+	parallelProjectsClean := cleanNovaStaleVmsCmd.Int("parallel-projects", 10, "Number of projects whose servers are listed concurrently; lower it on rate-limited clouds")
+	forceClean := cleanNovaStaleVmsCmd.Bool("force", false, "Compare even when --check-service finds nova-compute down or stale")
+	webhookURLClean := cleanNovaStaleVmsCmd.String("webhook-url", "", "POST a JSON summary of the comparison to this URL")
+	webhookOnClean := cleanNovaStaleVmsCmd.String("webhook-on", cleannovastalevms.WebhookStaleOnly, "When to post to --webhook-url: always or stale-only")
+	webhookTimeoutClean := cleanNovaStaleVmsCmd.Duration("webhook-timeout", 10*time.Second, "Timeout of each --webhook-url request")
+	cleanAuth := addAuthFlags(cleanNovaStaleVmsCmd)
 
 	userRolesCmd := pflag.NewFlagSet("user-roles", pflag.ExitOnError)
 	userVerbose := userRolesCmd.Bool("verbose", false, "Enable verbose logging")
-	userOutput := userRolesCmd.String("output", "table", "Output format (table or json)")
-	userAction := userRolesCmd.String("action", "list", "Action to perform (list, assign, remove, list-roles, list-users-by-role, list-user-roles-all-projects, list-users-in-project)")
+	userOutput := userRolesCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(userRolesCmd)
+	userAction := userRolesCmd.String("action", "list", "Action to perform (list, assign, remove, list-roles, list-users-by-role, list-user-roles-all-projects, list-users-in-project, export-assignments, import-assignments, create-role, delete-role, report-users, show-quota, set-quota)")
 	userName := userRolesCmd.String("user", "", "User name")
 	userProjectName := userRolesCmd.String("project", "", "Project name")
 	roleName := userRolesCmd.String("role", "", "Role name")
+	userDomain := userRolesCmd.String("user-domain", "", "Domain name or ID of the user (required when the user name exists in several domains)")
+	projectDomain := userRolesCmd.String("project-domain", "", "Domain name or ID of the project (required when the project name exists in several domains)")
+	userFile := userRolesCmd.String("file", "", "Role assignment file written by export-assignments and read by import-assignments")
+	userDescription := userRolesCmd.String("description", "", "Description of the role (for create-role)")
+	userForce := userRolesCmd.Bool("force", false, "Delete a role that still has assignments, after listing them (for delete-role)")
+	userWithCounts := userRolesCmd.Bool("with-counts", false, "Add the number of assignments of each role (for list-roles)")
+	userNamePrefix := userRolesCmd.String("name-prefix", "", "Only report users whose name starts with this prefix (for report-users)")
+	userCores := userRolesCmd.Int("cores", 0, "New compute cores quota, -1 for unlimited (for set-quota)")
+	userRAMGB := userRolesCmd.Int("ram-gb", 0, "New compute RAM quota in GB, -1 for unlimited (for set-quota)")
+	userInstances := userRolesCmd.Int("instances", 0, "New compute instances quota, -1 for unlimited (for set-quota)")
+	userVolumes := userRolesCmd.Int("volumes", 0, "New volumes quota, -1 for unlimited (for set-quota)")
+	userGigabytes := userRolesCmd.Int("gigabytes", 0, "New volume gigabytes quota, -1 for unlimited (for set-quota)")
+	userDryRun := userRolesCmd.Bool("dry-run", false, "Print the quota changes without making them (for set-quota)")
 	userTimeout := userRolesCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	userAuth := addAuthFlags(userRolesCmd).addMaxRetriesFlag(userRolesCmd)
 
 	vmCreateCmd := pflag.NewFlagSet("vm create", pflag.ExitOnError)
 	createVerbose := vmCreateCmd.Bool("verbose", false, "Enable verbose logging")
 	createTimeout := vmCreateCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	createStrict := vmCreateCmd.Bool("strict", false, "Stop instead of warning when the flavor does not fit on the chosen host")
+	createVerifySSH := vmCreateCmd.Bool("verify-ssh", false, "After the VM is ACTIVE, check that port 22 of its address accepts connections")
+	createVerifyTimeout := vmCreateCmd.Duration("verify-timeout", 2*time.Minute, "How long --verify-ssh keeps retrying")
+	createComputeHost := vmCreateCmd.String("compute-host", "", "Place the VM on this compute host (admin only); its availability zone is used and the zone and host prompts are skipped")
+	createOutput := vmCreateCmd.String("output", "table", "Output format for the result (table, json, csv or yaml)")
+	addNoHeaderFlag(vmCreateCmd)
+	vmCreateAuth := addAuthFlags(vmCreateCmd)
+
+	vmSelectProjectCmd := pflag.NewFlagSet("vm select-project", pflag.ExitOnError)
+	selectProjectVerbose := vmSelectProjectCmd.Bool("verbose", false, "Enable verbose logging")
+	selectProjectFormat := vmSelectProjectCmd.String("format", "text", "Output format for the chosen project (text or openrc)")
+	selectProjectTimeout := vmSelectProjectCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	vmSelectProjectAuth := addAuthFlags(vmSelectProjectCmd)
+
+	vmDiffCmd := pflag.NewFlagSet("vm diff", pflag.ExitOnError)
+	diffVerbose := vmDiffCmd.Bool("verbose", false, "Enable verbose logging")
+	diffOld := vmDiffCmd.String("old", "", "Earlier vm info JSON file")
+	diffNew := vmDiffCmd.String("new", "", "Later vm info JSON file")
+	diffOutput := vmDiffCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(vmDiffCmd)
 
 	createCmd := pflag.NewFlagSet("create", pflag.ExitOnError)
 	createCmdVerbose := createCmd.Bool("verbose", false, "Enable verbose logging")
 	createCmdTimeout := createCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	createCmdStrict := createCmd.Bool("strict", false, "Stop instead of warning when the flavor does not fit on the chosen host")
+	createCmdComputeHost := createCmd.String("compute-host", "", "Place the VM on this compute host (admin only); its availability zone is used and the zone and host prompts are skipped")
+	createAuth := addAuthFlags(createCmd)
+
+	checkCmd := pflag.NewFlagSet("check", pflag.ExitOnError)
+	checkVerbose := checkCmd.Bool("verbose", false, "Enable verbose logging")
+	checkServices := checkCmd.String("services", "", "Comma-separated services to probe (identity, compute, volume, image, network; default: all)")
+	checkOutput := checkCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(checkCmd)
+	checkTimeout := checkCmd.Int("timeout", 60, "Timeout in seconds for API operations")
+	checkAuth := addAuthFlags(checkCmd)
 
 	volumeCmd := pflag.NewFlagSet("volume", pflag.ExitOnError)
 	volumeCmd.Usage = func() {
@@ -72,38 +171,112 @@ func main() {
 		fmt.Println("    Change the status of specified volumes")
 		fmt.Println("  delete")
 		fmt.Println("    Delete specified volumes")
+		fmt.Println("  audit-attachments")
+		fmt.Println("    Report in-use volumes attached to servers that no longer exist in Nova")
+		fmt.Println("  snapshot report-orphans")
+		fmt.Println("    Report snapshots whose source volume no longer exists, with totals per project")
 		fmt.Println("Flags:")
 		fmt.Println("  --verbose          Enable verbose logging")
-		fmt.Println("  --output           Output format (table or json, default: table)")
+		fmt.Println("  --output           Output format (table, json, csv or yaml, default: table)")
+		fmt.Println("  --no-header        Omit the header row of table and CSV output")
 		fmt.Println("  --volume           Comma-separated volume names (required for change-status, delete)")
 		fmt.Println("  --project          Project name (required for list, change-status, delete; overrides OS_PROJECT_NAME)")
-		fmt.Println("  --status           Target status for volume (required for change-status, e.g., available, in-use)")
+		fmt.Println("                     list accepts a comma-separated list and merges the projects into one listing")
+		fmt.Println("  --strict           Fail when any project of a list with several projects cannot be listed")
+		fmt.Println("  --status           Target status for volume (required for change-status): available, in-use, error,")
+		fmt.Println("                     error_deleting, maintenance, reserved, detaching, attaching")
+		fmt.Println("  --force            Allow change-status to a status outside the list above; with delete, force-detach attachments")
+		fmt.Println("                     to servers that no longer exist, then force-delete (asks for confirmation)")
+		fmt.Println("  --yes              Skip the confirmation prompt of change-status and delete --force")
+		fmt.Println("  --dry-run          Show current -> target status per volume without changing it (for change-status, audit-attachments --fix,")
+		fmt.Println("                     snapshot report-orphans --delete, delete --force)")
+		fmt.Println("  --fail-fast        Stop at the first volume that cannot be found or changed (for change-status, delete)")
+		fmt.Println("  --all-projects     Audit volumes or snapshots in every project (for audit-attachments, snapshot report-orphans)")
+		fmt.Println("  --delete           Delete the orphaned snapshots after confirmation; with --dry-run only report them (for snapshot report-orphans)")
+		fmt.Println("  --fix              Force-detach dangling attachments and reset volumes to available after confirmation (for audit-attachments)")
 		fmt.Println("  --long             Show extended volume details (attached-to, wwn) for list and list-all")
 		fmt.Println("  --not-associated   Show only volumes not associated with images or VMs (for list and list-all)")
+		fmt.Println("  --attached-only    Show only volumes attached to a server, regardless of image (for list and list-all)")
+		fmt.Println("  --unattached-only  Show only volumes not attached to a server, regardless of image (for list and list-all)")
+		fmt.Println("  --az               Show only volumes in this availability zone (for list and list-all)")
+		fmt.Println("  --backend          Show only volumes whose backend host contains this; admin only (for list and list-all)")
+		fmt.Println("                     --attached-only cannot be combined with --unattached-only or --not-associated")
+		fmt.Println("  --show-association Add an Association column explaining --not-associated decisions; requires --long")
+		fmt.Println("  --skip-image-check Let --not-associated go on without image names when the image service is unavailable;")
+		fmt.Println("                     image-backed volumes may then be listed as not associated")
+		fmt.Println("  --summary          Print total volume count and size, per project for list-all (for list and list-all)")
+		fmt.Println("  --group-by         Print per-project count, size and unattached count instead of the volumes;")
+		fmt.Println("                     only 'project' (for list-all)")
+		fmt.Println("  --max-results      Stop fetching after this many volumes for list-all (default: 0, no cap)")
 		fmt.Println("  --timeout          Timeout in seconds for API operations (default: 300)")
+		fmt.Println("  --insecure         Skip TLS certificate verification for OpenStack API endpoints")
+		fmt.Println("  --request-timeout  Limit each OpenStack API request, e.g. 30s (default: 0, no per-request limit)")
+		fmt.Println("  --max-retries      Retries of an OpenStack read request after a 5xx response or timeout (default: 3)")
+		fmt.Println("  --os-interface     Endpoint interface: public, internal or admin (default: OS_INTERFACE, else public)")
 		fmt.Println("Examples:")
 		fmt.Println("  openstack-tool volume list --project=proj1 --not-associated --output=table")
+		fmt.Println("  openstack-tool volume list --project=proj1,proj2,proj3 --summary")
 		fmt.Println("  openstack-tool volume list-all --long --not-associated --output=json")
-		fmt.Println("  openstack-tool volume change-status --volume=vol1,vol2 --project=proj1 --status=available")
+		fmt.Println("  openstack-tool volume list-all --long --backend=cinder-01@pool-a")
+		fmt.Println("  openstack-tool volume change-status --volume=vol1,vol2 --project=proj1 --status=available --yes")
+		fmt.Println("  openstack-tool volume change-status --volume=vol1 --project=proj1 --status=available --dry-run --output=json")
+		fmt.Println("  openstack-tool volume audit-attachments --all-projects --fix")
+		fmt.Println("  openstack-tool volume snapshot report-orphans --all-projects --delete --dry-run")
 		fmt.Println("  openstack-tool volume delete --volume=vol1 --project=proj1")
 	}
 	volumeVerbose := volumeCmd.Bool("verbose", false, "Enable verbose logging")
-	volumeOutput := volumeCmd.String("output", "table", "Output format (table or json)")
+	volumeOutput := volumeCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(volumeCmd)
 	volumeNames := volumeCmd.String("volume", "", "Comma-separated volume names (required for change-status, delete)")
 	volumeProject := volumeCmd.String("project", "", "Project name (required for list, change-status, delete; overrides OS_PROJECT_NAME)")
 	volumeStatus := volumeCmd.String("status", "", "Target status for volume (e.g., available, in-use)")
 	volumeLong := volumeCmd.Bool("long", false, "Show extended volume details (attached-to, wwn) for list and list-all")
 	volumeNotAssociated := volumeCmd.Bool("not-associated", false, "Show only volumes not associated with images or VMs (for list and list-all)")
+	volumeAZ := volumeCmd.String("az", "", "Show only volumes in this availability zone (for list and list-all)")
+	volumeBackend := volumeCmd.String("backend", "", "Show only volumes whose backend host contains this, e.g. a pool name; admin only (for list and list-all)")
+	volumeSkipImageCheck := volumeCmd.Bool("skip-image-check", false, "Let --not-associated go on without image names when the image client cannot be created (image-backed volumes may be listed)")
+	volumeAttachedOnly := volumeCmd.Bool("attached-only", false, "Show only volumes attached to a server, regardless of image association (for list and list-all)")
+	volumeUnattachedOnly := volumeCmd.Bool("unattached-only", false, "Show only volumes not attached to any server, regardless of image association (for list and list-all)")
+	volumeShowAssociation := volumeCmd.Bool("show-association", false, "Add an Association column (image:<name>, server:<name> or none) to --long output (for list and list-all)")
+	volumeSummary := volumeCmd.Bool("summary", false, "Print total volume count and size after the listing (for list and list-all)")
+	volumeGroupBy := volumeCmd.String("group-by", "", "Print per-project volume count, size and unattached count instead of the volumes; only 'project' (for list-all)")
+	volumeForce := volumeCmd.Bool("force", false, "Allow change-status to a status outside the known set; for delete, force-detach stale attachments and force-delete")
+	volumeYes := volumeCmd.Bool("yes", false, "Skip the confirmation prompt of change-status, delete --force, audit-attachments --fix and snapshot report-orphans --delete")
+	volumeDryRun := volumeCmd.Bool("dry-run", false, "Show the current and target status of each volume without changing it (for change-status)")
+	volumeStrict := volumeCmd.Bool("strict", false, "Fail when any project of a list with several projects cannot be listed")
+	volumeFailFast := volumeCmd.Bool("fail-fast", false, "Stop at the first volume that cannot be found or changed (for change-status, delete)")
+	volumeMaxResults := volumeCmd.Int("max-results", 0, "Stop fetching after this many volumes for list-all (0 for no cap)")
+	volumeAllProjects := volumeCmd.Bool("all-projects", false, "Audit volumes in every project (for audit-attachments)")
+	volumeDelete := volumeCmd.Bool("delete", false, "Delete the orphaned snapshots after confirmation (for snapshot report-orphans)")
+	volumeFix := volumeCmd.Bool("fix", false, "Force-detach dangling attachments and reset volumes to available after confirmation (for audit-attachments)")
 	volumeTimeout := volumeCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	volumeAuth := addAuthFlags(volumeCmd).addMaxRetriesFlag(volumeCmd)
 
 	imagesCmd := pflag.NewFlagSet("images", pflag.ExitOnError)
 	imagesVerbose := imagesCmd.Bool("verbose", false, "Enable verbose logging")
 	imagesProject := imagesCmd.String("project", "", "Project name (overrides OS_PROJECT_NAME)")
-	imagesOutput := imagesCmd.String("output", "table", "Output format (table or json, default: table)")
-	imagesAction := imagesCmd.String("action", "list", "Action to perform (list, list-all)")
+	imagesOutput := imagesCmd.String("output", "table", "Output format (table, json, csv or yaml, default: table)")
+	addNoHeaderFlag(imagesCmd)
+	imagesAction := imagesCmd.String("action", "list", "Action to perform (list, list-all, validate, usage, list-shared, set-visibility, set-owner, update, delete)")
 	imagesTimeout := imagesCmd.Int("timeout", 300, "Timeout in seconds for API operations")
 	imagesLong := imagesCmd.Bool("long", false, "Show WWN and Size in table output")
-	imagesLimit := imagesCmd.Int("limit", 0, "Limit number of images to fetch (0 for no limit)")
+	imagesLimit := imagesCmd.Int("limit", 0, "Maximum number of images to return for list and list-all (0 for no limit)")
+	imagesPageSize := imagesCmd.Int("page-size", 0, "Images requested per API call (0 for the server default)")
+	imagesSummary := imagesCmd.Bool("summary", false, "Print image count and size per project after list-all")
+	imagesOlderThan := imagesCmd.String("older-than", "", "Only images created more than this long ago, e.g. 365d or 72h (for list, list-all, usage)")
+	imagesMaxResults := imagesCmd.Int("max-results", 0, "Stop fetching after this many images (0 for no cap)")
+	imagesCmd.MarkDeprecated("max-results", "use --limit")
+	imagesImage := imagesCmd.String("image", "", "Image name or ID (for set-visibility, set-owner, update, delete)")
+	imagesVisibility := imagesCmd.String("visibility", "", "New visibility: public, private, shared or community (for set-visibility)")
+	imagesNewName := imagesCmd.String("new-name", "", "New image name (for update)")
+	imagesDescription := imagesCmd.String("description", "", "New image description, empty to remove it (for update)")
+	imagesMinDisk := imagesCmd.Int("min-disk", 0, "New minimum disk size in GB (for update)")
+	imagesMinRAM := imagesCmd.Int("min-ram", 0, "New minimum RAM in MB (for update)")
+	imagesToProject := imagesCmd.String("to-project", "", "Name of the project to transfer the image to (for set-owner, admin only)")
+	imagesForce := imagesCmd.Bool("force", false, "Delete the image even when VMs were booted from it (for delete)")
+	imagesYes := imagesCmd.Bool("yes", false, "Skip the confirmation prompt (for delete and set-visibility to public)")
+	imagesStrict := imagesCmd.Bool("strict", false, "Exit non-zero when the volume details of any image could not be fetched (for list, list-all, usage)")
+	imagesAuth := addAuthFlags(imagesCmd).addMaxRetriesFlag(imagesCmd)
 
 	// Define vol subcommand
 	volCmd := pflag.NewFlagSet("vol", pflag.ExitOnError)
@@ -114,20 +287,110 @@ func main() {
 		fmt.Println("    List storage volumes")
 		fmt.Println("Flags:")
 		fmt.Println("  --ip               IP address or hostname of the Storage (required)")
+		fmt.Println("  --ssh-port         SSH port of the Storage (default: 22)")
+		fmt.Println("  --ssh-bastion      Connect to the Storage through this jump host (user@host[:port])")
 		fmt.Println("  --username         Username for SSH authentication (required)")
-		fmt.Println("  --password         Password for SSH authentication (required)")
+		fmt.Println("  --password         Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt (optional with ssh-agent)")
 		fmt.Println("  --long             Include ID, Capacity, Status, and Volume Type in detailed format")
-		fmt.Println("  --verbose          Display raw lsvdisk output only")
+		fmt.Println("  --output           Output format (table, json, csv or yaml, default: table)")
+		fmt.Println("  --verbose          Display raw lsvdisk output only, with debug logs on stderr")
+		fmt.Println("  --match-openstack  Match volumes to Cinder volumes by WWN and add OpenStack Volume and Attached VM columns")
+		fmt.Println("  --orphans-only     Show only array-only volumes and Cinder volumes that are not attached, with the orphan kind")
 		fmt.Println("  --timeout          Timeout in seconds for API operations (default: 300)")
+		fmt.Println("  --no-header        Omit the header line and dashed separator, e.g. when appending to a report")
+		fmt.Println("  --insecure-host-key  Skip SSH host key verification (host keys are checked against ~/.ssh/known_hosts by default; earlier releases did not check them)")
+		fmt.Println("  --insecure         Skip TLS certificate verification for OpenStack API endpoints")
+		fmt.Println("  --request-timeout  Limit each OpenStack API request, e.g. 30s (default: 0, no per-request limit)")
+		fmt.Println("  --max-retries      Retries of an OpenStack read request after a 5xx response or timeout (default: 3)")
+		fmt.Println("  --os-interface     Endpoint interface: public, internal or admin (default: OS_INTERFACE, else public)")
 		fmt.Println("Examples:")
 		fmt.Println("  openstack-tool storage vol list --ip=192.168.1.100 --username=admin --password=secret --long --timeout=300")
 	}
 	storageIP := volCmd.String("ip", "", "IP address or hostname of the Storage (required)")
+	storageSSHPort := volCmd.Int("ssh-port", 22, "SSH port of the Storage")
+	storageSSHBastion := volCmd.String("ssh-bastion", "", "Connect to the Storage through this jump host (user@host[:port])")
 	storageUsername := volCmd.String("username", "", "Username for SSH authentication (required)")
-	storagePassword := volCmd.String("password", "", "Password for SSH authentication (required)")
+	storagePassword := volCmd.String("password", "", "Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt, as flags show in ps (optional when ssh-agent holds a key)")
 	storageLong := volCmd.Bool("long", false, "Include ID, Capacity, Status, and Volume Type in detailed format")
-	storageVerbose := volCmd.Bool("verbose", false, "Display raw lsvdisk output only")
+	storageOutput := volCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	storageVerbose := volCmd.Bool("verbose", false, "Display raw lsvdisk output only, with debug logs on stderr")
 	storageTimeout := volCmd.Int("timeout", 300, "Timeout in seconds for API operations (default: 300)")
+	storageMatchOpenStack := volCmd.Bool("match-openstack", false, "Match volumes to Cinder volumes by WWN and show the VM each is attached to")
+	storageOrphansOnly := volCmd.Bool("orphans-only", false, "Show only volumes without a Cinder volume or whose Cinder volume is not attached")
+	storageInsecureHostKey := volCmd.Bool("insecure-host-key", false, "Skip SSH host key verification for the Storage (does not affect OpenStack TLS)")
+	addNoHeaderFlag(volCmd)
+	storageAuth := addAuthFlags(volCmd).addMaxRetriesFlag(volCmd)
+
+	hostCmd := pflag.NewFlagSet("host", pflag.ExitOnError)
+	hostCmd.Usage = func() {
+		fmt.Println("Usage: openstack-tool storage host <action> [flags]")
+		fmt.Println("Actions:")
+		fmt.Println("  audit")
+		fmt.Println("    Compare array host definitions with Nova hypervisors")
+		fmt.Println("Flags:")
+		fmt.Println("  --ip                 IP address or hostname of the Storage (required)")
+		fmt.Println("  --ssh-port           SSH port of the Storage (default: 22)")
+		fmt.Println("  --ssh-bastion        Connect to the Storage through this jump host (user@host[:port])")
+		fmt.Println("  --username           Username for SSH authentication (required)")
+		fmt.Println("  --password           Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt (optional with ssh-agent)")
+		fmt.Println("  --output             Output format (table, json, csv or yaml, default: table)")
+		fmt.Println("  --no-header          Omit the header row of table and CSV output")
+		fmt.Println("  --fail-on-mismatch   Exit with status 2 when array hosts and hypervisors do not match")
+		fmt.Println("  --verbose            Enable verbose logging")
+		fmt.Println("  --timeout            Timeout in seconds for API operations (default: 300)")
+		fmt.Println("  --insecure-host-key  Skip SSH host key verification (host keys are checked against ~/.ssh/known_hosts by default; earlier releases did not check them)")
+		fmt.Println("  --insecure           Skip TLS certificate verification for OpenStack API endpoints")
+		fmt.Println("  --request-timeout    Limit each OpenStack API request, e.g. 30s (default: 0, no per-request limit)")
+		fmt.Println("  --max-retries        Retries of an OpenStack read request after a 5xx response or timeout (default: 3)")
+		fmt.Println("  --os-interface       Endpoint interface: public, internal or admin (default: OS_INTERFACE, else public)")
+		fmt.Println("Examples:")
+		fmt.Println("  openstack-tool storage host audit --ip=192.168.1.100 --username=admin --password=secret --output=json --fail-on-mismatch")
+	}
+	hostIP := hostCmd.String("ip", "", "IP address or hostname of the Storage (required)")
+	hostSSHPort := hostCmd.Int("ssh-port", 22, "SSH port of the Storage")
+	hostSSHBastion := hostCmd.String("ssh-bastion", "", "Connect to the Storage through this jump host (user@host[:port])")
+	hostUsername := hostCmd.String("username", "", "Username for SSH authentication (required)")
+	hostPassword := hostCmd.String("password", "", "Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt, as flags show in ps (optional when ssh-agent holds a key)")
+	hostOutput := hostCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(hostCmd)
+	hostFailOnMismatch := hostCmd.Bool("fail-on-mismatch", false, "Exit with status 2 when array hosts and hypervisors do not match")
+	hostVerbose := hostCmd.Bool("verbose", false, "Enable verbose logging")
+	hostTimeout := hostCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	hostInsecureHostKey := hostCmd.Bool("insecure-host-key", false, "Skip SSH host key verification for the Storage (does not affect OpenStack TLS)")
+	hostAuth := addAuthFlags(hostCmd).addMaxRetriesFlag(hostCmd)
+
+	poolCmd := pflag.NewFlagSet("pool", pflag.ExitOnError)
+	poolCmd.Usage = func() {
+		fmt.Println("Usage: openstack-tool storage pool <action> [flags]")
+		fmt.Println("Actions:")
+		fmt.Println("  list")
+		fmt.Println("    List the storage pools that hold volumes, with volume count and virtual capacity")
+		fmt.Println("Flags:")
+		fmt.Println("  --ip                 IP address or hostname of the Storage (required)")
+		fmt.Println("  --ssh-port           SSH port of the Storage (default: 22)")
+		fmt.Println("  --ssh-bastion        Connect to the Storage through this jump host (user@host[:port])")
+		fmt.Println("  --username           Username for SSH authentication (required)")
+		fmt.Println("  --password           Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt (optional with ssh-agent)")
+		fmt.Println("  --savings            Add real capacity, thin-provisioning savings and oversubscription ratio per pool")
+		fmt.Println("  --output             Output format (table, json, csv or yaml, default: table)")
+		fmt.Println("  --no-header          Omit the header row of table and CSV output")
+		fmt.Println("  --verbose            Enable verbose logging")
+		fmt.Println("  --timeout            Timeout in seconds for API operations (default: 300)")
+		fmt.Println("  --insecure-host-key  Skip SSH host key verification (host keys are checked against ~/.ssh/known_hosts by default; earlier releases did not check them)")
+		fmt.Println("Examples:")
+		fmt.Println("  openstack-tool storage pool list --ip=192.168.1.100 --username=admin --password=secret --savings")
+	}
+	poolIP := poolCmd.String("ip", "", "IP address or hostname of the Storage (required)")
+	poolSSHPort := poolCmd.Int("ssh-port", 22, "SSH port of the Storage")
+	poolSSHBastion := poolCmd.String("ssh-bastion", "", "Connect to the Storage through this jump host (user@host[:port])")
+	poolUsername := poolCmd.String("username", "", "Username for SSH authentication (required)")
+	poolPassword := poolCmd.String("password", "", "Password for SSH authentication; prefer OPENSTACK_TOOL_SSH_PASSWORD or the prompt, as flags show in ps (optional when ssh-agent holds a key)")
+	poolSavings := poolCmd.Bool("savings", false, "Add real capacity, thin-provisioning savings and oversubscription ratio per pool")
+	poolOutput := poolCmd.String("output", "table", "Output format (table, json, csv or yaml)")
+	addNoHeaderFlag(poolCmd)
+	poolVerbose := poolCmd.Bool("verbose", false, "Enable verbose logging")
+	poolTimeout := poolCmd.Int("timeout", 300, "Timeout in seconds for API operations")
+	poolInsecureHostKey := poolCmd.Bool("insecure-host-key", false, "Skip SSH host key verification for the Storage")
 
 	// Check if a subcommand is provided
 	if len(os.Args) < 2 {
@@ -143,53 +406,72 @@ func main() {
 	switch os.Args[1] {
 	case "vm":
 		if len(os.Args) < 3 {
-			fmt.Println("Error: 'vm' subcommand requires 'info', 'manage', or 'create' action")
+			fmt.Println("Error: 'vm' subcommand requires 'info', 'manage', 'create', 'select-project', or 'diff' action")
 			printUsage()
 			os.Exit(1)
 		}
 		switch os.Args[2] {
 		case "info":
 			vmInfoCmd.Parse(os.Args[3:])
+			checkOutputFormat(*output)
+			if *infoWatch && *infoWatchInterval <= 0 {
+				fmt.Println("Error: --watch-interval must be positive")
+				os.Exit(1)
+			}
 			authVerbose = *verbose
 			timeoutDuration := time.Duration(*timeout) * time.Second
-			ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
+			// Ctrl-C ends a --watch cleanly; without it the whole command shares one timeout
+			sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ctx, cancel := context.WithTimeout(sigCtx, timeoutDuration)
 			defer cancel()
-			authClient, err = auth.NewClient(ctx, auth.Config{
-				Verbose: authVerbose,
-				Timeout: timeoutDuration,
-			})
+			authClient, err = auth.NewClient(ctx, infoAuth.config(authVerbose, timeoutDuration))
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 				os.Exit(1)
 			}
-			if err := vm.Run(ctx, authClient, "info", vm.Config{
+			runCtx := ctx
+			if *infoWatch {
+				runCtx = sigCtx
+			}
+			if err := vm.Run(runCtx, authClient, "info", vm.Config{
 				Verbose:        *verbose,
 				FilterStr:      *filter,
+				TimeZone:       *timeZone,
+				Long:           *infoLong,
+				Deleted:        *infoDeleted,
+				ResolveFIPs:    *infoResolveFIPs,
+				NoEmail:        *infoNoEmail,
+				OutputFile:     *infoOutputFile,
+				DiffAgainst:    *infoDiffAgainst,
+				Watch:          *infoWatch,
+				WatchInterval:  *infoWatchInterval,
 				OutputFormat:   *output,
 				UseFlavorCache: *useFlavorCache,
-				MaxRetries:     3,
 				MaxConcurrency: 10,
 				Timeout:        timeoutDuration,
 			}); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
 				os.Exit(1)
 			}
 		case "manage":
 			vmManageCmd.Parse(os.Args[3:])
+			checkOutputFormat(*manageOutput)
+			if err := vm.ValidateActionRetries(*manageMaxRetries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
 			authVerbose = *manageVerbose
 			timeoutDuration := time.Duration(*manageTimeout) * time.Second
 			ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 			defer cancel()
-			authClient, err = auth.NewClient(ctx, auth.Config{
-				Verbose: authVerbose,
-				Timeout: timeoutDuration,
-			})
+			authClient, err = auth.NewClient(ctx, manageAuth.config(authVerbose, timeoutDuration))
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 				os.Exit(1)
 			}
-			if *manageVM == "" || *manageProject == "" {
-				fmt.Println("Error: --vm and --project flags are required for manage")
+			if (*manageVM == "" && *manageFilter == "") || *manageProject == "" {
+				fmt.Println("Error: --project and one of --vm or --filter are required for manage")
 				printManageVmsUsage()
 				os.Exit(1)
 			}
@@ -203,119 +485,291 @@ func main() {
 				printManageVmsUsage()
 				os.Exit(1)
 			}
+			if (os.Args[3] == "set-metadata" || os.Args[3] == "unset-metadata") && len(*manageMetadata) == 0 {
+				fmt.Printf("Error: --metadata flag is required for %s subcommand\n", os.Args[3])
+				printManageVmsUsage()
+				os.Exit(1)
+			}
 			if err := vm.Run(ctx, authClient, os.Args[3], vm.Config{
-				Verbose:      *manageVerbose,
-				VM:           *manageVM,
-				Project:      *manageProject,
-				DryRun:       *manageDryRun,
-				OutputFormat: *manageOutput,
-				Timeout:      timeoutDuration,
-				State:        *manageState,
+				Verbose:            *manageVerbose,
+				VM:                 *manageVM,
+				FilterStr:          *manageFilter,
+				Project:            *manageProject,
+				DryRun:             *manageDryRun,
+				OutputFormat:       *manageOutput,
+				Timeout:            timeoutDuration,
+				State:              *manageState,
+				Metadata:           *manageMetadata,
+				ConsoleLines:       *manageLines,
+				ConsoleType:        *manageConsoleType,
+				Yes:                *manageYes,
+				MaxConcurrency:     *manageConcurrency,
+				ProtectedFile:      *manageProtectedFile,
+				OverrideProtection: *manageOverrideProtection,
+				ShowTiming:         *manageShowTiming,
+				ShowRequestID:      *manageShowRequestID,
+				FailFast:           *manageFailFast,
+				Force:              *manageForce,
+				ActionRetries:      *manageMaxRetries,
+				RetryDelay:         *manageRetryDelay,
+				Wait:               *manageWait,
+				WaitTimeout:        *manageWaitTimeout,
 			}); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
 				os.Exit(1)
 			}
 		case "create":
 			vmCreateCmd.Parse(os.Args[3:])
+			checkOutputFormat(*createOutput)
 			authVerbose = *createVerbose
 			timeoutDuration := time.Duration(*createTimeout) * time.Second
 			ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 			defer cancel()
-			authClient, err = auth.NewClient(ctx, auth.Config{
-				Verbose: authVerbose,
-				Timeout: timeoutDuration,
-			})
+			authClient, err = auth.NewClient(ctx, vmCreateAuth.config(authVerbose, timeoutDuration))
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+			if err := vm.CreateVM(ctx, vm.Config{
+				Verbose:        *createVerbose,
+				Timeout:        timeoutDuration,
+				Insecure:       *vmCreateAuth.insecure,
+				RequestTimeout: *vmCreateAuth.requestTimeout,
+				Interface:      *vmCreateAuth.osInterface,
+				Strict:         *createStrict,
+				ComputeHost:    *createComputeHost,
+				VerifySSH:      *createVerifySSH,
+				VerifyTimeout:  *createVerifyTimeout,
+				OutputFormat:   *createOutput,
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+		case "select-project":
+			vmSelectProjectCmd.Parse(os.Args[3:])
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*selectProjectTimeout)*time.Second)
+			defer cancel()
+			if err := vm.SelectProject(ctx, vm.Config{
+				Verbose:        *selectProjectVerbose,
+				Format:         *selectProjectFormat,
+				Insecure:       *vmSelectProjectAuth.insecure,
+				RequestTimeout: *vmSelectProjectAuth.requestTimeout,
+				Interface:      *vmSelectProjectAuth.osInterface,
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, time.Duration(*selectProjectTimeout)*time.Second))
+				os.Exit(1)
+			}
+		case "diff":
+			vmDiffCmd.Parse(os.Args[3:])
+			checkOutputFormat(*diffOutput)
+			if *diffOld == "" || *diffNew == "" {
+				fmt.Println("Error: --old and --new are required for vm diff")
+				vmDiffCmd.Usage()
 				os.Exit(1)
 			}
-			if err := vm.CreateVM(ctx); err != nil {
+			if err := vm.RunDiff(vm.Config{
+				Verbose:      *diffVerbose,
+				DiffOld:      *diffOld,
+				DiffNew:      *diffNew,
+				OutputFormat: *diffOutput,
+			}); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		default:
-			fmt.Printf("Error: invalid subcommand '%s' for 'vm'; expected 'info', 'manage', or 'create'\n", os.Args[2])
+			fmt.Printf("Error: invalid subcommand '%s' for 'vm'; expected 'info', 'manage', 'create', 'select-project', or 'diff'\n", os.Args[2])
 			printUsage()
 			os.Exit(1)
 		}
 	case "clean-nova-stale-vms":
 		cleanNovaStaleVmsCmd.Parse(os.Args[2:])
+		checkOutputFormat(*outputClean)
+		checkSSHPort(*sshPortClean)
+		checkSSHBastion(*sshBastionClean)
+		resolveSSHPassword(passFlag)
+		if err := cleannovastalevms.ValidateWebhookOn(*webhookOnClean); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := cleannovastalevms.ValidateParallelProjects(*parallelProjectsClean); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		authVerbose = *cleanVerbose
 		timeoutDuration := time.Duration(*timeoutClean) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, cleanAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
-		if *userFlag == "" || *passFlag == "" || *ipFlag == "" {
-			fmt.Println("Error: --user, --password, and --ip flags are required for clean-nova-stale-vms")
+		if *userFlag == "" || *ipFlag == "" || !util.HasSSHCredentials(*passFlag) {
+			fmt.Println("Error: --user, --ip, and --password (or OPENSTACK_TOOL_SSH_PASSWORD or a running ssh-agent) are required for clean-nova-stale-vms")
 			cleanNovaStaleVmsCmd.Usage()
 			os.Exit(1)
 		}
-		if err := cleannovastalevms.Run(ctx, authClient, *cleanVerbose, *userFlag, *passFlag, *ipFlag, *outputClean, *dryRunClean); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := cleannovastalevms.Run(ctx, authClient, cleannovastalevms.Config{
+			Verbose:          *cleanVerbose,
+			User:             *userFlag,
+			Password:         *passFlag,
+			IP:               *ipFlag,
+			SSHPort:          *sshPortClean,
+			SSHBastion:       *sshBastionClean,
+			OutputFormat:     *outputClean,
+			DryRun:           *dryRunClean,
+			InsecureHostKey:  *insecureHostKeyClean,
+			CacheInventory:   *cacheInventoryClean,
+			CacheTTL:         *cacheTTLClean,
+			Refresh:          *refreshClean,
+			CheckService:     *checkServiceClean,
+			MaxHeartbeatAge:  *maxHeartbeatAgeClean,
+			Force:            *forceClean,
+			Quiet:            *quietClean,
+			ParallelProjects: *parallelProjectsClean,
+			IncludeDisabled:  *includeDisabledClean,
+			Project:          *projectClean,
+			WebhookURL:       *webhookURLClean,
+			WebhookOn:        *webhookOnClean,
+			WebhookTimeout:   *webhookTimeoutClean,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+			if errors.Is(err, cleannovastalevms.ErrDeletionFailed) {
+				os.Exit(2)
+			}
 			os.Exit(1)
 		}
 	case "user-roles":
 		userRolesCmd.Parse(os.Args[2:])
+		checkOutputFormat(*userOutput)
+		var quota user.QuotaUpdate
+		if *userAction == "set-quota" {
+			quotaFlags := []struct {
+				name  string
+				value *int
+				field **int
+			}{
+				{"cores", userCores, &quota.Cores},
+				{"ram-gb", userRAMGB, &quota.RAMGB},
+				{"instances", userInstances, &quota.Instances},
+				{"volumes", userVolumes, &quota.Volumes},
+				{"gigabytes", userGigabytes, &quota.Gigabytes},
+			}
+			for _, f := range quotaFlags {
+				if !userRolesCmd.Changed(f.name) {
+					continue
+				}
+				if *f.value < -1 {
+					fmt.Printf("Error: --%s must be -1 (unlimited) or more\n", f.name)
+					userRolesCmd.Usage()
+					os.Exit(1)
+				}
+				*f.field = f.value
+			}
+			if quota == (user.QuotaUpdate{}) {
+				fmt.Println("Error: set-quota requires at least one of --cores, --ram-gb, --instances, --volumes and --gigabytes")
+				userRolesCmd.Usage()
+				os.Exit(1)
+			}
+		}
 		authVerbose = *userVerbose
 		timeoutDuration := time.Duration(*userTimeout) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, userAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
-		if err := user.Run(ctx, authClient, *userVerbose, *userOutput, *userAction, *userName, *userProjectName, *roleName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := user.Run(ctx, authClient, user.Config{
+			Verbose:       *userVerbose,
+			OutputFormat:  *userOutput,
+			Action:        *userAction,
+			UserName:      *userName,
+			ProjectName:   *userProjectName,
+			RoleName:      *roleName,
+			UserDomain:    *userDomain,
+			ProjectDomain: *projectDomain,
+			File:          *userFile,
+			Description:   *userDescription,
+			Force:         *userForce,
+			WithCounts:    *userWithCounts,
+			NamePrefix:    *userNamePrefix,
+			Quota:         quota,
+			DryRun:        *userDryRun,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
 	case "volume":
 		if len(os.Args) < 3 {
-			fmt.Println("Error: 'volume' subcommand requires 'list', 'list-all', 'change-status', or 'delete'")
+			fmt.Println("Error: 'volume' subcommand requires 'list', 'list-all', 'change-status', 'delete', 'audit-attachments', or 'snapshot report-orphans'")
 			volumeCmd.Usage()
 			os.Exit(1)
 		}
 		validVolumeSubcommands := map[string]bool{
-			"list":          true,
-			"list-all":      true,
-			"change-status": true,
-			"delete":        true,
+			"list":              true,
+			"list-all":          true,
+			"change-status":     true,
+			"delete":            true,
+			"audit-attachments": true,
+			"snapshot":          true,
 		}
 		subcommand := os.Args[2]
 		if !validVolumeSubcommands[subcommand] {
-			fmt.Printf("Error: invalid subcommand '%s' for 'volume'; expected 'list', 'list-all', 'change-status', or 'delete'\n", subcommand)
+			fmt.Printf("Error: invalid subcommand '%s' for 'volume'; expected 'list', 'list-all', 'change-status', 'delete', 'audit-attachments', or 'snapshot report-orphans'\n", subcommand)
 			volumeCmd.Usage()
 			os.Exit(1)
 		}
-		volumeCmd.Parse(os.Args[2:])
+		flagArgs := os.Args[2:]
+		if subcommand == "snapshot" {
+			if len(os.Args) < 4 || os.Args[3] != "report-orphans" {
+				fmt.Println("Error: 'volume snapshot' requires the 'report-orphans' action")
+				volumeCmd.Usage()
+				os.Exit(1)
+			}
+			subcommand = "snapshot report-orphans"
+			flagArgs = os.Args[3:]
+		}
+		volumeCmd.Parse(flagArgs)
 		if volumeCmd.Parsed() && volumeCmd.Lookup("help") != nil && volumeCmd.Lookup("help").Value.String() == "true" {
 			volumeCmd.Usage()
 			os.Exit(0)
 		}
+		checkOutputFormat(*volumeOutput)
 		authVerbose = *volumeVerbose
 		timeoutDuration := time.Duration(*volumeTimeout) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, volumeAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
+			os.Exit(1)
+		}
+		if (subcommand == "list" || subcommand == "change-status" || subcommand == "delete" || ((subcommand == "audit-attachments" || subcommand == "snapshot report-orphans") && !*volumeAllProjects)) && (*volumeProject == "" && os.Getenv("OS_PROJECT_NAME") == "") {
+			fmt.Println("Error: --project flag or OS_PROJECT_NAME environment variable is required for list, change-status, and delete subcommands, and for audit-attachments and snapshot report-orphans without --all-projects")
+			volumeCmd.Usage()
 			os.Exit(1)
 		}
-		if (subcommand == "list" || subcommand == "change-status" || subcommand == "delete") && (*volumeProject == "" && os.Getenv("OS_PROJECT_NAME") == "") {
-			fmt.Println("Error: --project flag or OS_PROJECT_NAME environment variable is required for list, change-status, and delete subcommands")
+		if *volumeAttachedOnly && (*volumeUnattachedOnly || *volumeNotAssociated) {
+			fmt.Println("Error: --attached-only cannot be combined with --unattached-only or --not-associated")
+			volumeCmd.Usage()
+			os.Exit(1)
+		}
+		if *volumeGroupBy != "" {
+			if *volumeGroupBy != "project" || subcommand != "list-all" {
+				fmt.Println("Error: --group-by only supports 'project', for list-all")
+				volumeCmd.Usage()
+				os.Exit(1)
+			}
+			if *volumeSummary || *volumeLong {
+				fmt.Println("Error: --group-by replaces the volume listing and cannot be combined with --summary or --long")
+				volumeCmd.Usage()
+				os.Exit(1)
+			}
+		}
+		if *volumeShowAssociation && !*volumeLong {
+			fmt.Println("Error: --show-association requires --long")
 			volumeCmd.Usage()
 			os.Exit(1)
 		}
@@ -329,29 +783,122 @@ func main() {
 			volumeCmd.Usage()
 			os.Exit(1)
 		}
-		if err := volume.Run(ctx, authClient, *volumeVerbose, *volumeOutput, subcommand, *volumeNames, *volumeProject, *volumeStatus, *volumeLong, *volumeNotAssociated); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := volume.Run(ctx, authClient, volume.Config{
+			Verbose:         *volumeVerbose,
+			OutputFormat:    *volumeOutput,
+			Subcommand:      subcommand,
+			VolumeNames:     *volumeNames,
+			ProjectName:     *volumeProject,
+			Status:          *volumeStatus,
+			Long:            *volumeLong,
+			NotAssociated:   *volumeNotAssociated,
+			AttachedOnly:    *volumeAttachedOnly,
+			UnattachedOnly:  *volumeUnattachedOnly,
+			Summary:         *volumeSummary,
+			GroupBy:         *volumeGroupBy,
+			ShowAssociation: *volumeShowAssociation,
+			Force:           *volumeForce,
+			Yes:             *volumeYes,
+			DryRun:          *volumeDryRun,
+			MaxResults:      *volumeMaxResults,
+			AllProjects:     *volumeAllProjects,
+			Fix:             *volumeFix,
+			Delete:          *volumeDelete,
+			Strict:          *volumeStrict,
+			FailFast:        *volumeFailFast,
+			SkipImageCheck:  *volumeSkipImageCheck,
+			AZ:              *volumeAZ,
+			Backend:         *volumeBackend,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+			if errors.Is(err, volume.ErrStatusChangeFailed) {
+				os.Exit(2)
+			}
 			os.Exit(1)
 		}
 	case "images":
-		imagesCmd.Parse(os.Args[2:])
+		// set-visibility, set-owner, update and delete may also be given as a subcommand instead of --action
+		if len(os.Args) > 2 && (os.Args[2] == "set-visibility" || os.Args[2] == "set-owner" || os.Args[2] == "update" || os.Args[2] == "delete") {
+			imagesCmd.Parse(os.Args[3:])
+			*imagesAction = os.Args[2]
+		} else {
+			imagesCmd.Parse(os.Args[2:])
+		}
+		checkOutputFormat(*imagesOutput)
+		if (*imagesAction == "set-visibility" || *imagesAction == "set-owner" || *imagesAction == "update" || *imagesAction == "delete") && *imagesImage == "" {
+			fmt.Printf("Error: --image is required for %s\n", *imagesAction)
+			imagesCmd.Usage()
+			os.Exit(1)
+		}
+		if *imagesAction == "set-visibility" {
+			if err := images.ValidateVisibility(*imagesVisibility); err != nil {
+				fmt.Printf("Error: --visibility: %v\n", err)
+				imagesCmd.Usage()
+				os.Exit(1)
+			}
+		}
+		// Only the update flags that were given change the image, so an empty description or a
+		// zero minimum can be set too
+		var imageDescription *string
+		var imageMinDisk, imageMinRAM *int
+		if *imagesAction == "update" {
+			if imagesCmd.Changed("description") {
+				imageDescription = imagesDescription
+			}
+			if imagesCmd.Changed("min-disk") {
+				imageMinDisk = imagesMinDisk
+			}
+			if imagesCmd.Changed("min-ram") {
+				imageMinRAM = imagesMinRAM
+			}
+			if *imagesNewName == "" && imageDescription == nil && imageMinDisk == nil && imageMinRAM == nil {
+				fmt.Println("Error: update requires at least one of --new-name, --description, --min-disk and --min-ram")
+				imagesCmd.Usage()
+				os.Exit(1)
+			}
+			if *imagesMinDisk < 0 || *imagesMinRAM < 0 {
+				fmt.Println("Error: --min-disk and --min-ram must not be negative")
+				imagesCmd.Usage()
+				os.Exit(1)
+			}
+		}
+		if *imagesAction == "set-owner" && *imagesToProject == "" {
+			fmt.Println("Error: --to-project is required for set-owner")
+			imagesCmd.Usage()
+			os.Exit(1)
+		}
+		var imageAge time.Duration
+		if *imagesOlderThan != "" {
+			if imageAge, err = images.ParseAge(*imagesOlderThan); err != nil {
+				fmt.Printf("Error: --older-than: %v\n", err)
+				imagesCmd.Usage()
+				os.Exit(1)
+			}
+		}
+		if *imagesSummary && *imagesAction != "list-all" {
+			fmt.Println("Error: --summary is only supported with --action=list-all")
+			imagesCmd.Usage()
+			os.Exit(1)
+		}
 		authVerbose = *imagesVerbose
 		timeoutDuration := time.Duration(*imagesTimeout) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, imagesAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
-		if *imagesAction == "list" && *imagesProject == "" && os.Getenv("OS_PROJECT_NAME") == "" {
-			fmt.Println("Error: --project flag or OS_PROJECT_NAME environment variable is required for list action")
+		if (*imagesAction == "list" || *imagesAction == "list-shared") && *imagesProject == "" && os.Getenv("OS_PROJECT_NAME") == "" {
+			fmt.Printf("Error: --project flag or OS_PROJECT_NAME environment variable is required for %s action\n", *imagesAction)
 			imagesCmd.Usage()
 			os.Exit(1)
 		}
+		// --max-results is the deprecated spelling of --limit
+		imageLimit := *imagesLimit
+		if imageLimit == 0 {
+			imageLimit = *imagesMaxResults
+		}
 		if err := images.Run(ctx, authClient, images.Config{
 			Verbose:      *imagesVerbose,
 			ProjectName:  *imagesProject,
@@ -359,82 +906,212 @@ func main() {
 			Action:       *imagesAction,
 			Timeout:      timeoutDuration,
 			Long:         *imagesLong,
-			Limit:        *imagesLimit,
+			Limit:        imageLimit,
+			PageSize:     *imagesPageSize,
+			Summary:      *imagesSummary,
+			OlderThan:    imageAge,
+			Image:        *imagesImage,
+			Visibility:   *imagesVisibility,
+			ToProject:    *imagesToProject,
+			NewName:      *imagesNewName,
+			Description:  imageDescription,
+			MinDisk:      imageMinDisk,
+			MinRAM:       imageMinRAM,
+			Force:        *imagesForce,
+			Yes:          *imagesYes,
+			Strict:       *imagesStrict,
 		}); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if errors.Is(err, images.ErrBrokenImages) {
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
 	case "storage":
 		if len(os.Args) < 3 {
-			fmt.Println("Error: 'storage' subcommand requires 'vol'")
+			fmt.Println("Error: 'storage' subcommand requires 'vol', 'host' or 'pool'")
 			printStorageUsage()
 			os.Exit(1)
 		}
-		if os.Args[2] != "vol" {
-			fmt.Printf("Error: invalid subcommand '%s' for 'storage'; expected 'vol'\n", os.Args[2])
+		switch os.Args[2] {
+		case "vol":
+			if len(os.Args) < 4 {
+				fmt.Println("Error: 'vol' subcommand requires an action (e.g., 'list')")
+				volCmd.Usage()
+				os.Exit(1)
+			}
+			if os.Args[3] != "list" {
+				fmt.Printf("Error: invalid action '%s' for 'vol'; expected 'list'\n", os.Args[3])
+				volCmd.Usage()
+				os.Exit(1)
+			}
+			volCmd.Parse(os.Args[2:]) // Parse vol subcommand and flags starting from 'vol'
+			if volCmd.Parsed() && volCmd.Lookup("help") != nil && volCmd.Lookup("help").Value.String() == "true" {
+				volCmd.Usage()
+				os.Exit(0)
+			}
+			checkOutputFormat(*storageOutput)
+			checkSSHPort(*storageSSHPort)
+			checkSSHBastion(*storageSSHBastion)
+			resolveSSHPassword(storagePassword)
+			authVerbose = *storageVerbose
+			timeoutDuration := time.Duration(*storageTimeout) * time.Second
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
+			defer cancel()
+			if *storageIP == "" || *storageUsername == "" || !util.HasSSHCredentials(*storagePassword) {
+				fmt.Println("Error: --ip, --username, and --password (or OPENSTACK_TOOL_SSH_PASSWORD or a running ssh-agent) are required for storage vol")
+				volCmd.Usage()
+				os.Exit(1)
+			}
+			// Initialize authentication client (optional for storage, but kept for consistency)
+			authClient, err = auth.NewClient(ctx, storageAuth.config(authVerbose, timeoutDuration))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+			if err := storage.Run(ctx, authClient, storage.Config{
+				IP:              *storageIP,
+				SSHPort:         *storageSSHPort,
+				SSHBastion:      *storageSSHBastion,
+				Username:        *storageUsername,
+				Password:        *storagePassword,
+				Long:            *storageLong,
+				OutputFormat:    *storageOutput,
+				Verbose:         *storageVerbose,
+				Timeout:         *storageTimeout,
+				InsecureHostKey: *storageInsecureHostKey,
+				MatchOpenStack:  *storageMatchOpenStack,
+				OrphansOnly:     *storageOrphansOnly,
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+		case "host":
+			if len(os.Args) < 4 || os.Args[3] != "audit" {
+				fmt.Println("Error: 'host' subcommand requires the 'audit' action")
+				hostCmd.Usage()
+				os.Exit(1)
+			}
+			hostCmd.Parse(os.Args[4:])
+			checkOutputFormat(*hostOutput)
+			checkSSHPort(*hostSSHPort)
+			checkSSHBastion(*hostSSHBastion)
+			resolveSSHPassword(hostPassword)
+			authVerbose = *hostVerbose
+			timeoutDuration := time.Duration(*hostTimeout) * time.Second
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
+			defer cancel()
+			if *hostIP == "" || *hostUsername == "" || !util.HasSSHCredentials(*hostPassword) {
+				fmt.Println("Error: --ip, --username, and --password (or OPENSTACK_TOOL_SSH_PASSWORD or a running ssh-agent) are required for storage host audit")
+				hostCmd.Usage()
+				os.Exit(1)
+			}
+			authClient, err = auth.NewClient(ctx, hostAuth.config(authVerbose, timeoutDuration))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+			if err := storage.AuditHosts(ctx, authClient, storage.Config{
+				IP:              *hostIP,
+				SSHPort:         *hostSSHPort,
+				SSHBastion:      *hostSSHBastion,
+				Username:        *hostUsername,
+				Password:        *hostPassword,
+				Verbose:         *hostVerbose,
+				Timeout:         *hostTimeout,
+				InsecureHostKey: *hostInsecureHostKey,
+				OutputFormat:    *hostOutput,
+				FailOnMismatch:  *hostFailOnMismatch,
+			}); err != nil {
+				if errors.Is(err, storage.ErrHostMismatch) {
+					os.Exit(2)
+				}
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+				os.Exit(1)
+			}
+		case "pool":
+			if len(os.Args) < 4 || os.Args[3] != "list" {
+				fmt.Println("Error: 'pool' subcommand requires the 'list' action")
+				poolCmd.Usage()
+				os.Exit(1)
+			}
+			poolCmd.Parse(os.Args[4:])
+			checkOutputFormat(*poolOutput)
+			checkSSHPort(*poolSSHPort)
+			checkSSHBastion(*poolSSHBastion)
+			resolveSSHPassword(poolPassword)
+			if *poolIP == "" || *poolUsername == "" || !util.HasSSHCredentials(*poolPassword) {
+				fmt.Println("Error: --ip, --username, and --password (or OPENSTACK_TOOL_SSH_PASSWORD or a running ssh-agent) are required for storage pool list")
+				poolCmd.Usage()
+				os.Exit(1)
+			}
+			if err := storage.ListPools(context.Background(), storage.Config{
+				IP:              *poolIP,
+				SSHPort:         *poolSSHPort,
+				SSHBastion:      *poolSSHBastion,
+				Username:        *poolUsername,
+				Password:        *poolPassword,
+				Verbose:         *poolVerbose,
+				Timeout:         *poolTimeout,
+				InsecureHostKey: *poolInsecureHostKey,
+				OutputFormat:    *poolOutput,
+				Savings:         *poolSavings,
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, time.Duration(*poolTimeout)*time.Second))
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("Error: invalid subcommand '%s' for 'storage'; expected 'vol', 'host' or 'pool'\n", os.Args[2])
 			printStorageUsage()
 			os.Exit(1)
 		}
-		if len(os.Args) < 4 {
-			fmt.Println("Error: 'vol' subcommand requires an action (e.g., 'list')")
-			volCmd.Usage()
-			os.Exit(1)
-		}
-		if os.Args[3] != "list" {
-			fmt.Printf("Error: invalid action '%s' for 'vol'; expected 'list'\n", os.Args[3])
-			volCmd.Usage()
-			os.Exit(1)
-		}
-		volCmd.Parse(os.Args[2:]) // Parse vol subcommand and flags starting from 'vol'
-		if volCmd.Parsed() && volCmd.Lookup("help") != nil && volCmd.Lookup("help").Value.String() == "true" {
-			volCmd.Usage()
-			os.Exit(0)
-		}
-		authVerbose = *storageVerbose
-		timeoutDuration := time.Duration(*storageTimeout) * time.Second
+	case "create":
+		createCmd.Parse(os.Args[2:])
+		authVerbose = *createCmdVerbose
+		timeoutDuration := time.Duration(*createCmdTimeout) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		if *storageIP == "" || *storageUsername == "" || *storagePassword == "" {
-			fmt.Println("Error: --ip, --username, and --password flags are required for storage vol")
-			volCmd.Usage()
-			os.Exit(1)
-		}
-		// Initialize authentication client (optional for storage, but kept for consistency)
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, createAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
-		if err := storage.Run(ctx, storage.Config{
-			IP:       *storageIP,
-			Username: *storageUsername,
-			Password: *storagePassword,
-			Long:     *storageLong,
-			Verbose:  *storageVerbose,
-			Timeout:  *storageTimeout,
+		if err := vm.CreateVM(ctx, vm.Config{
+			Verbose:        *createCmdVerbose,
+			Timeout:        timeoutDuration,
+			Insecure:       *createAuth.insecure,
+			RequestTimeout: *createAuth.requestTimeout,
+			Interface:      *createAuth.osInterface,
+			Strict:         *createCmdStrict,
+			ComputeHost:    *createCmdComputeHost,
 		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
+			os.Exit(1)
+		}
+	case "check":
+		checkCmd.Parse(os.Args[2:])
+		checkOutputFormat(*checkOutput)
+		services, err := check.ParseServices(*checkServices)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-	case "create":
-		createCmd.Parse(os.Args[2:])
-		authVerbose = *createCmdVerbose
-		timeoutDuration := time.Duration(*createCmdTimeout) * time.Second
+		authVerbose = *checkVerbose
+		timeoutDuration := time.Duration(*checkTimeout) * time.Second
 		ctx, cancel := context.WithTimeout(context.Background(), timeoutDuration)
 		defer cancel()
-		authClient, err = auth.NewClient(ctx, auth.Config{
-			Verbose: authVerbose,
-			Timeout: timeoutDuration,
-		})
+		authClient, err = auth.NewClient(ctx, checkAuth.config(authVerbose, timeoutDuration))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Authentication error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
-		if err := vm.CreateVM(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := check.Run(ctx, authClient, check.Config{
+			Verbose:      *checkVerbose,
+			OutputFormat: *checkOutput,
+			Services:     services,
+			Timeout:      timeoutDuration,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", util.TimeoutError(err, timeoutDuration))
 			os.Exit(1)
 		}
 	default:
@@ -444,53 +1121,186 @@ func main() {
 	}
 }
 
+// checkOutputFormat exits with an error before any API work when --output is not a known format
+func checkOutputFormat(format string) {
+	if err := output.Validate(format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// checkSSHPort exits with an error when port is not a valid --ssh-port value
+func checkSSHPort(port int) {
+	if err := util.ValidateSSHPort(port); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// checkSSHBastion exits with an error when bastion is set but not of the form user@host[:port]
+func checkSSHBastion(bastion string) {
+	if bastion == "" {
+		return
+	}
+	if _, _, err := util.ParseBastion(bastion); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolveSSHPassword fills in *password from OPENSTACK_TOOL_SSH_PASSWORD or a terminal prompt
+// when --password was not given, and exits when the prompt fails
+func resolveSSHPassword(password *string) {
+	resolved, err := util.SSHPassword(*password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	*password = resolved
+}
+
+// authFlags holds the OpenStack connection flags shared by every subcommand
+type authFlags struct {
+	insecure       *bool
+	requestTimeout *time.Duration
+	osInterface    *string
+	maxRetries     *int // Nil for subcommands without the shared --max-retries
+}
+
+// addNoHeaderFlag adds --no-header, which sets output.NoHeader directly since a run parses
+// only one subcommand's flags
+func addNoHeaderFlag(fs *pflag.FlagSet) {
+	fs.BoolVar(&output.NoHeader, "no-header", false, "Omit the header row of table and CSV output, e.g. when appending to a report")
+}
+
+func addAuthFlags(fs *pflag.FlagSet) *authFlags {
+	return &authFlags{
+		insecure:       fs.Bool("insecure", false, "Skip TLS certificate verification for OpenStack API endpoints (does not affect SSH)"),
+		requestTimeout: fs.Duration("request-timeout", 0, "Limit each OpenStack API request to this duration, e.g. 30s (0 for no per-request limit)"),
+		osInterface:    fs.String("os-interface", "", "Endpoint interface to use: public, internal or admin (default: OS_INTERFACE, else public)"),
+	}
+}
+
+// addMaxRetriesFlag adds the shared --max-retries for OpenStack read requests to a subcommand
+// that has no --max-retries of its own
+func (f *authFlags) addMaxRetriesFlag(fs *pflag.FlagSet) *authFlags {
+	f.maxRetries = fs.Int("max-retries", 3, "Retries of an OpenStack read request after a 5xx response or timeout (0 disables)")
+	return f
+}
+
+// config builds the auth.Config for a subcommand from its parsed flags
+func (f *authFlags) config(verbose bool, timeout time.Duration) auth.Config {
+	cfg := auth.Config{
+		Verbose:        verbose,
+		Timeout:        timeout,
+		Insecure:       *f.insecure,
+		RequestTimeout: *f.requestTimeout,
+		Interface:      *f.osInterface,
+	}
+	if f.maxRetries != nil {
+		cfg.Retries = *f.maxRetries
+	}
+	return cfg
+}
+
 func printUsage() {
 	fmt.Println("OpenStack Tool: Manage VMs, users, volumes, images, and storage in an OpenStack cloud.")
 	fmt.Println("Usage: openstack-tool <subcommand> [flags]")
 	fmt.Println("\nSubcommands:")
 	fmt.Println("  vm")
-	fmt.Println("    Subcommands: info, manage, create")
+	fmt.Println("    Subcommands: info, manage, create, select-project, diff")
 	fmt.Println("    Example: openstack-tool vm info --verbose --filter=\"host=host1,status=ACTIVE,days>7\" --output=json --timeout=300")
+	fmt.Println("    Example: openstack-tool vm info --filter=\"host=host1\" --watch --watch-interval=10s")
+	fmt.Println("    Example: openstack-tool vm info --filter=@capacity.filter,status=ACTIVE --output=csv")
+	fmt.Println("    Example: openstack-tool vm info --no-email --output=csv")
+	fmt.Println("    Example: openstack-tool vm info --diff-against=inventory-yesterday.json --output-file=inventory-today.json")
 	fmt.Println("    Example: openstack-tool vm manage delete --vm=test-vm1,test-vm2 --project=admin --dry-run --output=table --timeout=300")
 	fmt.Println("    Example: openstack-tool vm create --verbose --timeout=300")
+	fmt.Println("    Example: openstack-tool vm create --verify-ssh --verify-timeout=3m --output=json")
+	fmt.Println("    Example: openstack-tool vm create --compute-host=compute-07 --strict")
+	fmt.Println("    Example: eval \"$(openstack-tool vm select-project --format=openrc)\"")
+	fmt.Println("    Example: openstack-tool vm diff --old=inventory-2026-10-15.json --new=inventory-2026-10-16.json")
 	fmt.Println("  clean-nova-stale-vms")
 	fmt.Println("    Clean stale VMs on a hypervisor")
 	fmt.Println("    Example: openstack-tool clean-nova-stale-vms --verbose --user=root --password=secret --ip=192.168.1.100 --dry-run --output=table --timeout=300")
+	fmt.Println("    Example: openstack-tool clean-nova-stale-vms --user=root --ip=192.168.1.100 --dry-run --cache-inventory=/tmp/inventory.json --cache-ttl=10m")
+	fmt.Println("    Example: openstack-tool clean-nova-stale-vms --user=root --ip=192.168.1.100 --project=proj1 --dry-run")
 	fmt.Println("  user-roles")
 	fmt.Println("    Manage user roles in OpenStack")
 	fmt.Println("    Example: openstack-tool user-roles --action=list-users-in-project --project=admin --output=table --timeout=300")
+	fmt.Println("    Example: openstack-tool user-roles --action=export-assignments --project=proj1 --file=proj1-roles.json")
+	fmt.Println("    Example: openstack-tool user-roles --action=create-role --role=product-x-operator --description=\"Operators of product X\"")
+	fmt.Println("    Example: openstack-tool user-roles --action=report-users --user-domain=Default --name-prefix=svc- --output=json")
+	fmt.Println("    Example: openstack-tool user-roles --action=set-quota --project=proj1 --cores=64 --ram-gb=256 --gigabytes=2000 --dry-run")
 	fmt.Println("  volume")
 	fmt.Println("    Manage volumes in OpenStack")
 	fmt.Println("    Example: openstack-tool volume list --project=proj1 --not-associated --output=table")
 	fmt.Println("    Example: openstack-tool volume list-all --long --not-associated --output=json")
+	fmt.Println("    Example: openstack-tool volume audit-attachments --all-projects --output=json")
 	fmt.Println("  images")
 	fmt.Println("    Manage OpenStack images")
 	fmt.Println("    Example: openstack-tool images --action=list --project=proj1 --output=table --timeout=300")
+	fmt.Println("    Example: openstack-tool images --action=validate --output=json   (exits 2 when broken images are found)")
+	fmt.Println("    Example: openstack-tool images --action=usage --output=csv")
+	fmt.Println("    Example: openstack-tool images --action=list-all --summary --older-than=365d")
+	fmt.Println("    Example: openstack-tool images --action=list-shared --project=proj1")
+	fmt.Println("    Example: openstack-tool images set-visibility --image=golden-rhel9 --visibility=public")
+	fmt.Println("    Example: openstack-tool images update --image=golden-rhle9 --new-name=golden-rhel9 --min-disk=20")
+	fmt.Println("    Example: openstack-tool images set-owner --image=golden-rhel9 --to-project=platform")
+	fmt.Println("    Example: openstack-tool images delete --image=rhel-8-old   (refused while VMs were booted from it, unless --force)")
 	fmt.Println("  storage")
 	fmt.Println("    Manage storage volumes on Storage")
-	fmt.Println("    Subcommands: vol")
+	fmt.Println("    Subcommands: vol, host")
 	fmt.Println("    Example: openstack-tool storage vol list --ip=192.168.1.100 --username=admin --password=secret --long --timeout=300")
 	fmt.Println("  create")
 	fmt.Println("    Interactively create a new VM")
 	fmt.Println("    Example: openstack-tool create --verbose --timeout=300")
+	fmt.Println("  check")
+	fmt.Println("    Authenticate and probe the Identity, Compute, Volume, Image and Network endpoints")
+	fmt.Println("    Example: openstack-tool check --services=compute,volume --output=json")
 	fmt.Println("\nEnvironment Variables:")
 	fmt.Println("  OS_AUTH_URL, OS_USERNAME, OS_PASSWORD, OS_PROJECT_NAME, OS_DOMAIN_NAME, OS_REGION_NAME")
+	fmt.Println("  OS_USER_DOMAIN_NAME and OS_PROJECT_DOMAIN_NAME may be set instead of OS_DOMAIN_NAME")
 }
 
 func printManageVmsUsage() {
 	fmt.Println("Usage: openstack-tool vm manage <subcommand> [flags]")
-	fmt.Println("Subcommands: delete, force-delete, start, stop, pause, unpause, suspend, resume, reboot, set-state")
+	fmt.Println("Subcommands: delete, force-delete, start, stop, pause, unpause, suspend, resume, reboot, set-state, set-metadata, unset-metadata,")
+	fmt.Println("             console-log, console-url (read-only, never confirmed)")
 	fmt.Println("Flags:")
 	fmt.Println("  --verbose           Enable verbose logging")
-	fmt.Println("  --vm                VM name(s) or ID(s), comma-separated (e.g., vm1,vm2) (required)")
+	fmt.Println("  --vm                VM name(s) or ID(s), comma-separated (e.g., vm1,vm2)")
+	fmt.Println("  --filter            Select the project's VMs instead of --vm, using vm info filter keys (e.g., days>60,status=ACTIVE);")
+	fmt.Println("                      --dry-run lists the selected VMs with age and owner, and a real run always asks for confirmation")
 	fmt.Println("  --project           Project name (required)")
 	fmt.Println("  --dry-run           Perform a dry run without making changes")
-	fmt.Println("  --output            Output format (table or json, default: table)")
+	fmt.Println("  --output            Output format (table, json, csv or yaml, default: table)")
+	fmt.Println("  --no-header         Omit the header row of table and CSV output")
 	fmt.Println("  --timeout           Timeout in seconds for API operations (default: 300)")
 	fmt.Println("  --state             Desired state for set-state action (ACTIVE or ERROR)")
+	fmt.Println("  --metadata          key=value to set with set-metadata, or key to remove with unset-metadata; repeat for several keys")
+	fmt.Println("  --lines             Console lines to print for console-log (default: 50, 0 for the whole log)")
+	fmt.Println("  --console-type      Console for console-url: novnc or serial (default: novnc)")
+	fmt.Println("  --concurrency       VMs processed in parallel (default: 5); 1 processes them in order and prints each result as it completes")
+	fmt.Println("  --yes               Skip the single confirmation prompt before delete, force-delete, set-state and --filter actions")
+	fmt.Println("  --protected-file    File listing protected VM names, IDs or glob patterns")
+	fmt.Println("                      (default: ~/.config/openstack-tool/protected-vms.yaml)")
+	fmt.Println("  --override-protection  Act on protected VMs after typing 'override protection'")
+	fmt.Println("  --show-timing       Show the start time and duration of each action in table and CSV output")
+	fmt.Println("  --show-request-id   Show the Nova request ID of each action in table and CSV output")
+	fmt.Println("  --max-retries       Retries per VM after a 5xx response or timeout (default: 3); 4xx errors and dry runs are not retried")
+	fmt.Println("  --retry-delay       Delay before the first retry, one delay longer for each further retry (default: 2s)")
+	fmt.Println("  --fail-fast         Stop at the first VM that cannot be found or fails; VMs not yet started are skipped")
+	fmt.Println("  --wait              Wait until each VM reaches the status of the action, e.g. SHUTOFF for stop")
+	fmt.Println("  --wait-timeout      Longest wait for one VM with --wait; it is then marked timeout (default: 5m, 0 for only --timeout)")
+	fmt.Println("  --force             Run set-state on VMs that have a task in progress (skipped by default)")
+	fmt.Println("  --insecure          Skip TLS certificate verification for OpenStack API endpoints")
 	fmt.Println("Examples:")
 	fmt.Println("  openstack-tool vm manage delete --vm=test-vm1,test-vm2 --project=admin --dry-run --output=table --timeout=300")
 	fmt.Println("  openstack-tool vm manage set-state --vm=test-vm1 --project=admin --state=ACTIVE --dry-run --output=json --timeout=300")
+	fmt.Println("  openstack-tool vm manage stop --project=sandbox --filter=\"days>60,status=ACTIVE\" --dry-run")
+	fmt.Println("  openstack-tool vm manage console-log --vm=stuck-vm --project=proj1 --lines=100")
+	fmt.Println("  openstack-tool vm manage set-metadata --vm=vm1,vm2 --project=proj1 --metadata owner_email=ops@example.com --metadata cost_center=4711")
 }
 
 func printStorageUsage() {
@@ -500,4 +1310,12 @@ func printStorageUsage() {
 	fmt.Println("    Manage storage volumes on Storage")
 	fmt.Println("    Example: openstack-tool storage vol list --ip=192.168.1.100 --username=admin --password=secret")
 	fmt.Println("    Actions: list")
+	fmt.Println("  host")
+	fmt.Println("    Audit array host definitions against Nova hypervisors")
+	fmt.Println("    Example: openstack-tool storage host audit --ip=192.168.1.100 --username=admin --password=secret --fail-on-mismatch")
+	fmt.Println("    Actions: audit")
+	fmt.Println("  pool")
+	fmt.Println("    Sum the volumes of each storage pool, with thin-provisioning savings")
+	fmt.Println("    Example: openstack-tool storage pool list --ip=192.168.1.100 --username=admin --password=secret --savings")
+	fmt.Println("    Actions: list")
 }
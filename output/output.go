@@ -0,0 +1,189 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats lists the values accepted by the --output flag
+var Formats = []string{"table", "json", "csv", "yaml"}
+
+// Validate returns an error listing the valid formats when format is not one of Formats
+func Validate(format string) error {
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format '%s'; valid: %s", format, strings.Join(Formats, ", "))
+}
+
+// NoHeader makes Table and CSV omit the header row, for appending output to an existing
+// report. It is bound to the --no-header flag; JSON and YAML are unaffected.
+var NoHeader bool
+
+// defaultEmpty is printed by Table when a Result has no rows and no Empty message
+const defaultEmpty = "No results found."
+
+// Result is what a command prints. Table and CSV render Headers and Rows; JSON and YAML
+// render Data, falling back to one object per row keyed by header when Data is nil.
+type Result struct {
+	Headers []string
+	Rows    [][]string
+	Data    interface{}
+	Empty   string // Printed to stderr by Table instead of an empty table
+}
+
+// AddRow appends a row, formatting each cell with %v
+func (r *Result) AddRow(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, c := range cells {
+		row[i] = fmt.Sprint(c)
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// data returns the value marshalled by JSON and YAML. Nil slices become empty ones so an
+// empty result is printed as [] rather than null.
+func (r *Result) data() interface{} {
+	if r.Data == nil {
+		rows := make([]map[string]string, 0, len(r.Rows))
+		for _, row := range r.Rows {
+			m := make(map[string]string, len(r.Headers))
+			for i, h := range r.Headers {
+				if i < len(row) {
+					m[h] = row[i]
+				}
+			}
+			rows = append(rows, m)
+		}
+		return rows
+	}
+	if v := reflect.ValueOf(r.Data); v.Kind() == reflect.Slice && v.IsNil() {
+		return []struct{}{}
+	}
+	return r.Data
+}
+
+// Printer writes a Result in one output format
+type Printer interface {
+	Print(r *Result) error
+}
+
+// New returns the Printer for format writing to w
+func New(format string, w io.Writer) (Printer, error) {
+	switch strings.ToLower(format) {
+	case "", "table":
+		return Table{W: w, Err: os.Stderr, NoHeader: NoHeader}, nil
+	case "json":
+		return JSON{W: w}, nil
+	case "csv":
+		return CSV{W: w, NoHeader: NoHeader}, nil
+	case "yaml":
+		return YAML{W: w}, nil
+	}
+	return nil, Validate(format)
+}
+
+// Print writes r to stdout in format
+func Print(format string, r *Result) error {
+	p, err := New(format, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return p.Print(r)
+}
+
+// Table prints aligned columns to W, or the Result's Empty message to Err when there are no
+// rows so that scripts reading W see no output rather than a lone header
+type Table struct {
+	W        io.Writer
+	Err      io.Writer
+	NoHeader bool
+}
+
+func (t Table) Print(r *Result) error {
+	if len(r.Rows) == 0 {
+		msg := r.Empty
+		if msg == "" {
+			msg = defaultEmpty
+		}
+		errW := t.Err
+		if errW == nil {
+			errW = t.W
+		}
+		_, err := fmt.Fprintln(errW, msg)
+		return err
+	}
+	w := tabwriter.NewWriter(t.W, 0, 0, 2, ' ', 0)
+	if !t.NoHeader {
+		fmt.Fprintln(w, strings.Join(r.Headers, "\t"))
+	}
+	for _, row := range r.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// JSON prints the Result's Data as indented JSON
+type JSON struct {
+	W io.Writer
+}
+
+func (j JSON) Print(r *Result) error {
+	data, err := json.MarshalIndent(r.data(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	_, err = fmt.Fprintln(j.W, string(data))
+	return err
+}
+
+// CSV prints the header row followed by every row; an empty Result prints only the header
+type CSV struct {
+	W        io.Writer
+	NoHeader bool
+}
+
+func (c CSV) Print(r *Result) error {
+	w := csv.NewWriter(c.W)
+	if !c.NoHeader {
+		if err := w.Write(r.Headers); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteAll(r.Rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
+
+// YAML prints the Result's Data as YAML, keeping the field order of its JSON encoding
+type YAML struct {
+	W io.Writer
+}
+
+func (y YAML) Print(r *Result) error {
+	data, err := json.Marshal(r.data())
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %v", err)
+	}
+	doc, err := yamlNode(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %v", err)
+	}
+	enc := yaml.NewEncoder(y.W)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write YAML: %v", err)
+	}
+	return enc.Close()
+}
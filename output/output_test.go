@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func TestEmptyResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		result  *Result
+		wantOut string
+		wantErr string
+	}{
+		{name: "json nil slice", format: "json", result: &Result{Data: []item(nil)}, wantOut: "[]\n"},
+		{name: "json no data and no rows", format: "json", result: &Result{Headers: []string{"Name"}}, wantOut: "[]\n"},
+		{name: "yaml nil slice", format: "yaml", result: &Result{Data: []item(nil)}, wantOut: "[]\n"},
+		{name: "csv header only", format: "csv", result: &Result{Headers: []string{"Name", "Size"}}, wantOut: "Name,Size\n"},
+		{name: "table empty message", format: "table", result: &Result{Headers: []string{"Name"}, Empty: "No volumes found in project demo."},
+			wantErr: "No volumes found in project demo.\n"},
+		{name: "table default message", format: "table", result: &Result{Headers: []string{"Name"}}, wantErr: defaultEmpty + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			p, err := New(tt.format, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table, ok := p.(Table); ok {
+				table.Err = &errOut
+				p = table
+			}
+			if err := p.Print(tt.result); err != nil {
+				t.Fatalf("Print: %v", err)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("got output %q, want %q", out.String(), tt.wantOut)
+			}
+			if errOut.String() != tt.wantErr {
+				t.Errorf("got stderr %q, want %q", errOut.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestYAMLKeyOrderAndQuoting(t *testing.T) {
+	data := struct {
+		Name   string   `json:"name"`
+		Status string   `json:"status"`
+		ID     string   `json:"id"`
+		Size   int      `json:"size"`
+		Tags   []string `json:"tags"`
+	}{Name: "web: 1", Status: "true", ID: "0123", Size: 10, Tags: []string{"a", "-b"}}
+	want := "name: 'web: 1'\nstatus: \"true\"\nid: \"0123\"\nsize: 10\ntags:\n  - a\n  - -b\n"
+	var out bytes.Buffer
+	if err := (YAML{W: &out}).Print(&Result{Data: data}); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,24 @@
+package output
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// yamlNode parses a JSON document into a YAML node tree. JSON is a subset of YAML, so the node
+// keeps the document's key order; the flow style and quoting of the JSON text are dropped so
+// that the encoder writes block style and quotes only the strings that need it.
+func yamlNode(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	clearStyle(&doc)
+	return &doc, nil
+}
+
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
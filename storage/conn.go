@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// conn is the SSH connection to the storage system for the duration of one Run or AuditHosts.
+// The handshake with the array takes seconds, so every command of a run uses the same
+// connection. Sessions are opened one at a time because the FlashSystem CLI limits the
+// concurrent sessions of a user. A connection that drops is redialed once per command; the
+// commands are read-only listings, so running one again is safe.
+type conn struct {
+	cfg    Config
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newConn(cfg Config) *conn {
+	return &conn{cfg: cfg}
+}
+
+// run runs command and returns its stdout and stderr. A non-zero exit status is returned as
+// the *ssh.ExitError of the session. When ctx ends, the session is closed and ctx.Err() returned.
+func (c *conn) run(ctx context.Context, command string) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stdout, stderr, err := c.runLocked(ctx, command)
+	if err != nil && ctx.Err() == nil && connectionLost(err) && c.client != nil {
+		log.Debugf("Connection to %s lost while running %s (%v), reconnecting", c.cfg.IP, command, err)
+		c.client.Close()
+		c.client = nil
+		stdout, stderr, err = c.runLocked(ctx, command)
+	}
+	return stdout, stderr, err
+}
+
+func (c *conn) runLocked(ctx context.Context, command string) (string, string, error) {
+	if c.client == nil {
+		client, err := c.dial(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		c.client = client
+	}
+	session, err := c.client.NewSession()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		session.Close()
+		return "", "", fmt.Errorf("%s interrupted: %w", command, ctx.Err())
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// dial connects to the storage system, giving up when ctx ends first
+func (c *conn) dial(ctx context.Context) (*ssh.Client, error) {
+	return connect(ctx, c.cfg)
+}
+
+// close closes the connection, if one was opened
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+// connectionLost reports whether err means the connection failed rather than the command.
+// Only an exit status comes from the command itself.
+func connectionLost(err error) bool {
+	var exitErr *ssh.ExitError
+	return !errors.As(err, &exitErr)
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnRunCancelledContext(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	c := newConn(Config{IP: "127.0.0.1", SSHPort: port, Username: "admin", Password: "secret", InsecureHostKey: true})
+	defer c.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, _, err = c.run(ctx, "lsvdisk -delim ,")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("run took %s after ctx was cancelled", time.Since(start))
+	}
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// ErrHostMismatch is returned by AuditHosts with FailOnMismatch when array hosts and hypervisors disagree
+var ErrHostMismatch = errors.New("array hosts and Nova hypervisors do not match")
+
+// ArrayHost is a host object defined on the FlashSystem
+type ArrayHost struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	WWPNs      []string `json:"wwpns"`
+	ISCSINames []string `json:"iscsi_names"`
+}
+
+// HostMatch pairs an array host with the Nova hypervisor it was matched to
+type HostMatch struct {
+	ArrayHost  string `json:"array_host"`
+	Hypervisor string `json:"hypervisor"`
+	MatchedBy  string `json:"matched_by"`
+}
+
+// HostAudit is the result of comparing array hosts with Nova hypervisors
+type HostAudit struct {
+	Matched             []HostMatch `json:"matched"`
+	OrphanedArrayHosts  []ArrayHost `json:"orphaned_array_hosts"`
+	UnmappedHypervisors []string    `json:"unmapped_hypervisors"`
+}
+
+// AuditHosts reports array hosts without a Nova hypervisor and hypervisors without an array host
+func AuditHosts(ctx context.Context, authClient *auth.Client, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+
+	if cfg.IP == "" || cfg.Username == "" || !util.HasSSHCredentials(cfg.Password) {
+		return fmt.Errorf("IP, Username, and a Password or ssh-agent are required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	log.Debug("Listing Nova hypervisors")
+	pages, err := hypervisors.List(authClient.Compute, nil).AllPages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list hypervisors: %v", err)
+	}
+	hypervisorList, err := hypervisors.ExtractHypervisors(pages)
+	if err != nil {
+		return fmt.Errorf("failed to extract hypervisors: %v", err)
+	}
+	log.Debugf("Found %d hypervisors", len(hypervisorList))
+
+	c := newConn(cfg)
+	defer c.close()
+
+	arrayHosts, err := listArrayHosts(ctx, c)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Found %d array hosts", len(arrayHosts))
+
+	audit := matchHosts(arrayHosts, hypervisorList)
+
+	result := &output.Result{Headers: []string{"Array Host", "Hypervisor", "Result"}, Data: audit, Empty: "No array hosts or hypervisors found."}
+	for _, m := range audit.Matched {
+		result.AddRow(m.ArrayHost, m.Hypervisor, "matched by "+m.MatchedBy)
+	}
+	for _, h := range audit.OrphanedArrayHosts {
+		result.AddRow(h.Name, "-", fmt.Sprintf("no hypervisor (WWPNs: %s)", strings.Join(h.WWPNs, " ")))
+	}
+	for _, name := range audit.UnmappedHypervisors {
+		result.AddRow("-", name, "no array host")
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return err
+	}
+	if cfg.OutputFormat == "table" {
+		fmt.Printf("Matched: %d, Orphaned array hosts: %d, Hypervisors without array host: %d\n",
+			len(audit.Matched), len(audit.OrphanedArrayHosts), len(audit.UnmappedHypervisors))
+	}
+
+	if cfg.FailOnMismatch && (len(audit.OrphanedArrayHosts) > 0 || len(audit.UnmappedHypervisors) > 0) {
+		return ErrHostMismatch
+	}
+	return nil
+}
+
+// listArrayHosts runs lshost and fetches the detailed view of every host for its WWPNs and iSCSI names
+func listArrayHosts(ctx context.Context, c *conn) ([]ArrayHost, error) {
+	out, err := runCommand(ctx, c, "lshost -delim ,")
+	if err != nil {
+		return nil, err
+	}
+	var hosts []ArrayHost
+	var columns map[string]int
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if columns == nil {
+			columns = make(map[string]int)
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		}
+		host := ArrayHost{ID: column(fields, columns, "id"), Name: column(fields, columns, "name"), Status: column(fields, columns, "status")}
+		if host.ID == "" || host.Name == "" {
+			log.Debugf("Skipping malformed lshost line: %s", line)
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+
+	for i := range hosts {
+		detail, err := runCommand(ctx, c, fmt.Sprintf("lshost -delim , %s", hosts[i].ID))
+		if err != nil {
+			log.Warnf("Failed to get details of array host %s: %v", hosts[i].Name, err)
+			continue
+		}
+		for _, line := range strings.Split(detail, "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), ",")
+			if !ok || value == "" {
+				continue
+			}
+			switch strings.ToLower(key) {
+			case "wwpn":
+				hosts[i].WWPNs = append(hosts[i].WWPNs, strings.ToLower(value))
+			case "iscsi_name":
+				hosts[i].ISCSINames = append(hosts[i].ISCSINames, strings.ToLower(value))
+			}
+		}
+	}
+	return hosts, nil
+}
+
+func column(fields []string, columns map[string]int, name string) string {
+	if i, ok := columns[name]; ok && i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+// shortHostname lowercases a hostname and strips its domain and separators so
+// "Compute-01.example.com" and "compute_01" compare equal
+func shortHostname(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
+
+// matchHosts pairs array hosts with hypervisors. Nova does not expose FC WWPNs, so there is no
+// WWPN matching: hosts are matched by short hostname first and then by the hypervisor name
+// appearing in an iSCSI IQN. WWPNs of unmatched array hosts are reported so they can be traced
+// on the fabric.
+func matchHosts(arrayHosts []ArrayHost, hypervisorList []hypervisors.Hypervisor) HostAudit {
+	audit := HostAudit{Matched: []HostMatch{}, OrphanedArrayHosts: []ArrayHost{}, UnmappedHypervisors: []string{}}
+	remaining := make(map[string]string) // short name -> hypervisor hostname
+	for _, h := range hypervisorList {
+		remaining[shortHostname(h.HypervisorHostname)] = h.HypervisorHostname
+		if h.Service.Host != "" {
+			remaining[shortHostname(h.Service.Host)] = h.HypervisorHostname
+		}
+	}
+	matchedHypervisors := make(map[string]bool)
+	// Names are tried longest first so that an IQN naming compute10 is not taken by compute1,
+	// and in a fixed order so that repeated audits give the same result
+	shortNames := make([]string, 0, len(remaining))
+	for short := range remaining {
+		shortNames = append(shortNames, short)
+	}
+	sort.Slice(shortNames, func(i, j int) bool {
+		if len(shortNames[i]) != len(shortNames[j]) {
+			return len(shortNames[i]) > len(shortNames[j])
+		}
+		return shortNames[i] < shortNames[j]
+	})
+
+	for _, host := range arrayHosts {
+		short := shortHostname(host.Name)
+		if hv, ok := remaining[short]; ok {
+			audit.Matched = append(audit.Matched, HostMatch{ArrayHost: host.Name, Hypervisor: hv, MatchedBy: "hostname"})
+			matchedHypervisors[hv] = true
+			continue
+		}
+		matched := false
+		for _, hvShort := range shortNames {
+			for _, iqn := range host.ISCSINames {
+				if strings.Contains(strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(iqn)), hvShort) {
+					hv := remaining[hvShort]
+					audit.Matched = append(audit.Matched, HostMatch{ArrayHost: host.Name, Hypervisor: hv, MatchedBy: "iscsi_name"})
+					matchedHypervisors[hv] = true
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			audit.OrphanedArrayHosts = append(audit.OrphanedArrayHosts, host)
+		}
+	}
+
+	for _, h := range hypervisorList {
+		if !matchedHypervisors[h.HypervisorHostname] {
+			audit.UnmappedHypervisors = append(audit.UnmappedHypervisors, h.HypervisorHostname)
+		}
+	}
+	sort.Slice(audit.Matched, func(i, j int) bool { return audit.Matched[i].ArrayHost < audit.Matched[j].ArrayHost })
+	sort.Strings(audit.UnmappedHypervisors)
+	return audit
+}
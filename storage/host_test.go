@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+)
+
+func hypervisor(name, serviceHost string) hypervisors.Hypervisor {
+	h := hypervisors.Hypervisor{HypervisorHostname: name}
+	h.Service.Host = serviceHost
+	return h
+}
+
+func TestMatchHosts(t *testing.T) {
+	arrayHosts := []ArrayHost{
+		{Name: "Compute_01", WWPNs: []string{"10000000c9a1b2c3"}},
+		{Name: "san-host-7", ISCSINames: []string{"iqn.1994-05.com.redhat:compute10-a1b2"}},
+		{Name: "san-host-8", ISCSINames: []string{"iqn.1994-05.com.redhat:COMPUTE-2"}},
+		{Name: "old_node", WWPNs: []string{"10000000c9d4e5f6"}},
+	}
+	hypervisorList := []hypervisors.Hypervisor{
+		hypervisor("compute-01.example.com", ""),
+		hypervisor("compute1.example.com", "compute1"),
+		hypervisor("compute10.example.com", "compute10"),
+		hypervisor("compute2.example.com", ""),
+		hypervisor("compute3.example.com", ""),
+	}
+	want := HostAudit{
+		Matched: []HostMatch{
+			{ArrayHost: "Compute_01", Hypervisor: "compute-01.example.com", MatchedBy: "hostname"},
+			{ArrayHost: "san-host-7", Hypervisor: "compute10.example.com", MatchedBy: "iscsi_name"},
+			{ArrayHost: "san-host-8", Hypervisor: "compute2.example.com", MatchedBy: "iscsi_name"},
+		},
+		OrphanedArrayHosts:  []ArrayHost{{Name: "old_node", WWPNs: []string{"10000000c9d4e5f6"}}},
+		UnmappedHypervisors: []string{"compute1.example.com", "compute3.example.com"},
+	}
+	// Map iteration order varies between runs, so repeat to catch nondeterministic matching
+	for i := 0; i < 50; i++ {
+		if got := matchHosts(arrayHosts, hypervisorList); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestMatchHostsEmpty(t *testing.T) {
+	got := matchHosts(nil, nil)
+	if got.Matched == nil || got.OrphanedArrayHosts == nil || got.UnmappedHypervisors == nil {
+		t.Errorf("got nil lists in %+v, want empty ones for JSON", got)
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// Orphan kinds reported by --orphans-only
+const (
+	orphanArrayOnly  = "array-only"              // No Cinder volume has the array volume's WWN
+	orphanUnattached = "unattached in OpenStack" // A Cinder volume matches but no server uses it
+)
+
+// cinderVolume is the Cinder side of an array volume matched by WWN
+type cinderVolume struct {
+	Name       string
+	AttachedVM string // Names of the servers the volume is attached to, empty when available
+}
+
+// normalizeWWN returns wwn in the form used as match key: lower case hex without separators
+func normalizeWWN(wwn string) string {
+	wwn = strings.ToLower(strings.TrimSpace(wwn))
+	wwn = strings.TrimPrefix(wwn, "0x")
+	return strings.NewReplacer(":", "", "-", "").Replace(wwn)
+}
+
+// fetchCinderVolumesByWWN lists the Cinder volumes of all projects and indexes them by the
+// volume_wwn metadata the storage driver records, resolving attached server names
+func fetchCinderVolumesByWWN(ctx context.Context, authClient *auth.Client) (map[string]cinderVolume, error) {
+	volumeClient, err := auth.NewBlockStorageV3Client(authClient)
+	if err != nil {
+		return nil, err
+	}
+	var all []volumes.Volume
+	err = volumes.List(volumeClient, volumes.ListOpts{AllTenants: true}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		volumeList, err := volumes.ExtractVolumes(page)
+		if err != nil {
+			return false, err
+		}
+		all = append(all, volumeList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Cinder volumes: %v", err)
+	}
+	log.Debugf("Fetched %d Cinder volumes", len(all))
+
+	serverNameCache := sync.Map{}
+	byWWN := make(map[string]cinderVolume)
+	for _, vol := range all {
+		wwn := normalizeWWN(vol.Metadata["volume_wwn"])
+		if wwn == "" {
+			continue
+		}
+		var attachedTo []string
+		for _, attachment := range vol.Attachments {
+			if name := getServerName(ctx, authClient, attachment.ServerID, &serverNameCache); name != "" {
+				attachedTo = append(attachedTo, name)
+			}
+		}
+		name := vol.Name
+		if name == "" {
+			name = vol.ID
+		}
+		byWWN[wwn] = cinderVolume{Name: name, AttachedVM: strings.Join(attachedTo, ", ")}
+	}
+	return byWWN, nil
+}
+
+// getServerName retrieves a server name from the cache or the compute API, falling back to the ID
+func getServerName(ctx context.Context, authClient *auth.Client, serverID string, serverNameCache *sync.Map) string {
+	if serverID == "" {
+		return ""
+	}
+	if cached, exists := serverNameCache.Load(serverID); exists {
+		return cached.(string)
+	}
+	server, err := servers.Get(ctx, authClient.Compute, serverID).Extract()
+	if err != nil {
+		log.Warnf("Failed to get server name for ID %s: %v", serverID, err)
+		return serverID
+	}
+	serverNameCache.Store(serverID, server.Name)
+	return server.Name
+}
+
+// matchOpenStack fills the OpenStack columns of each array volume and its orphan kind
+func matchOpenStack(vols []Volume, byWWN map[string]cinderVolume) {
+	for i := range vols {
+		cv, ok := byWWN[normalizeWWN(vols[i].WWN)]
+		switch {
+		case !ok:
+			vols[i].Orphan = orphanArrayOnly
+		case cv.AttachedVM == "":
+			vols[i].OpenStackVolume = cv.Name
+			vols[i].Orphan = orphanUnattached
+		default:
+			vols[i].OpenStackVolume = cv.Name
+			vols[i].AttachedVM = cv.AttachedVM
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// Pool sums the volumes of one storage pool
+type Pool struct {
+	Name            string       `json:"name"`
+	Volumes         int          `json:"volumes"`
+	VirtualCapacity int64        `json:"virtual_capacity"` // Bytes
+	Savings         *PoolSavings `json:"savings,omitempty"`
+}
+
+// PoolSavings compares what the volumes of a pool present to hosts with what the pool has
+// allocated to them. The fields are null when the array does not report real_capacity for
+// every volume of the pool.
+type PoolSavings struct {
+	RealCapacity     *int64   `json:"real_capacity"`          // Bytes
+	SavedCapacity    *int64   `json:"saved_capacity"`         // Virtual minus real, bytes
+	Oversubscription *float64 `json:"oversubscription_ratio"` // Virtual divided by real
+}
+
+// ListPools lists the pools that hold volumes with their volume count and virtual capacity.
+// With cfg.Savings, the real capacity of the volumes is summed too and the thin-provisioning
+// savings and oversubscription ratio of each pool are shown.
+func ListPools(ctx context.Context, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+
+	if cfg.IP == "" || cfg.Username == "" || !util.HasSSHCredentials(cfg.Password) {
+		return fmt.Errorf("IP, Username, and a Password or ssh-agent are required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	c := newConn(cfg)
+	defer c.close()
+
+	// -bytes prints capacities as plain numbers that can be summed
+	out, err := runCommand(ctx, c, "lsvdisk -bytes -delim ,")
+	if err != nil {
+		return err
+	}
+	volumes, err := parseLsvdiskOutput(out, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse lsvdisk output: %v", err)
+	}
+	pools := sumPools(volumes, cfg.Savings)
+
+	headers := []string{"Pool", "Volumes", "Virtual Capacity"}
+	if cfg.Savings {
+		headers = append(headers, "Real Capacity", "Saved", "Oversubscription")
+	}
+	result := &output.Result{Headers: headers, Data: pools, Empty: "No pools with volumes found."}
+	for _, p := range pools {
+		row := []interface{}{p.Name, p.Volumes, formatBytes(p.VirtualCapacity)}
+		if p.Savings != nil {
+			allocated, saved, ratio := notReported, notReported, notReported
+			if p.Savings.RealCapacity != nil {
+				allocated = formatBytes(*p.Savings.RealCapacity)
+				saved = formatBytes(*p.Savings.SavedCapacity)
+			}
+			if p.Savings.Oversubscription != nil {
+				ratio = fmt.Sprintf("%.2f:1", *p.Savings.Oversubscription)
+			}
+			row = append(row, allocated, saved, ratio)
+		}
+		result.AddRow(row...)
+	}
+	return output.Print(cfg.OutputFormat, result)
+}
+
+// sumPools groups volumes by pool, sorted by name. A volume whose capacity cannot be read is
+// left out of the sums with a warning; one without a real capacity makes its pool's savings n/a.
+func sumPools(volumes []Volume, savings bool) []Pool {
+	byName := make(map[string]*Pool)
+	realByName := make(map[string]int64)
+	unreported := make(map[string]bool)
+	for _, vol := range volumes {
+		p, ok := byName[vol.PoolName]
+		if !ok {
+			p = &Pool{Name: vol.PoolName}
+			byName[vol.PoolName] = p
+		}
+		p.Volumes++
+		virtual, err := strconv.ParseInt(vol.Capacity, 10, 64)
+		if err != nil {
+			log.Warnf("Volume %s has unreadable capacity %q, leaving it out of pool %s", vol.Name, vol.Capacity, vol.PoolName)
+			unreported[vol.PoolName] = true
+			continue
+		}
+		p.VirtualCapacity += virtual
+		allocated, err := strconv.ParseInt(vol.RealCapacity, 10, 64)
+		if err != nil {
+			log.Debugf("Volume %s has no real capacity (%q)", vol.Name, vol.RealCapacity)
+			unreported[vol.PoolName] = true
+			continue
+		}
+		realByName[vol.PoolName] += allocated
+	}
+
+	pools := make([]Pool, 0, len(byName))
+	for name, p := range byName {
+		if savings {
+			p.Savings = &PoolSavings{}
+			if !unreported[name] {
+				allocated := realByName[name]
+				saved := p.VirtualCapacity - allocated
+				p.Savings.RealCapacity, p.Savings.SavedCapacity = &allocated, &saved
+				if allocated > 0 {
+					ratio := float64(p.VirtualCapacity) / float64(allocated)
+					p.Savings.Oversubscription = &ratio
+				}
+			}
+		}
+		pools = append(pools, *p)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	return pools
+}
+
+// formatBytes formats a byte count in GiB, or in TiB from 1 TiB on
+func formatBytes(b int64) string {
+	const gib = 1 << 30
+	if b >= 1024*gib {
+		return fmt.Sprintf("%.2f TiB", float64(b)/(1024*gib))
+	}
+	return fmt.Sprintf("%.2f GiB", float64(b)/gib)
+}
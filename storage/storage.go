@@ -1,15 +1,16 @@
 package storage
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -18,145 +19,197 @@ var log = logrus.New()
 
 // Config holds configuration parameters for the storage module
 type Config struct {
-	IP       string
-	Username string
-	Password string
-	Long     bool
-	Verbose  bool
-	Timeout  int // Timeout in seconds
+	IP              string
+	Username        string
+	Password        string // Optional when ssh-agent holds a usable key
+	Long            bool
+	Verbose         bool
+	Timeout         int    // Timeout in seconds
+	InsecureHostKey bool   // Skip SSH host key verification
+	SSHPort         int    // SSH port of the storage system
+	SSHBastion      string // Jump host as user@host[:port]; empty to connect directly
+	OutputFormat    string
+	FailOnMismatch  bool // host audit: return ErrHostMismatch when array hosts and hypervisors disagree
+	MatchOpenStack  bool // vol list: match volumes to Cinder volumes by WWN and show the attached VM
+	OrphansOnly     bool // vol list: show only array-only volumes and Cinder volumes without an attachment
+	Savings         bool // pool list: add real capacity, thin-provisioning savings and oversubscription ratio
 }
 
 // Volume represents a volume on the FlashSystem
 type Volume struct {
 	ID         string `json:"id"`
 	Name       string `json:"name"`
-	Capacity   string `json:"capacity"`
+	Capacity   string `json:"capacity"` // Virtual capacity
 	PoolName   string `json:"pool_name"`
 	Status     string `json:"status"`
 	VolumeType string `json:"volume_type"`
 	WWN        string `json:"wwn"`
 	HostName   string `json:"host_name"`
+
+	// Capacity written and allocated in the pool; "n/a" when the firmware does not report them
+	UsedCapacity string `json:"used_capacity"`
+	RealCapacity string `json:"real_capacity"`
+
+	OpenStackVolume string `json:"openstack_volume,omitempty"` // Cinder volume with the same WWN
+	AttachedVM      string `json:"attached_vm,omitempty"`      // Servers the Cinder volume is attached to
+	Orphan          string `json:"orphan,omitempty"`           // array-only or unattached in OpenStack
 }
 
-// Run executes the storage volume listing logic (handles 'list' action)
-func Run(ctx context.Context, cfg Config) error {
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
+// Run executes the storage volume listing logic (handles 'list' action). authClient is only
+// used with MatchOpenStack or OrphansOnly.
+func Run(ctx context.Context, authClient *auth.Client, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
 
 	// Validate input arguments
-	if cfg.IP == "" || cfg.Username == "" || cfg.Password == "" {
-		return fmt.Errorf("all fields IP, Username, and Password are required")
+	if cfg.IP == "" || cfg.Username == "" || !util.HasSSHCredentials(cfg.Password) {
+		return fmt.Errorf("IP, Username, and a Password or ssh-agent are required")
 	}
 
 	// Apply timeout to context
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
 	defer cancel()
 
-	// SSH configuration
-	config := &ssh.ClientConfig{
-		User: cfg.Username,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(cfg.Password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // Insecure; use known_hosts in production
-	}
-
-	// Connect to the FlashSystem
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:22", cfg.IP), config)
-	if err != nil {
-		return fmt.Errorf("failed to connect via SSH: %v", err)
-	}
-	defer client.Close()
-
-	// Create a session for lsvdisk
-	session, err := client.NewSession()
-	if err != nil {
-		return fmt.Errorf("failed to create SSH session: %v", err)
-	}
-	defer session.Close()
+	// Connect to the FlashSystem on the first command; later commands reuse the connection
+	c := newConn(cfg)
+	defer c.close()
 
 	// Run lsvdisk command with CSV delimiter
-	var lsvdiskStdout, lsvdiskStderr bytes.Buffer
-	session.Stdout = &lsvdiskStdout
-	session.Stderr = &lsvdiskStderr
 	log.Println("Executing command: lsvdisk -delim ,")
-	err = session.Run("lsvdisk -delim ,")
+	lsvdiskStdout, lsvdiskStderr, err := c.run(ctx, "lsvdisk -delim ,")
 	if err != nil {
-		return fmt.Errorf("failed to run lsvdisk: %v, stderr: %s", err, lsvdiskStderr.String())
+		return fmt.Errorf("failed to run lsvdisk: %v, stderr: %s", err, lsvdiskStderr)
 	}
 
 	// If verbose, print raw lsvdisk output and exit
 	if cfg.Verbose {
 		fmt.Println("Raw lsvdisk output:")
-		fmt.Println(lsvdiskStdout.String())
+		fmt.Println(lsvdiskStdout)
 		return nil
 	}
 
 	// Run lshostvdiskmap to get all host-to-volume mappings
-	hostMap, err := getHostMappings(client)
+	hostMap, err := getHostMappings(ctx, c)
 	if err != nil {
 		return fmt.Errorf("failed to get host mappings: %v", err)
 	}
 
 	// Parse lsvdisk output
-	volumes, err := parseLsvdiskOutput(lsvdiskStdout.String(), hostMap)
+	volumes, err := parseLsvdiskOutput(lsvdiskStdout, hostMap)
 	if err != nil {
 		return fmt.Errorf("failed to parse lsvdisk output: %v", err)
 	}
 
-	// Output results
-	if len(volumes) == 0 {
-		fmt.Println("No volumes found on Storage.")
-		return nil
+	// --orphans-only needs the OpenStack side to tell the two kinds of orphan apart
+	if cfg.MatchOpenStack || cfg.OrphansOnly {
+		byWWN, err := fetchCinderVolumesByWWN(ctx, authClient)
+		if err != nil {
+			return err
+		}
+		matchOpenStack(volumes, byWWN)
+		if cfg.OrphansOnly {
+			var orphans []Volume
+			for _, vol := range volumes {
+				if vol.Orphan != "" {
+					orphans = append(orphans, vol)
+				}
+			}
+			volumes = orphans
+		}
 	}
 
+	// Output results; JSON and YAML always carry every field of Volume
+	result := &output.Result{Data: volumes, Empty: "No volumes found on Storage."}
+	if cfg.OrphansOnly {
+		result.Empty = "No orphaned volumes found on Storage."
+	}
+	matched := cfg.MatchOpenStack || cfg.OrphansOnly
 	if cfg.Long {
-		// Detailed format with all fields
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tName\tCapacity\tPool Name\tStatus\tVolume Type\tWWN\tHost Name")
-		fmt.Fprintln(w, "--------------------------------------------------------------------------------")
-		for _, vol := range volumes {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				vol.ID, vol.Name, vol.Capacity, vol.PoolName, vol.Status, vol.VolumeType, vol.WWN, vol.HostName)
-		}
-		w.Flush()
+		result.Headers = append([]string{"ID", "Name", "Capacity", "Used Capacity", "Real Capacity", "Pool Name", "Status", "Volume Type", "WWN", "Host Name"},
+			matchHeaders(matched, cfg.OrphansOnly)...)
 	} else {
-		// Compact format with Name, PoolName, WWN, HostName
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Name\tPool Name\tWWN\tHost Name")
-		fmt.Fprintln(w, "--------------------------------------------")
-		for _, vol := range volumes {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-				vol.Name, vol.PoolName, vol.WWN, vol.HostName)
+		result.Headers = append([]string{"Name", "Pool Name", "WWN", "Host Name"}, matchHeaders(matched, cfg.OrphansOnly)...)
+	}
+	for _, vol := range volumes {
+		var row []interface{}
+		if cfg.Long {
+			row = []interface{}{vol.ID, vol.Name, vol.Capacity, vol.UsedCapacity, vol.RealCapacity, vol.PoolName, vol.Status, vol.VolumeType, vol.WWN, vol.HostName}
+		} else {
+			row = []interface{}{vol.Name, vol.PoolName, vol.WWN, vol.HostName}
 		}
-		w.Flush()
+		result.AddRow(append(row, matchColumns(vol, matched, cfg.OrphansOnly)...)...)
 	}
+	return output.Print(cfg.OutputFormat, result)
+}
 
-	return nil
+// matchHeaders returns the extra headers of --match-openstack and --orphans-only
+func matchHeaders(matched, orphansOnly bool) []string {
+	if !matched {
+		return nil
+	}
+	if orphansOnly {
+		return []string{"OpenStack Volume", "Orphan"}
+	}
+	return []string{"OpenStack Volume", "Attached VM"}
 }
 
-// getHostMappings runs lshostvdiskmap -delim , and returns a map of volume names to host names
-func getHostMappings(client *ssh.Client) (map[string]string, error) {
-	session, err := client.NewSession()
+// matchColumns returns vol's values for the columns of matchHeaders
+func matchColumns(vol Volume, matched, orphansOnly bool) []interface{} {
+	if !matched {
+		return nil
+	}
+	if orphansOnly {
+		return []interface{}{vol.OpenStackVolume, vol.Orphan}
+	}
+	return []interface{}{vol.OpenStackVolume, vol.AttachedVM}
+}
+
+// connect opens an SSH connection to the storage system
+func connect(ctx context.Context, cfg Config) (*ssh.Client, error) {
+	hostKeyCallback, err := util.HostKeyCallback(cfg.InsecureHostKey)
+	if err != nil {
+		return nil, err
+	}
+	authMethods, closeAgent, err := util.SSHAuthMethods(cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent() // The agent is only needed for the handshake
+	config := &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	}
+	client, err := util.DialSSHContext(ctx, util.SSHAddress(cfg.IP, cfg.SSHPort), config, cfg.SSHBastion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+	return client, nil
+}
+
+// runCommand runs a CLI command on the storage system and returns its stdout
+func runCommand(ctx context.Context, c *conn, command string) (string, error) {
+	log.Debugf("Executing command: %s", command)
+	stdout, stderr, err := c.run(ctx, command)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create SSH session: %v", err)
+		return "", fmt.Errorf("failed to run %s: %v, stderr: %s", command, err, stderr)
 	}
-	defer session.Close()
+	return stdout, nil
+}
 
-	var stdout, stderr bytes.Buffer
-	session.Stdout = &stdout
-	session.Stderr = &stderr
+// getHostMappings runs lshostvdiskmap -delim , and returns a map of volume names to host names
+func getHostMappings(ctx context.Context, c *conn) (map[string]string, error) {
 	log.Println("Executing command: lshostvdiskmap -delim ,")
-	err = session.Run("lshostvdiskmap -delim ,")
+	stdout, stderr, err := c.run(ctx, "lshostvdiskmap -delim ,")
 	if err != nil {
-		if strings.Contains(stderr.String(), "No host mappings found") || stdout.String() == "" {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) && (strings.Contains(stderr, "No host mappings found") || stdout == "") {
 			return make(map[string]string), nil // No mappings exist
 		}
-		return nil, fmt.Errorf("failed to run lshostvdiskmap: %v, stderr: %s", err, stderr.String())
+		return nil, fmt.Errorf("failed to run lshostvdiskmap: %v, stderr: %s", err, stderr)
 	}
 
 	hostMap := make(map[string]string)
-	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
 	for _, line := range lines {
 		if line == "" || strings.HasPrefix(line, "id,") {
 			continue
@@ -176,33 +229,51 @@ func getHostMappings(client *ssh.Client) (map[string]string, error) {
 	return hostMap, nil
 }
 
-// parseLsvdiskOutput parses the lsvdisk CSV output into a slice of Volume structs
+// lsvdiskColumns are the columns of lsvdisk -delim , on firmware that prints no header line
+var lsvdiskColumns = map[string]int{"id": 0, "name": 1, "status": 4, "mdisk_grp_name": 6, "capacity": 7, "type": 8, "vdisk_UID": 13}
+
+// notReported is shown for capacity columns the array's firmware does not print
+const notReported = "n/a"
+
+// parseLsvdiskOutput parses the lsvdisk CSV output into a slice of Volume structs. Columns are
+// found by the header line, so columns that only some firmware prints, such as used_capacity
+// and real_capacity, are read when present and reported as "n/a" otherwise.
 func parseLsvdiskOutput(output string, hostMap map[string]string) ([]Volume, error) {
 	var volumes []Volume
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if line == "" || strings.HasPrefix(line, "id,") {
+	columns := lsvdiskColumns
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
 			continue
 		}
 		fields := strings.Split(line, ",")
-		if len(fields) < 14 {
+		if strings.HasPrefix(line, "id,") {
+			columns = make(map[string]int)
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		}
+		if len(fields) <= columns["vdisk_UID"] {
 			log.Printf("Skipping malformed line (insufficient fields): %s", line)
 			continue
 		}
-		volumeName := fields[1]
+		volumeName := column(fields, columns, "name")
 		hostName, exists := hostMap[volumeName]
 		if !exists {
 			hostName = "None"
 		}
 		volume := Volume{
-			ID:         fields[0],  // id
-			Name:       fields[1],  // name
-			Status:     fields[4],  // status
-			Capacity:   fields[7],  // capacity
-			PoolName:   fields[6],  // mdisk_grp_name
-			VolumeType: fields[8],  // volume_type
-			WWN:        fields[13], // vdisk_UID
-			HostName:   hostName,
+			ID:           column(fields, columns, "id"),
+			Name:         volumeName,
+			Status:       column(fields, columns, "status"),
+			Capacity:     column(fields, columns, "capacity"),
+			UsedCapacity: reported(column(fields, columns, "used_capacity")),
+			RealCapacity: reported(column(fields, columns, "real_capacity")),
+			PoolName:     column(fields, columns, "mdisk_grp_name"),
+			VolumeType:   column(fields, columns, "type"),
+			WWN:          column(fields, columns, "vdisk_UID"),
+			HostName:     hostName,
 		}
 		volumes = append(volumes, volume)
 	}
@@ -211,3 +282,10 @@ func parseLsvdiskOutput(output string, hostMap map[string]string) ([]Volume, err
 	}
 	return volumes, nil
 }
+
+func reported(value string) string {
+	if value == "" {
+		return notReported
+	}
+	return value
+}
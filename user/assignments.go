@@ -0,0 +1,248 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// AssignmentExport is the file written by export-assignments and read by import-assignments
+type AssignmentExport struct {
+	Project     string            `json:"project"`
+	ProjectID   string            `json:"project_id"`
+	ExportedAt  time.Time         `json:"exported_at"`
+	Assignments []ActorAssignment `json:"assignments"`
+}
+
+// ActorAssignment lists the roles a user or group holds on the exported project. Names are
+// authoritative on import; IDs are kept for reference since they change when users are recreated.
+type ActorAssignment struct {
+	Type   string   `json:"type"` // "user" or "group"
+	Name   string   `json:"name"`
+	ID     string   `json:"id"`
+	Domain string   `json:"domain"`
+	Roles  []string `json:"roles"`
+}
+
+// ImportResult is the outcome of applying one role of one actor
+type ImportResult struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Result  string `json:"result"` // created, already-present, failed
+	Message string `json:"message,omitempty"`
+}
+
+// listProjectAssignments returns the direct role assignments on a project with entity names included
+func listProjectAssignments(ctx context.Context, client *auth.Client, projectID string) ([]roles.RoleAssignment, error) {
+	includeNames := true
+	var assignments []roles.RoleAssignment
+	err := roles.ListAssignments(client.Identity, roles.ListAssignmentsOpts{
+		ScopeProjectID: projectID,
+		IncludeNames:   &includeNames,
+	}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		assignmentList, err := roles.ExtractRoleAssignments(page)
+		if err != nil {
+			return false, err
+		}
+		assignments = append(assignments, assignmentList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list role assignments")
+	}
+	log.Debugf("Fetched %d role assignments for project %s", len(assignments), projectID)
+	return assignments, nil
+}
+
+func exportAssignments(ctx context.Context, client *auth.Client, projectName, projectDomainID, file string) error {
+	log.Debugf("Exporting role assignments of project %s to %s", projectName, file)
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
+	if err != nil {
+		return err
+	}
+	assignments, err := listProjectAssignments(ctx, client, projectID)
+	if err != nil {
+		return err
+	}
+
+	byActor := make(map[string]*ActorAssignment)
+	for _, a := range assignments {
+		var actor ActorAssignment
+		switch {
+		case a.User.ID != "":
+			actor = ActorAssignment{Type: "user", Name: a.User.Name, ID: a.User.ID, Domain: a.User.Domain.Name}
+		case a.Group.ID != "":
+			actor = ActorAssignment{Type: "group", Name: a.Group.Name, ID: a.Group.ID, Domain: a.Group.Domain.Name}
+		default:
+			continue
+		}
+		key := actor.Type + "/" + actor.ID
+		if _, ok := byActor[key]; !ok {
+			byActor[key] = &actor
+		}
+		byActor[key].Roles = append(byActor[key].Roles, a.Role.Name)
+	}
+
+	export := AssignmentExport{
+		Project:     projectName,
+		ProjectID:   projectID,
+		ExportedAt:  time.Now().UTC(),
+		Assignments: []ActorAssignment{},
+	}
+	for _, actor := range byActor {
+		sort.Strings(actor.Roles)
+		export.Assignments = append(export.Assignments, *actor)
+	}
+	sort.Slice(export.Assignments, func(i, j int) bool {
+		if export.Assignments[i].Type != export.Assignments[j].Type {
+			return export.Assignments[i].Type > export.Assignments[j].Type // users before groups
+		}
+		return export.Assignments[i].Name < export.Assignments[j].Name
+	})
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal JSON")
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file)
+	}
+	log.Infof("Exported %d role assignments for %d users and groups of project %s to %s",
+		len(assignments), len(export.Assignments), projectName, file)
+	return nil
+}
+
+func importAssignments(ctx context.Context, client *auth.Client, rc *roleCache, projectName, projectDomainID, file, outputFormat string) error {
+	log.Debugf("Importing role assignments from %s into project %s", file, projectName)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", file)
+	}
+	var export AssignmentExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", file)
+	}
+
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
+	if err != nil {
+		return err
+	}
+	existing, err := listProjectAssignments(ctx, client, projectID)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool) // "<actor ID>/<role ID>"
+	for _, a := range existing {
+		present[a.User.ID+a.Group.ID+"/"+a.Role.ID] = true
+	}
+
+	results := []ImportResult{}
+	var missing []string
+	failed := 0
+	for _, actor := range export.Assignments {
+		// Resolve by name: the rebuild may have recreated the user or group with a new ID
+		actorID, err := resolveActorID(ctx, client, actor)
+		if err != nil {
+			log.Debugf("Failed to resolve %s %s: %v", actor.Type, actor.Name, err)
+			missing = append(missing, fmt.Sprintf("%s %s (previous ID: %s)", actor.Type, actor.Name, actor.ID))
+			for _, roleName := range actor.Roles {
+				results = append(results, ImportResult{Type: actor.Type, Name: actor.Name, Role: roleName, Result: "failed", Message: err.Error()})
+			}
+			continue
+		}
+		if actorID != actor.ID {
+			log.Debugf("%s %s changed ID %s -> %s", actor.Type, actor.Name, actor.ID, actorID)
+		}
+
+		for _, roleName := range actor.Roles {
+			result := ImportResult{Type: actor.Type, Name: actor.Name, Role: roleName}
+			roleID, err := rc.id(ctx, client, roleName)
+			if err != nil {
+				result.Result, result.Message = "failed", err.Error()
+				results = append(results, result)
+				failed++
+				continue
+			}
+			if present[actorID+"/"+roleID] {
+				result.Result = "already-present"
+				results = append(results, result)
+				continue
+			}
+			opts := roles.AssignOpts{ProjectID: projectID}
+			if actor.Type == "group" {
+				opts.GroupID = actorID
+			} else {
+				opts.UserID = actorID
+			}
+			if err := roles.Assign(ctx, client.Identity, roleID, opts).ExtractErr(); err != nil {
+				log.Debugf("Failed to assign role %s to %s %s: %v", roleName, actor.Type, actor.Name, err)
+				result.Result, result.Message = "failed", err.Error()
+				failed++
+			} else {
+				result.Result = "created"
+				present[actorID+"/"+roleID] = true
+			}
+			results = append(results, result)
+		}
+	}
+
+	out := &output.Result{
+		Headers: []string{"Type", "Name", "Role", "Result", "Message"},
+		Data: struct {
+			Project string         `json:"project"`
+			Results []ImportResult `json:"results"`
+			Missing []string       `json:"missing"`
+		}{projectName, results, append([]string{}, missing...)},
+		Empty: "No assignments found in " + file,
+	}
+	for _, r := range results {
+		out.AddRow(r.Type, r.Name, r.Role, r.Result, r.Message)
+	}
+	if err := output.Print(outputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print import results")
+	}
+	if outputFormat == "table" && len(missing) > 0 {
+		fmt.Println("\nUsers and groups that no longer exist:")
+		for _, m := range missing {
+			fmt.Printf(" - %s\n", m)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d role assignments could not be applied", failed, len(results))
+	}
+	return nil
+}
+
+// resolveActorID looks up the current ID of an exported user or group by name within its domain
+func resolveActorID(ctx context.Context, client *auth.Client, actor ActorAssignment) (string, error) {
+	domainID, err := resolveDomainID(ctx, client, actor.Domain)
+	if err != nil {
+		return "", err
+	}
+	if actor.Type != "group" {
+		return getUserID(ctx, client, actor.Name, domainID)
+	}
+	allPages, err := groups.List(client.Identity, groups.ListOpts{Name: actor.Name, DomainID: domainID}).AllPages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list groups")
+	}
+	groupList, err := groups.ExtractGroups(allPages)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to extract groups")
+	}
+	if len(groupList) == 0 {
+		return "", fmt.Errorf("group '%s' not found", actor.Name)
+	}
+	return groupList[0].ID, nil
+}
@@ -0,0 +1,178 @@
+package user
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// RoleGrant is a role a user holds on a project, directly or through a group
+type RoleGrant struct {
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	ProjectID   string `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	RoleName    string `json:"role_name"`
+	Source      string `json:"source"` // direct, group:<name> or inherited
+}
+
+// grantKey identifies a grant regardless of how it was obtained
+type grantKey struct {
+	userID, projectID, roleID string
+}
+
+// effectiveGrants returns the project roles of userID (named userName), or of every user on
+// projectID when userID is empty, including roles held through group membership. Keystone's
+// effective listing expands groups but does not say which group a role came from, so direct and
+// group assignments are listed and expanded to label each grant. Effective assignments not found
+// that way, such as inherited ones, are added as "inherited"; when the cloud does not support
+// effective listing, the expanded assignments are used on their own.
+func effectiveGrants(ctx context.Context, client *auth.Client, rc *roleCache, userID, userName, projectID string) ([]RoleGrant, error) {
+	grants := make(map[grantKey]*RoleGrant)
+	var order []grantKey
+	add := func(a roles.RoleAssignment, uID, uName, source string) {
+		if a.Scope.Project.ID == "" {
+			return
+		}
+		key := grantKey{uID, a.Scope.Project.ID, a.Role.ID}
+		if _, ok := grants[key]; ok {
+			return
+		}
+		grants[key] = &RoleGrant{UserID: uID, UserName: uName, ProjectID: a.Scope.Project.ID, ProjectName: a.Scope.Project.Name, RoleName: a.Role.Name, Source: source}
+		order = append(order, key)
+	}
+
+	direct, err := listAssignments(ctx, client, roles.ListAssignmentsOpts{UserID: userID, ScopeProjectID: projectID})
+	if err != nil {
+		return nil, err
+	}
+	// Direct user assignments first so a role held both ways is reported as direct
+	for _, a := range direct {
+		if a.User.ID != "" {
+			add(a, a.User.ID, a.User.Name, "direct")
+		}
+	}
+
+	if userID != "" {
+		userGroups, err := listUserGroups(ctx, client, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range userGroups {
+			groupAssignments, err := listAssignments(ctx, client, roles.ListAssignmentsOpts{GroupID: g.ID})
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range groupAssignments {
+				add(a, userID, userName, "group:"+g.Name)
+			}
+		}
+	} else {
+		for _, a := range direct {
+			if a.Group.ID == "" {
+				continue
+			}
+			members, err := listGroupMembers(ctx, client, a.Group.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				add(a, m.ID, m.Name, "group:"+a.Group.Name)
+			}
+		}
+	}
+
+	effective := true
+	all, err := listAssignments(ctx, client, roles.ListAssignmentsOpts{UserID: userID, ScopeProjectID: projectID, Effective: &effective})
+	if err != nil {
+		log.Warnf("Effective role assignments not available: %v; showing direct and group assignments only", err)
+	} else {
+		for _, a := range all {
+			add(a, a.User.ID, a.User.Name, "inherited")
+		}
+	}
+
+	result := make([]RoleGrant, 0, len(order))
+	for _, key := range order {
+		g := grants[key]
+		if g.RoleName == "" {
+			if role, err := rc.get(ctx, client, key.roleID); err == nil {
+				g.RoleName = role.Name
+			} else {
+				log.Warnf("Failed to fetch role %s: %v", key.roleID, err)
+				g.RoleName = key.roleID
+			}
+		}
+		result = append(result, *g)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ProjectName != result[j].ProjectName {
+			return result[i].ProjectName < result[j].ProjectName
+		}
+		if result[i].UserName != result[j].UserName {
+			return result[i].UserName < result[j].UserName
+		}
+		return result[i].RoleName < result[j].RoleName
+	})
+	return result, nil
+}
+
+// listAssignments returns the role assignments matching opts with entity names included
+func listAssignments(ctx context.Context, client *auth.Client, opts roles.ListAssignmentsOpts) ([]roles.RoleAssignment, error) {
+	includeNames := true
+	opts.IncludeNames = &includeNames
+	var assignments []roles.RoleAssignment
+	err := roles.ListAssignments(client.Identity, opts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		assignmentList, err := roles.ExtractRoleAssignments(page)
+		if err != nil {
+			return false, err
+		}
+		assignments = append(assignments, assignmentList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list role assignments")
+	}
+	return assignments, nil
+}
+
+// listUserGroups returns the groups userID is a member of
+func listUserGroups(ctx context.Context, client *auth.Client, userID string) ([]groups.Group, error) {
+	var userGroups []groups.Group
+	err := users.ListGroups(client.Identity, userID).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		groupList, err := groups.ExtractGroups(page)
+		if err != nil {
+			return false, err
+		}
+		userGroups = append(userGroups, groupList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list groups of user %s", userID)
+	}
+	log.Debugf("User %s is a member of %d groups", userID, len(userGroups))
+	return userGroups, nil
+}
+
+// listGroupMembers returns the users in groupID
+func listGroupMembers(ctx context.Context, client *auth.Client, groupID string) ([]users.User, error) {
+	var members []users.User
+	err := users.ListInGroup(client.Identity, groupID, users.ListOpts{}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		userList, err := users.ExtractUsers(page)
+		if err != nil {
+			return false, err
+		}
+		members = append(members, userList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list members of group %s", groupID)
+	}
+	return members, nil
+}
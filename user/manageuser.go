@@ -2,12 +2,10 @@ package user
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
-	"text/tabwriter"
 
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
@@ -15,73 +13,136 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 // Logger for structured logging
 var log = logrus.New()
 
+// Config holds configuration parameters for the user-roles module
+type Config struct {
+	Verbose       bool
+	OutputFormat  string
+	Action        string
+	UserName      string
+	ProjectName   string
+	RoleName      string
+	UserDomain    string      // Domain name or ID used to disambiguate UserName
+	ProjectDomain string      // Domain name or ID used to disambiguate ProjectName
+	File          string      // Assignment file for export-assignments and import-assignments
+	Description   string      // Description of the role created by create-role
+	Force         bool        // delete-role: delete a role that still has assignments
+	WithCounts    bool        // list-roles: add the number of assignments of each role
+	NamePrefix    string      // report-users: only users whose name starts with this prefix
+	Quota         QuotaUpdate // set-quota: the quotas to change
+	DryRun        bool        // set-quota: print the changes without making them
+}
+
 // Run executes the user role management logic
-func Run(ctx context.Context, client *auth.Client, verbose bool, outputFormat, action, userName, projectName, roleName string) error {
-	log.Debugf("Starting user role management with config: Verbose=%v, OutputFormat=%s, Action=%s, User=%s, Project=%s, Role=%s",
-		verbose, outputFormat, action, userName, projectName, roleName)
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	if verbose {
-		log.SetLevel(logrus.DebugLevel)
-	}
+func Run(ctx context.Context, client *auth.Client, cfg Config) error {
+	log.Debugf("Starting user role management with config: Verbose=%v, OutputFormat=%s, Action=%s, User=%s, Project=%s, Role=%s, UserDomain=%s, ProjectDomain=%s, File=%s",
+		cfg.Verbose, cfg.OutputFormat, cfg.Action, cfg.UserName, cfg.ProjectName, cfg.RoleName, cfg.UserDomain, cfg.ProjectDomain, cfg.File)
+	util.SetupLogger(log, cfg.Verbose)
 
 	// Action validation
-	validActions := []string{"list", "assign", "remove", "list-roles", "list-users-by-role", "list-user-roles-all-projects", "list-users-in-project"}
-	if !contains(validActions, action) {
-		log.Debugf("Invalid action detected: %s", action)
-		return fmt.Errorf("invalid action: %s; valid actions: %v", action, validActions)
+	validActions := []string{"list", "assign", "remove", "list-roles", "list-users-by-role", "list-user-roles-all-projects", "list-users-in-project", "export-assignments", "import-assignments", "create-role", "delete-role", "report-users", "show-quota", "set-quota"}
+	if !contains(validActions, cfg.Action) {
+		log.Debugf("Invalid action detected: %s", cfg.Action)
+		return fmt.Errorf("invalid action: %s; valid actions: %v", cfg.Action, validActions)
+	}
+
+	// Resolve optional domain scopes once so every action uses the same IDs
+	userDomainID, err := resolveDomainID(ctx, client, cfg.UserDomain)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve user domain")
+	}
+	projectDomainID, err := resolveDomainID(ctx, client, cfg.ProjectDomain)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve project domain")
 	}
 
-	switch action {
+	// Roles are looked up per assignment by several actions; fetch each one once per command
+	rc := newRoleCache()
+
+	switch cfg.Action {
 	case "list":
 		log.Debug("Executing list action")
-		return listUsers(ctx, client, outputFormat)
+		return listUsers(ctx, client, cfg.OutputFormat)
 	case "assign":
-		if userName == "" || projectName == "" || roleName == "" {
+		if cfg.UserName == "" || cfg.ProjectName == "" || cfg.RoleName == "" {
 			log.Debug("Missing required flags for assign action")
 			return fmt.Errorf("user, project, and role flags are required for assign action")
 		}
-		log.Debugf("Executing assign action for user %s, project %s, role %s", userName, projectName, roleName)
-		return assignRole(ctx, client, userName, projectName, roleName)
+		log.Debugf("Executing assign action for user %s, project %s, role %s", cfg.UserName, cfg.ProjectName, cfg.RoleName)
+		return assignRole(ctx, client, cfg.UserName, userDomainID, cfg.ProjectName, projectDomainID, cfg.RoleName)
 	case "remove":
-		if userName == "" || projectName == "" || roleName == "" {
+		if cfg.UserName == "" || cfg.ProjectName == "" || cfg.RoleName == "" {
 			log.Debug("Missing required flags for remove action")
 			return fmt.Errorf("user, project, and role flags are required for remove action")
 		}
-		log.Debugf("Executing remove action for user %s, project %s, role %s", userName, projectName, roleName)
-		return removeRole(ctx, client, userName, projectName, roleName)
+		log.Debugf("Executing remove action for user %s, project %s, role %s", cfg.UserName, cfg.ProjectName, cfg.RoleName)
+		return removeRole(ctx, client, cfg.UserName, userDomainID, cfg.ProjectName, projectDomainID, cfg.RoleName)
 	case "list-roles":
 		log.Debug("Executing list-roles action")
-		return listRoles(ctx, client, outputFormat)
+		return listRoles(ctx, client, cfg.OutputFormat, cfg.WithCounts)
+	case "create-role", "delete-role":
+		if cfg.RoleName == "" {
+			log.Debugf("Missing role flag for %s action", cfg.Action)
+			return fmt.Errorf("role flag is required for %s action", cfg.Action)
+		}
+		log.Debugf("Executing %s action for role %s", cfg.Action, cfg.RoleName)
+		if cfg.Action == "create-role" {
+			return createRole(ctx, client, cfg.RoleName, cfg.Description)
+		}
+		return deleteRole(ctx, client, rc, cfg.RoleName, cfg.Force)
 	case "list-users-by-role":
-		if roleName == "" {
+		if cfg.RoleName == "" {
 			log.Debug("Missing role flag for list-users-by-role action")
 			return fmt.Errorf("role flag is required for list-users-by-role action")
 		}
-		log.Debugf("Executing list-users-by-role action for role %s", roleName)
-		return listUsersByRole(ctx, client, roleName, outputFormat)
+		log.Debugf("Executing list-users-by-role action for role %s", cfg.RoleName)
+		return listUsersByRole(ctx, client, rc, cfg.RoleName, cfg.OutputFormat)
 	case "list-user-roles-all-projects":
-		if userName == "" {
+		if cfg.UserName == "" {
 			log.Debug("Missing user flag for list-user-roles-all-projects action")
 			return fmt.Errorf("user flag is required for list-user-roles-all-projects action")
 		}
-		log.Debugf("Executing list-user-roles-all-projects action for user %s", userName)
-		return listUserRolesAllProjects(ctx, client, userName, outputFormat)
+		log.Debugf("Executing list-user-roles-all-projects action for user %s", cfg.UserName)
+		return listUserRolesAllProjects(ctx, client, rc, cfg.UserName, userDomainID, cfg.OutputFormat)
 	case "list-users-in-project":
-		if projectName == "" {
+		if cfg.ProjectName == "" {
 			log.Debug("Missing project flag for list-users-in-project action")
 			return fmt.Errorf("project flag is required for list-users-in-project action")
 		}
-		log.Debugf("Executing list-users-in-project action for project %s", projectName)
-		return listUsersInProject(ctx, client, projectName, outputFormat)
+		log.Debugf("Executing list-users-in-project action for project %s", cfg.ProjectName)
+		return listUsersInProject(ctx, client, rc, cfg.ProjectName, projectDomainID, cfg.OutputFormat)
+	case "report-users":
+		log.Debug("Executing report-users action")
+		return reportUsers(ctx, client, userDomainID, cfg.NamePrefix, cfg.OutputFormat)
+	case "show-quota", "set-quota":
+		if cfg.ProjectName == "" {
+			log.Debugf("Missing project flag for %s action", cfg.Action)
+			return fmt.Errorf("project flag is required for %s action", cfg.Action)
+		}
+		log.Debugf("Executing %s action for project %s", cfg.Action, cfg.ProjectName)
+		if cfg.Action == "show-quota" {
+			return showQuota(ctx, client, cfg.ProjectName, projectDomainID, cfg.OutputFormat)
+		}
+		return setQuota(ctx, client, cfg.ProjectName, projectDomainID, cfg.Quota, cfg.DryRun, cfg.OutputFormat)
+	case "export-assignments", "import-assignments":
+		if cfg.ProjectName == "" || cfg.File == "" {
+			log.Debugf("Missing project or file flag for %s action", cfg.Action)
+			return fmt.Errorf("project and file flags are required for %s action", cfg.Action)
+		}
+		log.Debugf("Executing %s action for project %s with file %s", cfg.Action, cfg.ProjectName, cfg.File)
+		if cfg.Action == "export-assignments" {
+			return exportAssignments(ctx, client, cfg.ProjectName, projectDomainID, cfg.File)
+		}
+		return importAssignments(ctx, client, rc, cfg.ProjectName, projectDomainID, cfg.File, cfg.OutputFormat)
 	default:
-		log.Debugf("Unsupported action encountered: %s", action)
-		return fmt.Errorf("unsupported action: %s", action)
+		log.Debugf("Unsupported action encountered: %s", cfg.Action)
+		return fmt.Errorf("unsupported action: %s", cfg.Action)
 	}
 }
 
@@ -131,37 +192,28 @@ func listUsers(ctx context.Context, client *auth.Client, outputFormat string) er
 		})
 	}
 
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for users")
-		data, err := json.MarshalIndent(outputUsers, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
-		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for users")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Name\tEmail")
-		for _, u := range outputUsers {
-			fmt.Fprintf(w, "%s\t%s\n", u.Name, u.Email)
-		}
-		w.Flush()
+	log.Debugf("Preparing %s output for users", outputFormat)
+	result := &output.Result{Headers: []string{"Name", "Email"}, Data: outputUsers, Empty: "No users found."}
+	for _, u := range outputUsers {
+		result.AddRow(u.Name, u.Email)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print users")
 	}
 	log.Debug("User listing completed")
 	return nil
 }
 
-func assignRole(ctx context.Context, client *auth.Client, userName, projectName, roleName string) error {
+func assignRole(ctx context.Context, client *auth.Client, userName, userDomainID, projectName, projectDomainID, roleName string) error {
 	log.Debugf("Assigning role %s to user %s in project %s", roleName, userName, projectName)
-	userID, err := getUserID(ctx, client, userName)
+	userID, err := getUserID(ctx, client, userName, userDomainID)
 	if err != nil {
 		log.Debugf("Failed to get user ID for %s: %v", userName, err)
 		return err
 	}
 	log.Debugf("Resolved user ID: %s", userID)
 
-	projectID, err := getProjectID(ctx, client, projectName)
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
 	if err != nil {
 		log.Debugf("Failed to get project ID for %s: %v", projectName, err)
 		return err
@@ -189,16 +241,16 @@ func assignRole(ctx context.Context, client *auth.Client, userName, projectName,
 	return nil
 }
 
-func removeRole(ctx context.Context, client *auth.Client, userName, projectName, roleName string) error {
+func removeRole(ctx context.Context, client *auth.Client, userName, userDomainID, projectName, projectDomainID, roleName string) error {
 	log.Debugf("Removing role %s from user %s in project %s", roleName, userName, projectName)
-	userID, err := getUserID(ctx, client, userName)
+	userID, err := getUserID(ctx, client, userName, userDomainID)
 	if err != nil {
 		log.Debugf("Failed to get user ID for %s: %v", userName, err)
 		return err
 	}
 	log.Debugf("Resolved user ID: %s", userID)
 
-	projectID, err := getProjectID(ctx, client, projectName)
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
 	if err != nil {
 		log.Debugf("Failed to get project ID for %s: %v", projectName, err)
 		return err
@@ -226,7 +278,7 @@ func removeRole(ctx context.Context, client *auth.Client, userName, projectName,
 	return nil
 }
 
-func listRoles(ctx context.Context, client *auth.Client, outputFormat string) error {
+func listRoles(ctx context.Context, client *auth.Client, outputFormat string, withCounts bool) error {
 	log.Debugf("Listing all roles with output format: %s", outputFormat)
 	var allRoles []roles.Role
 	err := roles.List(client.Identity, roles.ListOpts{}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
@@ -246,30 +298,47 @@ func listRoles(ctx context.Context, client *auth.Client, outputFormat string) er
 	}
 	log.Debugf("Total roles fetched: %d", len(allRoles))
 
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for roles")
-		data, err := json.MarshalIndent(allRoles, "", "  ")
+	var counts map[string]int
+	if withCounts {
+		assignments, err := listRoleAssignments(ctx, client, "", false)
 		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
+			return err
+		}
+		counts = make(map[string]int)
+		for _, a := range assignments {
+			counts[a.Role.ID]++
 		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for roles")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tName")
-		for _, r := range allRoles {
-			fmt.Fprintf(w, "%s\t%s\n", r.ID, r.Name)
+	}
+
+	log.Debugf("Preparing %s output for roles", outputFormat)
+	summaries := make([]RoleSummary, 0, len(allRoles))
+	headers := []string{"ID", "Name", "Description"}
+	if withCounts {
+		headers = append(headers, "Assignments")
+	}
+	result := &output.Result{Headers: headers, Empty: "No roles found."}
+	for _, r := range allRoles {
+		s := RoleSummary{ID: r.ID, Name: r.Name, DomainID: r.DomainID, Description: roleDescription(r)}
+		row := []interface{}{s.ID, s.Name, s.Description}
+		if withCounts {
+			count := counts[r.ID]
+			s.AssignmentCount = &count
+			row = append(row, count)
 		}
-		w.Flush()
+		summaries = append(summaries, s)
+		result.AddRow(row...)
+	}
+	result.Data = summaries
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print roles")
 	}
 	log.Debug("Role listing completed")
 	return nil
 }
 
-func listUsersByRole(ctx context.Context, client *auth.Client, roleName, outputFormat string) error {
+func listUsersByRole(ctx context.Context, client *auth.Client, rc *roleCache, roleName, outputFormat string) error {
 	log.Debugf("Listing users by role %s with output format: %s", roleName, outputFormat)
-	roleID, err := getRoleID(ctx, client, roleName)
+	roleID, err := rc.id(ctx, client, roleName)
 	if err != nil {
 		log.Debugf("Failed to get role ID for %s: %v", roleName, err)
 		return err
@@ -300,7 +369,7 @@ func listUsersByRole(ctx context.Context, client *auth.Client, roleName, outputF
 	log.Debug("Collecting unique users from assignments")
 	userMap := make(map[string]users.User)
 	for _, assignment := range assignments {
-		if assignment.User.ID != "" {
+		if _, seen := userMap[assignment.User.ID]; assignment.User.ID != "" && !seen {
 			log.Debugf("Processing assignment for user ID: %s", assignment.User.ID)
 			user, err := getUserByID(ctx, client, assignment.User.ID)
 			if err != nil {
@@ -327,167 +396,79 @@ func listUsersByRole(ctx context.Context, client *auth.Client, roleName, outputF
 		})
 	}
 
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for users by role")
-		data, err := json.MarshalIndent(allUsers, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
-		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for users by role")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Name\tEmail")
-		for _, u := range allUsers {
-			fmt.Fprintf(w, "%s\t%s\n", u.Name, u.Email)
-		}
-		w.Flush()
+	log.Debugf("Preparing %s output for users by role", outputFormat)
+	result := &output.Result{Headers: []string{"Name", "Email"}, Data: allUsers, Empty: "No users found."}
+	for _, u := range allUsers {
+		result.AddRow(u.Name, u.Email)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print users by role")
 	}
 	log.Debug("Users by role listing completed")
 	return nil
 }
 
-func listUserRolesAllProjects(ctx context.Context, client *auth.Client, userName, outputFormat string) error {
+func listUserRolesAllProjects(ctx context.Context, client *auth.Client, rc *roleCache, userName, userDomainID, outputFormat string) error {
 	log.Debugf("Listing user %s roles across all projects with output format: %s", userName, outputFormat)
-	userID, err := getUserID(ctx, client, userName)
+	userID, err := getUserID(ctx, client, userName, userDomainID)
 	if err != nil {
 		log.Debugf("Failed to get user ID for %s: %v", userName, err)
 		return err
 	}
 	log.Debugf("Resolved user ID: %s", userID)
 
-	var assignments []roles.RoleAssignment
-	err = roles.ListAssignments(client.Identity, roles.ListAssignmentsOpts{
-		UserID: userID,
-	}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-		log.Debug("Processing user role assignment page")
-		assignmentList, err := roles.ExtractRoleAssignments(page)
-		if err != nil {
-			log.Debugf("Failed to extract assignments from page: %v", err)
-			return false, err
-		}
-		log.Debugf("Extracted %d assignments from page", len(assignmentList))
-		assignments = append(assignments, assignmentList...)
-		return true, nil
-	})
+	grants, err := effectiveGrants(ctx, client, rc, userID, userName, "")
 	if err != nil {
 		log.Debugf("Failed to list assignments for user: %v", err)
 		return errors.Wrap(err, "failed to list assignments for user")
 	}
-	log.Debugf("Total assignments fetched: %d", len(assignments))
+	log.Debugf("Found %d project roles for user %s", len(grants), userName)
 
-	// Map to collect unique role names
-	log.Debug("Collecting unique role names from assignments")
-	roleMap := make(map[string]string)
-	for _, assignment := range assignments {
-		if assignment.Scope.Project.ID != "" {
-			log.Debugf("Processing assignment for project ID: %s", assignment.Scope.Project.ID)
-			role, err := getRoleByID(ctx, client, assignment.Role.ID)
-			if err != nil {
-				log.Warnf("Failed to fetch role %s: %v", assignment.Role.ID, err)
-				continue
-			}
-			log.Debugf("Adding role %s to map", role.Name)
-			roleMap[role.Name] = role.Name
-		}
+	result := &output.Result{Headers: []string{"Project", "Role Name", "Source"}, Data: grants, Empty: "No roles found."}
+	for _, g := range grants {
+		result.AddRow(g.ProjectName, g.RoleName, g.Source)
 	}
-	log.Debugf("Found %d unique roles", len(roleMap))
-
-	var roleAssignments []struct {
-		RoleName string `json:"role_name"`
-	}
-	for _, roleName := range roleMap {
-		log.Debugf("Adding role to output: %s", roleName)
-		roleAssignments = append(roleAssignments, struct {
-			RoleName string `json:"role_name"`
-		}{
-			RoleName: roleName,
-		})
-	}
-
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for user roles")
-		data, err := json.MarshalIndent(roleAssignments, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
-		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for user roles")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Role Name")
-		for _, ra := range roleAssignments {
-			fmt.Fprintf(w, "%s\n", ra.RoleName)
-		}
-		w.Flush()
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print user roles")
 	}
 	log.Debug("User roles listing completed")
 	return nil
 }
 
-func listUsersInProject(ctx context.Context, client *auth.Client, projectName, outputFormat string) error {
+// listUsersInProject lists the users holding a role on the project, directly or through a group,
+// one row per user and role
+func listUsersInProject(ctx context.Context, client *auth.Client, rc *roleCache, projectName, projectDomainID, outputFormat string) error {
 	log.Debugf("Listing users in project %s with output format: %s", projectName, outputFormat)
-	log.Warnf("list-users-in-project is a placeholder for project '%s'; full implementation requires roles.ListAssignments", projectName)
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
+	if err != nil {
+		log.Debugf("Failed to get project ID for %s: %v", projectName, err)
+		return err
+	}
+	log.Debugf("Resolved project ID: %s", projectID)
 
-	var allUsers []users.User
-	err := users.List(client.Identity, users.ListOpts{}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-		log.Debug("Processing user list page")
-		usersList, err := users.ExtractUsers(page)
-		if err != nil {
-			log.Debugf("Failed to extract users from page: %v", err)
-			return false, err
-		}
-		log.Debugf("Extracted %d users from page", len(usersList))
-		allUsers = append(allUsers, usersList...)
-		return true, nil
-	})
+	grants, err := effectiveGrants(ctx, client, rc, "", "", projectID)
 	if err != nil {
 		log.Debugf("Failed to list users in project: %v", err)
 		return errors.Wrap(err, "failed to list users in project")
 	}
-	log.Debugf("Total users fetched: %d", len(allUsers))
+	log.Debugf("Found %d user roles in project %s", len(grants), projectName)
 
-	// Custom struct for output without ID
-	type userOutput struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+	result := &output.Result{Headers: []string{"User Name", "Role Name", "Source"}, Data: grants, Empty: "No users found."}
+	for _, g := range grants {
+		result.AddRow(g.UserName, g.RoleName, g.Source)
 	}
-	var outputUsers []userOutput
-	for _, user := range allUsers {
-		log.Debugf("Processing user: %s, Email: %s", user.Name, user.Description)
-		outputUsers = append(outputUsers, userOutput{
-			Name:  user.Name,
-			Email: user.Description,
-		})
-	}
-
-	if strings.ToLower(outputFormat) == "json" {
-		log.Debug("Preparing JSON output for users in project")
-		data, err := json.MarshalIndent(outputUsers, "", "  ")
-		if err != nil {
-			log.Debugf("Failed to marshal JSON: %v", err)
-			return errors.Wrap(err, "failed to marshal JSON")
-		}
-		fmt.Println(string(data))
-	} else {
-		log.Debug("Preparing table output for users in project")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Name\tEmail")
-		for _, u := range outputUsers {
-			fmt.Fprintf(w, "%s\t%s\n", u.Name, u.Email)
-		}
-		w.Flush()
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print users in project")
 	}
 	log.Debug("Users in project listing completed")
 	return nil
 }
 
-func getUserID(ctx context.Context, client *auth.Client, userName string) (string, error) {
-	log.Debugf("Retrieving user ID for user name: %s", userName)
+func getUserID(ctx context.Context, client *auth.Client, userName, domainID string) (string, error) {
+	log.Debugf("Retrieving user ID for user name: %s, domain ID: %s", userName, domainID)
 	listOpts := users.ListOpts{
-		Name: userName,
+		Name:     userName,
+		DomainID: domainID,
 	}
 	allPages, err := users.List(client.Identity, listOpts).AllPages(ctx)
 	if err != nil {
@@ -501,16 +482,28 @@ func getUserID(ctx context.Context, client *auth.Client, userName string) (strin
 	}
 	if len(userList) == 0 {
 		log.Debugf("User '%s' not found", userName)
+		if domainID != "" {
+			return "", fmt.Errorf("user '%s' not found in domain %s", userName, domainID)
+		}
 		return "", fmt.Errorf("user '%s' not found", userName)
 	}
+	if len(userList) > 1 {
+		var candidates []string
+		for _, u := range userList {
+			candidates = append(candidates, fmt.Sprintf("%s (ID: %s, domain: %s)", u.Name, u.ID, getDomainName(ctx, client, u.DomainID)))
+		}
+		log.Debugf("User '%s' is ambiguous: %v", userName, candidates)
+		return "", fmt.Errorf("user name '%s' matches %d users; use --user-domain to select one: %s", userName, len(userList), strings.Join(candidates, ", "))
+	}
 	log.Debugf("Found user ID: %s for name %s", userList[0].ID, userName)
 	return userList[0].ID, nil
 }
 
-func getProjectID(ctx context.Context, client *auth.Client, projectName string) (string, error) {
-	log.Debugf("Retrieving project ID for project name: %s", projectName)
+func getProjectID(ctx context.Context, client *auth.Client, projectName, domainID string) (string, error) {
+	log.Debugf("Retrieving project ID for project name: %s, domain ID: %s", projectName, domainID)
 	listOpts := projects.ListOpts{
-		Name: projectName,
+		Name:     projectName,
+		DomainID: domainID,
 	}
 	allPages, err := projects.List(client.Identity, listOpts).AllPages(ctx)
 	if err != nil {
@@ -524,12 +517,63 @@ func getProjectID(ctx context.Context, client *auth.Client, projectName string)
 	}
 	if len(projectList) == 0 {
 		log.Debugf("Project '%s' not found", projectName)
+		if domainID != "" {
+			return "", fmt.Errorf("project '%s' not found in domain %s", projectName, domainID)
+		}
 		return "", fmt.Errorf("project '%s' not found", projectName)
 	}
+	if len(projectList) > 1 {
+		var candidates []string
+		for _, p := range projectList {
+			candidates = append(candidates, fmt.Sprintf("%s (ID: %s, domain: %s)", p.Name, p.ID, getDomainName(ctx, client, p.DomainID)))
+		}
+		log.Debugf("Project '%s' is ambiguous: %v", projectName, candidates)
+		return "", fmt.Errorf("project name '%s' matches %d projects; use --project-domain to select one: %s", projectName, len(projectList), strings.Join(candidates, ", "))
+	}
 	log.Debugf("Found project ID: %s for name %s", projectList[0].ID, projectName)
 	return projectList[0].ID, nil
 }
 
+// resolveDomainID returns the ID of the domain identified by name or ID; an empty input yields an empty ID
+func resolveDomainID(ctx context.Context, client *auth.Client, domain string) (string, error) {
+	if domain == "" {
+		return "", nil
+	}
+	log.Debugf("Resolving domain: %s", domain)
+	allPages, err := domains.List(client.Identity, domains.ListOpts{Name: domain}).AllPages(ctx)
+	if err != nil {
+		log.Debugf("Failed to list domains: %v", err)
+		return "", errors.Wrap(err, "failed to list domains")
+	}
+	domainList, err := domains.ExtractDomains(allPages)
+	if err != nil {
+		log.Debugf("Failed to extract domains: %v", err)
+		return "", errors.Wrap(err, "failed to extract domains")
+	}
+	if len(domainList) > 0 {
+		log.Debugf("Resolved domain %s to ID %s", domain, domainList[0].ID)
+		return domainList[0].ID, nil
+	}
+	// Fall back to treating the value as a domain ID
+	d, err := domains.Get(ctx, client.Identity, domain).Extract()
+	if err != nil {
+		log.Debugf("Domain %s not found by name or ID: %v", domain, err)
+		return "", fmt.Errorf("domain '%s' not found", domain)
+	}
+	log.Debugf("Resolved domain %s by ID", d.ID)
+	return d.ID, nil
+}
+
+// getDomainName returns the name of a domain for display, falling back to its ID
+func getDomainName(ctx context.Context, client *auth.Client, domainID string) string {
+	d, err := domains.Get(ctx, client.Identity, domainID).Extract()
+	if err != nil {
+		log.Debugf("Failed to get domain %s: %v", domainID, err)
+		return domainID
+	}
+	return d.Name
+}
+
 func getRoleID(ctx context.Context, client *auth.Client, roleName string) (string, error) {
 	log.Debugf("Retrieving role ID for role name: %s", roleName)
 	listOpts := roles.ListOpts{
@@ -565,6 +609,46 @@ func getUserByID(ctx context.Context, client *auth.Client, userID string) (users
 	return *user, nil
 }
 
+// roleCache remembers roles fetched during one command, by ID and by name. Lookups are
+// sequential, so it is not safe for concurrent use.
+type roleCache struct {
+	byID     map[string]roles.Role
+	idByName map[string]string
+}
+
+func newRoleCache() *roleCache {
+	return &roleCache{byID: make(map[string]roles.Role), idByName: make(map[string]string)}
+}
+
+// get returns the role with roleID, fetching it on first use
+func (rc *roleCache) get(ctx context.Context, client *auth.Client, roleID string) (roles.Role, error) {
+	if role, ok := rc.byID[roleID]; ok {
+		log.Debugf("Role cache hit for ID %s", roleID)
+		return role, nil
+	}
+	role, err := getRoleByID(ctx, client, roleID)
+	if err != nil {
+		return roles.Role{}, err
+	}
+	rc.byID[roleID] = role
+	rc.idByName[role.Name] = roleID
+	return role, nil
+}
+
+// id returns the ID of the role named roleName, looking it up on first use
+func (rc *roleCache) id(ctx context.Context, client *auth.Client, roleName string) (string, error) {
+	if roleID, ok := rc.idByName[roleName]; ok {
+		log.Debugf("Role cache hit for name %s", roleName)
+		return roleID, nil
+	}
+	roleID, err := getRoleID(ctx, client, roleName)
+	if err != nil {
+		return "", err
+	}
+	rc.idByName[roleName] = roleID
+	return roleID, nil
+}
+
 // Helper function to get role details by ID
 func getRoleByID(ctx context.Context, client *auth.Client, roleID string) (roles.Role, error) {
 	log.Debugf("Retrieving role details for ID: %s", roleID)
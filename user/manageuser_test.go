@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+// newFakeKeystone serves a Keystone whose user list is body
+func newFakeKeystone(t *testing.T, body string) *auth.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/v3/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	return &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+	}
+}
+
+func TestListUsersEmpty(t *testing.T) {
+	tests := []struct {
+		format     string
+		wantStdout string
+		wantStderr string
+	}{
+		{format: "json", wantStdout: "[]\n"},
+		{format: "yaml", wantStdout: "[]\n"},
+		{format: "table", wantStderr: "No users found.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			client := newFakeKeystone(t, `{"users": [], "links": {}}`)
+			var err error
+			stdout, stderr := testutil.CaptureOutput(t, func() {
+				err = listUsers(context.Background(), client, tt.format)
+			})
+			if err != nil {
+				t.Fatalf("listUsers: %v", err)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("got stdout %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestListUsersCancelledContext(t *testing.T) {
+	client := newFakeKeystone(t, `{"users": [{"id": "u1", "name": "alice"}], "links": {}}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var err error
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		err = listUsers(ctx, client, "json")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if stdout != "" {
+		t.Errorf("got stdout %q from a cancelled listing, want none", stdout)
+	}
+}
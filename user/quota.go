@@ -0,0 +1,183 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+	volumequotas "github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/quotasets"
+	computequotas "github.com/gophercloud/gophercloud/v2/openstack/compute/v2/quotasets"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// QuotaUsage is one row of show-quota: a project quota with what the project uses of it.
+// A limit of -1 means unlimited.
+type QuotaUsage struct {
+	Service  string `json:"service"`
+	Resource string `json:"resource"`
+	InUse    int    `json:"in_use"`
+	Limit    int    `json:"limit"`
+}
+
+// QuotaChange is one quota changed by set-quota
+type QuotaChange struct {
+	Service  string `json:"service"`
+	Resource string `json:"resource"`
+	Old      int    `json:"old"`
+	New      int    `json:"new"`
+	Status   string `json:"status"`
+}
+
+// QuotaUpdate holds the new quotas of set-quota; nil fields are left unchanged and -1 makes
+// a quota unlimited
+type QuotaUpdate struct {
+	Cores     *int
+	RAMGB     *int // Nova counts RAM in MB, so this is multiplied by 1024
+	Instances *int
+	Volumes   *int
+	Gigabytes *int
+}
+
+// showQuota prints the compute cores, RAM and instances quotas and the volume and gigabytes
+// quotas of projectName with their usage in one table
+func showQuota(ctx context.Context, client *auth.Client, projectName, projectDomainID, outputFormat string) error {
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
+	if err != nil {
+		return err
+	}
+	volumeClient, err := auth.NewBlockStorageV3Client(client)
+	if err != nil {
+		return err
+	}
+	compute, err := computequotas.GetDetail(ctx, client.Compute, projectID).Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get compute quotas of project %s", projectName)
+	}
+	volume, err := volumequotas.GetUsage(ctx, volumeClient, projectID).Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get volume quotas of project %s", projectName)
+	}
+
+	quotas := []QuotaUsage{
+		{Service: "compute", Resource: "cores", InUse: compute.Cores.InUse, Limit: compute.Cores.Limit},
+		{Service: "compute", Resource: "ram_mb", InUse: compute.RAM.InUse, Limit: compute.RAM.Limit},
+		{Service: "compute", Resource: "instances", InUse: compute.Instances.InUse, Limit: compute.Instances.Limit},
+		{Service: "volume", Resource: "volumes", InUse: volume.Volumes.InUse, Limit: volume.Volumes.Limit},
+		{Service: "volume", Resource: "gigabytes", InUse: volume.Gigabytes.InUse, Limit: volume.Gigabytes.Limit},
+	}
+	result := &output.Result{Headers: []string{"Service", "Resource", "In Use", "Limit", "Free"}, Data: quotas}
+	for _, q := range quotas {
+		free := "unlimited"
+		if q.Limit >= 0 {
+			free = fmt.Sprint(q.Limit - q.InUse)
+		}
+		result.AddRow(q.Service, q.Resource, q.InUse, formatLimit(q.Limit), free)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print quotas")
+	}
+	return nil
+}
+
+// setQuota changes the given compute and volume quotas of projectName and prints each change
+// as old → new. The compute and volume quotas are updated in one request each, and only
+// when one of their values differs. With dryRun nothing is changed.
+func setQuota(ctx context.Context, client *auth.Client, projectName, projectDomainID string, update QuotaUpdate, dryRun bool, outputFormat string) error {
+	projectID, err := getProjectID(ctx, client, projectName, projectDomainID)
+	if err != nil {
+		return err
+	}
+	volumeClient, err := auth.NewBlockStorageV3Client(client)
+	if err != nil {
+		return err
+	}
+	compute, err := computequotas.Get(ctx, client.Compute, projectID).Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get compute quotas of project %s", projectName)
+	}
+	volume, err := volumequotas.Get(ctx, volumeClient, projectID).Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get volume quotas of project %s", projectName)
+	}
+
+	ramMB := update.RAMGB
+	if ramMB != nil && *ramMB > 0 {
+		ramMB = gophercloud.IntToPointer(*ramMB * 1024)
+	}
+	var changes []QuotaChange
+	add := func(service, resource string, old int, value *int) *int {
+		if value == nil {
+			return nil
+		}
+		changes = append(changes, QuotaChange{Service: service, Resource: resource, Old: old, New: *value})
+		if old == *value {
+			return nil
+		}
+		return value
+	}
+	computeOpts := computequotas.UpdateOpts{
+		Cores:     add("compute", "cores", compute.Cores, update.Cores),
+		RAM:       add("compute", "ram_mb", compute.RAM, ramMB),
+		Instances: add("compute", "instances", compute.Instances, update.Instances),
+	}
+	volumeOpts := volumequotas.UpdateOpts{
+		Volumes:   add("volume", "volumes", volume.Volumes, update.Volumes),
+		Gigabytes: add("volume", "gigabytes", volume.Gigabytes, update.Gigabytes),
+	}
+	computeChanged := computeOpts.Cores != nil || computeOpts.RAM != nil || computeOpts.Instances != nil
+	volumeChanged := volumeOpts.Volumes != nil || volumeOpts.Gigabytes != nil
+
+	status := "updated"
+	if dryRun {
+		status = "dry-run"
+	}
+	if !dryRun && computeChanged {
+		log.Debugf("Updating compute quotas of project %s (ID: %s)", projectName, projectID)
+		updated, err := computequotas.Update(ctx, client.Compute, projectID, computeOpts).Extract()
+		if err != nil {
+			return errors.Wrapf(err, "failed to update compute quotas of project %s", projectName)
+		}
+		compute = updated
+	}
+	if !dryRun && volumeChanged {
+		log.Debugf("Updating volume quotas of project %s (ID: %s)", projectName, projectID)
+		updated, err := volumequotas.Update(ctx, volumeClient, projectID, volumeOpts).Extract()
+		if err != nil {
+			return errors.Wrapf(err, "failed to update volume quotas of project %s", projectName)
+		}
+		volume = updated
+	}
+
+	// Report the values the services stored rather than the requested ones
+	stored := map[string]int{
+		"cores": compute.Cores, "ram_mb": compute.RAM, "instances": compute.Instances,
+		"volumes": volume.Volumes, "gigabytes": volume.Gigabytes,
+	}
+	result := &output.Result{Headers: []string{"Service", "Resource", "Change", "Status"}, Data: changes}
+	for i := range changes {
+		c := &changes[i]
+		if c.Old == c.New {
+			c.Status = "unchanged"
+		} else {
+			c.Status = status
+			if !dryRun {
+				c.New = stored[c.Resource]
+			}
+		}
+		result.AddRow(c.Service, c.Resource, fmt.Sprintf("%s → %s", formatLimit(c.Old), formatLimit(c.New)), c.Status)
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print quota changes")
+	}
+	return nil
+}
+
+// formatLimit shows a quota limit, with -1 as unlimited
+func formatLimit(limit int) string {
+	if limit < 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
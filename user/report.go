@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// UserScope is one row of report-users: the projects a user holds any role on and the names
+// of those roles
+type UserScope struct {
+	UserID       string   `json:"user_id"`
+	UserName     string   `json:"user_name"`
+	Domain       string   `json:"domain"`
+	ProjectCount int      `json:"project_count"`
+	Projects     []string `json:"projects"`
+	Roles        []string `json:"roles"`
+}
+
+// reportUsers reports, for every user in domainID (all domains when empty) whose name starts
+// with namePrefix, the number of distinct projects they hold a role on and the role names.
+// All assignments come from one effective listing, which includes roles held through groups
+// and inherited from domains; when the cloud does not support it, the direct assignments are
+// used instead. Users are sorted by project count, widest first.
+func reportUsers(ctx context.Context, client *auth.Client, domainID, namePrefix, outputFormat string) error {
+	log.Debugf("Reporting user scopes in domain %q with name prefix %q", domainID, namePrefix)
+	var allUsers []users.User
+	err := users.List(client.Identity, users.ListOpts{DomainID: domainID}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		usersList, err := users.ExtractUsers(page)
+		if err != nil {
+			return false, err
+		}
+		allUsers = append(allUsers, usersList...)
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list users")
+	}
+
+	effective := true
+	assignments, err := listAssignments(ctx, client, roles.ListAssignmentsOpts{Effective: &effective})
+	if err != nil {
+		log.Warnf("Effective role assignments not available: %v; counting direct user assignments only", err)
+		assignments, err = listAssignments(ctx, client, roles.ListAssignmentsOpts{})
+		if err != nil {
+			return err
+		}
+	}
+	log.Debugf("Fetched %d role assignments", len(assignments))
+
+	// Group the project assignments by user in one pass
+	projectsByUser := make(map[string]map[string]bool)
+	rolesByUser := make(map[string]map[string]bool)
+	for _, a := range assignments {
+		if a.User.ID == "" || a.Scope.Project.ID == "" {
+			continue
+		}
+		if projectsByUser[a.User.ID] == nil {
+			projectsByUser[a.User.ID] = make(map[string]bool)
+			rolesByUser[a.User.ID] = make(map[string]bool)
+		}
+		project := a.Scope.Project.Name
+		if project == "" {
+			project = a.Scope.Project.ID
+		}
+		projectsByUser[a.User.ID][project] = true
+		role := a.Role.Name
+		if role == "" {
+			role = a.Role.ID
+		}
+		rolesByUser[a.User.ID][role] = true
+	}
+
+	domainNames := make(map[string]string)
+	scopes := []UserScope{}
+	for _, u := range allUsers {
+		if !strings.HasPrefix(u.Name, namePrefix) {
+			continue
+		}
+		if _, ok := domainNames[u.DomainID]; !ok {
+			domainNames[u.DomainID] = getDomainName(ctx, client, u.DomainID)
+		}
+		s := UserScope{
+			UserID:   u.ID,
+			UserName: u.Name,
+			Domain:   domainNames[u.DomainID],
+			Projects: sortedKeys(projectsByUser[u.ID]),
+			Roles:    sortedKeys(rolesByUser[u.ID]),
+		}
+		s.ProjectCount = len(s.Projects)
+		scopes = append(scopes, s)
+	}
+	sort.SliceStable(scopes, func(i, j int) bool {
+		if scopes[i].ProjectCount != scopes[j].ProjectCount {
+			return scopes[i].ProjectCount > scopes[j].ProjectCount
+		}
+		return scopes[i].UserName < scopes[j].UserName
+	})
+
+	result := &output.Result{Headers: []string{"User Name", "Domain", "Projects", "Roles"}, Data: scopes, Empty: "No users found."}
+	for _, s := range scopes {
+		result.AddRow(s.UserName, s.Domain, s.ProjectCount, strings.Join(s.Roles, ", "))
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print user report")
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of set in order, or an empty slice so that JSON shows []
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
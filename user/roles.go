@@ -0,0 +1,121 @@
+package user
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// RoleSummary is one row of list-roles
+type RoleSummary struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	DomainID        string `json:"domain_id"`
+	Description     string `json:"description"`
+	AssignmentCount *int   `json:"assignment_count,omitempty"` // Set with --with-counts
+}
+
+// roleDescription returns the description Keystone keeps among a role's extra attributes
+func roleDescription(role roles.Role) string {
+	if d, ok := role.Extra["description"].(string); ok {
+		return d
+	}
+	return ""
+}
+
+func createRole(ctx context.Context, client *auth.Client, roleName, description string) error {
+	log.Debugf("Creating role %s", roleName)
+	if _, err := getRoleID(ctx, client, roleName); err == nil {
+		return fmt.Errorf("role '%s' already exists", roleName)
+	}
+	opts := roles.CreateOpts{Name: roleName}
+	if description != "" {
+		opts.Extra = map[string]any{"description": description}
+	}
+	role, err := roles.Create(ctx, client.Identity, opts).Extract()
+	if err != nil {
+		log.Debugf("Failed to create role: %v", err)
+		return errors.Wrap(err, "failed to create role")
+	}
+	log.Infof("Created role %s (ID: %s)", role.Name, role.ID)
+	return nil
+}
+
+// deleteRole deletes roleName after a typed confirmation. A role that is still assigned is
+// refused unless force is set, in which case the assignments are listed before the prompt;
+// Keystone removes them together with the role.
+func deleteRole(ctx context.Context, client *auth.Client, rc *roleCache, roleName string, force bool) error {
+	log.Debugf("Deleting role %s", roleName)
+	roleID, err := rc.id(ctx, client, roleName)
+	if err != nil {
+		return err
+	}
+	assignments, err := listRoleAssignments(ctx, client, roleID, true)
+	if err != nil {
+		return err
+	}
+	if len(assignments) > 0 {
+		if !force {
+			return fmt.Errorf("role '%s' has %d assignment(s); use --force to list them and delete the role anyway", roleName, len(assignments))
+		}
+		result := &output.Result{Headers: []string{"Type", "Name", "Scope"}}
+		for _, a := range assignments {
+			actorType, actorName := "user", a.User.Name
+			if a.Group.ID != "" {
+				actorType, actorName = "group", a.Group.Name
+			}
+			scope := "project:" + a.Scope.Project.Name
+			if a.Scope.Project.ID == "" {
+				scope = "domain:" + a.Scope.Domain.Name
+			}
+			result.AddRow(actorType, actorName, scope)
+		}
+		fmt.Printf("Role %s is assigned %d time(s); these assignments are removed with it:\n", roleName, len(assignments))
+		if err := output.Print("table", result); err != nil {
+			return errors.Wrap(err, "failed to print role assignments")
+		}
+	}
+
+	fmt.Printf("About to delete role %s (ID: %s). Type 'confirm' to continue: ", roleName, roleID)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	if strings.ToLower(strings.TrimSpace(scanner.Text())) != "confirm" {
+		return fmt.Errorf("delete-role aborted by user")
+	}
+
+	if err := roles.Delete(ctx, client.Identity, roleID).ExtractErr(); err != nil {
+		log.Debugf("Failed to delete role: %v", err)
+		return errors.Wrap(err, "failed to delete role")
+	}
+	log.Infof("Deleted role %s", roleName)
+	return nil
+}
+
+// listRoleAssignments returns the direct assignments of roleID, or of every role when roleID is empty
+func listRoleAssignments(ctx context.Context, client *auth.Client, roleID string, includeNames bool) ([]roles.RoleAssignment, error) {
+	var assignments []roles.RoleAssignment
+	err := roles.ListAssignments(client.Identity, roles.ListAssignmentsOpts{
+		RoleID:       roleID,
+		IncludeNames: &includeNames,
+	}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		assignmentList, err := roles.ExtractRoleAssignments(page)
+		if err != nil {
+			return false, err
+		}
+		assignments = append(assignments, assignmentList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list role assignments")
+	}
+	log.Debugf("Fetched %d role assignments for role %q", len(assignments), roleID)
+	return assignments, nil
+}
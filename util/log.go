@@ -0,0 +1,25 @@
+package util
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logTimestampFormat is RFC3339 with milliseconds so slow operations can be correlated
+const logTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// SetupLogger sends a package logger to stderr at Info level, or at Debug level with
+// full timestamps when verbose is set. Stdout is left to command output, so that JSON, CSV
+// and YAML stay parseable whatever is logged.
+func SetupLogger(l *logrus.Logger, verbose bool) {
+	l.SetOutput(os.Stderr)
+	l.SetLevel(logrus.InfoLevel)
+	if verbose {
+		l.SetLevel(logrus.DebugLevel)
+		l.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: logTimestampFormat,
+		})
+	}
+}
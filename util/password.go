@@ -0,0 +1,38 @@
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// SSHPasswordEnv names the environment variable read for the SSH password when --password is
+// not given, which keeps the password out of the process list
+const SSHPasswordEnv = "OPENSTACK_TOOL_SSH_PASSWORD"
+
+// SSHPassword returns the SSH password to use: password when given, else SSHPasswordEnv. When
+// neither is set and no ssh-agent is running, it prompts on the terminal without echo. Without
+// a terminal it returns "", leaving the missing credentials to the caller's checks.
+func SSHPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	if env := os.Getenv(SSHPasswordEnv); env != "" {
+		return env, nil
+	}
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		return "", nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", nil
+	}
+	fmt.Fprint(os.Stderr, "SSH password: ")
+	read, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSH password: %v", err)
+	}
+	return string(read), nil
+}
@@ -1,20 +1,86 @@
 package util
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
 )
 
-// WithRetry executes a function with retries on failure.
-func WithRetry(attempts int, sleep time.Duration, fn func() error) error {
+// WithRetry executes a function with retries on failure. No attempt is started and no sleep
+// waited out once ctx has ended; the error then wraps ctx.Err().
+func WithRetry(ctx context.Context, attempts int, sleep time.Duration, fn func() error) error {
+	return WithRetryIf(ctx, attempts, sleep, func(error) bool { return true }, fn)
+}
+
+// WithRetryIf executes a function with retries, like WithRetry, but only retries errors for
+// which retryable returns true; any other error is returned at once.
+func WithRetryIf(ctx context.Context, attempts int, sleep time.Duration, retryable func(error) bool, fn func() error) error {
+	var lastErr error
 	for i := 0; i < attempts; i++ {
-		if err := fn(); err != nil {
-			if i == attempts-1 {
-				return err
-			}
-			time.Sleep(sleep * time.Duration(i+1))
-			continue
+		if err := ctx.Err(); err != nil {
+			return interrupted(err, lastErr)
+		}
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if i == attempts-1 || !retryable(err) {
+			return err
+		}
+		lastErr = err
+		if err := Sleep(ctx, sleep*time.Duration(i+1)); err != nil {
+			return interrupted(err, lastErr)
 		}
-		return nil
 	}
 	return nil
 }
+
+// Sleep waits for d, or returns ctx.Err() as soon as ctx ends
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// interrupted is the error of a retry loop that ctx ended, keeping the last attempt's error
+func interrupted(ctxErr, lastErr error) error {
+	if lastErr == nil || errors.Is(lastErr, ctxErr) {
+		return ctxErr
+	}
+	return fmt.Errorf("%w (last error: %v)", ctxErr, lastErr)
+}
+
+// TimeoutError returns err, or when err comes from the deadline of the command's --timeout,
+// an error saying so in place of the wrapped context.DeadlineExceeded. Errors wrapped with %v
+// lose the chain, so the message is checked too.
+func TimeoutError(err error, timeout time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		return fmt.Errorf("operation timed out after %s (increase --timeout)", timeout)
+	}
+	return err
+}
+
+// IsTransient reports whether err from an OpenStack call may succeed when repeated: a 5xx
+// response or a timed-out request. 4xx responses such as conflict, not found or forbidden
+// are never transient.
+func IsTransient(err error) bool {
+	var codeError gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &codeError) {
+		return codeError.Actual >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestWithRetryCancelledContext(t *testing.T) {
+	calls := 0
+	err := WithRetry(cancelledContext(), 3, time.Hour, func() error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if calls != 0 {
+		t.Errorf("fn was called %d times, want 0", calls)
+	}
+}
+
+func TestWithRetryCancelledDuringSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := errors.New("boom")
+	calls := 0
+	start := time.Now()
+	err := WithRetry(ctx, 3, time.Hour, func() error {
+		calls++
+		cancel()
+		return failure
+	})
+	if time.Since(start) > time.Minute {
+		t.Fatalf("WithRetry waited out its sleep after ctx was cancelled")
+	}
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got error %v, want context.Canceled with the last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn was called %d times, want 1", calls)
+	}
+}
+
+func TestSleepCancelledContext(t *testing.T) {
+	if err := Sleep(cancelledContext(), time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "other error", err: errors.New("boom"), want: "boom"},
+		{name: "cancelled", err: cancelledContext().Err(), want: context.Canceled.Error()},
+		{name: "deadline", err: context.DeadlineExceeded, want: "operation timed out after 30s (increase --timeout)"},
+		{name: "wrapped deadline", err: fmt.Errorf("list servers: %w", context.DeadlineExceeded), want: "operation timed out after 30s (increase --timeout)"},
+		{name: "deadline wrapped with %v", err: fmt.Errorf("list servers: %v", context.DeadlineExceeded), want: "operation timed out after 30s (increase --timeout)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TimeoutError(tt.err, 30*time.Second)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeoutErrorExpiredContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	err := TimeoutError(WithRetry(ctx, 3, time.Second, func() error { return nil }), 5*time.Second)
+	if err == nil || err.Error() != "operation timed out after 5s (increase --timeout)" {
+		t.Errorf("got error %v, want the --timeout message", err)
+	}
+}
@@ -0,0 +1,197 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyMigrationNote explains the change of default to users upgrading from releases that
+// accepted any SSH host key
+const hostKeyMigrationNote = "SSH host keys are verified against known_hosts by default; earlier releases accepted any key. " +
+	"Pass --insecure-host-key to keep the old behaviour"
+
+// HostKeyCallback returns a callback that verifies SSH host keys against
+// ~/.ssh/known_hosts, or one that accepts any key when insecure is set. A missing known_hosts
+// file or a rejected key is an error that explains the change of default and names
+// --insecure-host-key, which restores the unchecked behaviour of earlier releases.
+func HostKeyCallback(insecure bool) (ssh.HostKeyCallback, error) {
+	if insecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate home directory for known_hosts: %v. %s", err, hostKeyMigrationNote)
+	}
+	path := filepath.Join(home, ".ssh", "known_hosts")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist, so no SSH host key can be verified. Add the host keys with ssh-keyscan <host> >> %s. %s",
+			path, path, hostKeyMigrationNote)
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v. %s", path, err, hostKeyMigrationNote)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := callback(hostname, remote, key); err != nil {
+			return fmt.Errorf("host key of %s not accepted: %w (add it to %s with ssh-keyscan). %s",
+				hostname, err, path, hostKeyMigrationNote)
+		}
+		return nil
+	}, nil
+}
+
+// ValidateSSHPort checks that port is a usable TCP port number
+func ValidateSSHPort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid SSH port %d: must be between 1 and 65535", port)
+	}
+	return nil
+}
+
+// SSHAddress returns the dial address for host and port, bracketing IPv6 literals
+func SSHAddress(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// ParseBastion splits an --ssh-bastion value of the form user@host[:port] into the user and
+// the dial address; the port defaults to 22
+func ParseBastion(s string) (user, addr string, err error) {
+	user, hostPort, ok := strings.Cut(s, "@")
+	if !ok || user == "" || hostPort == "" {
+		return "", "", fmt.Errorf("invalid SSH bastion '%s': expected user@host[:port]", s)
+	}
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		// No port given; strip the brackets of an IPv6 literal before adding the default
+		return user, SSHAddress(strings.Trim(hostPort, "[]"), 22), nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid SSH bastion '%s': bad port '%s'", s, portStr)
+	}
+	if err := ValidateSSHPort(port); err != nil {
+		return "", "", fmt.Errorf("invalid SSH bastion '%s': %v", s, err)
+	}
+	return user, SSHAddress(host, port), nil
+}
+
+// DialSSH connects to addr with config. With a bastion (user@host[:port]) it first connects to
+// the bastion and opens the target connection through it; the bastion connection is closed
+// when the returned client is. The bastion uses config's auth methods and host key callback.
+func DialSSH(addr string, config *ssh.ClientConfig, bastion string) (*ssh.Client, error) {
+	if bastion == "" {
+		return ssh.Dial("tcp", addr, config)
+	}
+	user, bastionAddr, err := ParseBastion(bastion)
+	if err != nil {
+		return nil, err
+	}
+	bastionConfig := &ssh.ClientConfig{
+		User:            user,
+		Auth:            config.Auth,
+		HostKeyCallback: config.HostKeyCallback,
+		Timeout:         config.Timeout,
+	}
+	bastionClient, err := ssh.Dial("tcp", bastionAddr, bastionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to bastion %s: %v", bastionAddr, err)
+	}
+	conn, err := bastionClient.Dial("tcp", addr)
+	if err != nil {
+		bastionClient.Close()
+		return nil, fmt.Errorf("failed to reach %s through bastion %s: %v", addr, bastionAddr, err)
+	}
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		bastionClient.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(clientConn, chans, reqs)
+	go func() {
+		client.Wait()
+		bastionClient.Close()
+	}()
+	return client, nil
+}
+
+// DialSSHContext connects like DialSSH but gives up when ctx ends first. A connection that
+// still completes afterwards is closed.
+func DialSSHContext(ctx context.Context, addr string, config *ssh.ClientConfig, bastion string) (*ssh.Client, error) {
+	type dialResult struct {
+		client *ssh.Client
+		err    error
+	}
+	done := make(chan dialResult, 1)
+	go func() {
+		client, err := DialSSH(addr, config, bastion)
+		done <- dialResult{client, err}
+	}()
+	select {
+	case r := <-done:
+		return r.client, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.client != nil {
+				r.client.Close()
+			}
+		}()
+		return nil, fmt.Errorf("connecting to %s interrupted: %w", addr, ctx.Err())
+	}
+}
+
+// RunSessionContext calls run, which runs a command on session, and closes session when ctx
+// ends first so that the command does not outlive the caller's deadline
+func RunSessionContext(ctx context.Context, session *ssh.Session, run func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- run()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		session.Close()
+		return ctx.Err()
+	}
+}
+
+// SSHAuthMethods returns the auth methods for an SSH login: the keys of a running ssh-agent
+// (SSH_AUTH_SOCK) first, then password if one is given. A server that accepts none of the
+// agent's keys is offered the password next. The agent is asked for signatures during the
+// handshake, so the returned close function, which closes the agent connection, must only be
+// called once every dial with the methods has returned.
+func SSHAuthMethods(password string) ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { conn.Close() }
+		} else if password == "" {
+			return nil, nil, fmt.Errorf("failed to connect to ssh-agent at %s: %v", sock, err)
+		}
+	}
+	if password != "" {
+		methods = append(methods, ssh.Password(password))
+	}
+	if len(methods) == 0 {
+		closeAgent()
+		return nil, nil, fmt.Errorf("no SSH credentials: set %s, pass --password or load a key into ssh-agent (SSH_AUTH_SOCK)", SSHPasswordEnv)
+	}
+	return methods, closeAgent, nil
+}
+
+// HasSSHCredentials reports whether a password is given or an ssh-agent socket is set
+func HasSSHCredentials(password string) bool {
+	return password != "" || os.Getenv("SSH_AUTH_SOCK") != ""
+}
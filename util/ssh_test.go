@@ -0,0 +1,158 @@
+package util
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// TestDialSSHContextCancelled dials a server that accepts the connection but never answers
+// the SSH handshake, so only the cancelled context can end the dial
+func TestDialSSHContextCancelled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(chan net.Conn, 16)
+	go func() {
+		defer close(conns)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		for conn := range conns {
+			conn.Close()
+		}
+	})
+
+	config := &ssh.ClientConfig{User: "root", HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+	start := time.Now()
+	client, err := DialSSHContext(cancelledContext(), ln.Addr().String(), config, "")
+	if client != nil {
+		client.Close()
+		t.Fatal("got a client from a cancelled dial")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("DialSSHContext took %s after ctx was cancelled", time.Since(start))
+	}
+}
+
+func TestSSHAuthMethodsClosesAgent(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	methods, closeAgent, err := SSHAuthMethods("")
+	if err != nil {
+		t.Fatalf("SSHAuthMethods: %v", err)
+	}
+	if len(methods) != 1 {
+		t.Fatalf("got %d auth methods, want the agent only", len(methods))
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	closeAgent()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v reading from the agent side, want io.EOF after close", err)
+	}
+}
+
+func TestSSHAuthMethodsPasswordOnly(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	methods, closeAgent, err := SSHAuthMethods("secret")
+	if err != nil {
+		t.Fatalf("SSHAuthMethods: %v", err)
+	}
+	closeAgent()
+	if len(methods) != 1 {
+		t.Errorf("got %d auth methods, want the password only", len(methods))
+	}
+	if _, _, err := SSHAuthMethods(""); err == nil {
+		t.Error("got no error without credentials")
+	}
+}
+
+func TestHostKeyCallbackUnknownHost(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 22}
+
+	callback, err := HostKeyCallback(false)
+	if err != nil {
+		t.Fatalf("HostKeyCallback: %v", err)
+	}
+	err = callback("192.0.2.10:22", addr, key)
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		t.Fatalf("got %v, want a knownhosts.KeyError", err)
+	}
+	if !strings.Contains(err.Error(), "--insecure-host-key") {
+		t.Errorf("error %q does not name --insecure-host-key", err)
+	}
+
+	insecure, err := HostKeyCallback(true)
+	if err != nil {
+		t.Fatalf("HostKeyCallback: %v", err)
+	}
+	if err := insecure("192.0.2.10:22", addr, key); err != nil {
+		t.Errorf("insecure callback rejected the key: %v", err)
+	}
+}
+
+func TestHostKeyCallbackMissingKnownHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	_, err := HostKeyCallback(false)
+	if err == nil {
+		t.Fatal("HostKeyCallback accepted a missing known_hosts")
+	}
+	for _, want := range []string{"does not exist", "earlier releases accepted any key", "--insecure-host-key"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if _, err := HostKeyCallback(true); err != nil {
+		t.Errorf("HostKeyCallback(true): %v", err)
+	}
+}
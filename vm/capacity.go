@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/availabilityzones"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+)
+
+// hostCapacity is the free memory and vCPUs a hypervisor reports. Allocation ratios are not
+// applied, so an overcommitting scheduler may still place a flavor that does not fit here.
+type hostCapacity struct {
+	Host        string
+	ServiceHost string // Nova compute service host, the name zones and --compute-host use
+	FreeRAMMB   int
+	FreeVCPUs   int
+	Reported    bool // False when the hypervisor reports no vCPUs and memory (microversion 2.88+)
+}
+
+// is reports whether host names this hypervisor, by hypervisor hostname or service host, with
+// or without the domain
+func (c hostCapacity) is(host string) bool {
+	return strings.EqualFold(c.Host, host) || strings.EqualFold(c.ServiceHost, host) ||
+		strings.EqualFold(shortName(c.Host), shortName(host))
+}
+
+// fits reports whether flavor fits into the free capacity
+func (c hostCapacity) fits(flavor flavors.Flavor) bool {
+	return c.FreeRAMMB >= flavor.RAM && c.FreeVCPUs >= flavor.VCPUs
+}
+
+// checkHostCapacity compares the flavor with the free capacity of the chosen host. When it does
+// not fit, it warns and suggests the least-loaded host of zone that does; with strict set it
+// returns an error instead of continuing.
+func checkHostCapacity(ctx context.Context, client *gophercloud.ServiceClient, zone, host string, flavor flavors.Flavor, strict bool, out io.Writer) error {
+	capacities, err := fetchHostCapacities(ctx, client, zone)
+	if err != nil {
+		return fmt.Errorf("check host capacity: %v", err)
+	}
+	var chosen *hostCapacity
+	for i := range capacities {
+		if capacities[i].is(host) {
+			chosen = &capacities[i]
+			break
+		}
+	}
+	if chosen == nil {
+		log.Debugf("Host %s not found among hypervisors of zone %q; skipping capacity check", host, zone)
+		return nil
+	}
+	if !chosen.Reported {
+		log.Warnf("Hypervisor %s does not report its vCPUs and memory; cannot check that flavor %s fits", chosen.Host, flavor.Name)
+		return nil
+	}
+	if chosen.fits(flavor) {
+		log.Debugf("Flavor %s fits on %s (%d MB RAM, %d vCPUs free)", flavor.Name, host, chosen.FreeRAMMB, chosen.FreeVCPUs)
+		return nil
+	}
+
+	fmt.Fprintf(out, "⚠️ Host %s has %d MB RAM and %d vCPUs free; flavor %s needs %d MB RAM and %d vCPUs.\n",
+		host, chosen.FreeRAMMB, chosen.FreeVCPUs, flavor.Name, flavor.RAM, flavor.VCPUs)
+	if best, ok := leastLoadedHost(capacities, flavor); ok {
+		fmt.Fprintf(out, "Suggested host: %s (%d MB RAM, %d vCPUs free)\n", best.Host, best.FreeRAMMB, best.FreeVCPUs)
+	} else {
+		fmt.Fprintf(out, "No host in availability zone '%s' has room for flavor %s.\n", zone, flavor.Name)
+	}
+	if strict {
+		return fmt.Errorf("flavor %s does not fit on host %s", flavor.Name, host)
+	}
+	return nil
+}
+
+// fetchHostCapacities returns the free capacity of the hypervisors in zone, or of all
+// hypervisors when zone is empty
+func fetchHostCapacities(ctx context.Context, client *gophercloud.ServiceClient, zone string) ([]hostCapacity, error) {
+	pages, err := hypervisors.List(client, nil).AllPages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list hypervisors: %v", err)
+	}
+	hosts, err := hypervisors.ExtractHypervisors(pages)
+	if err != nil {
+		return nil, fmt.Errorf("extract hypervisors: %v", err)
+	}
+
+	var zoneHosts availabilityzones.Hosts
+	if zone != "" {
+		zonePages, err := availabilityzones.ListDetail(client).AllPages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list availability zones: %v", err)
+		}
+		zones, err := availabilityzones.ExtractAvailabilityZones(zonePages)
+		if err != nil {
+			return nil, fmt.Errorf("extract availability zones: %v", err)
+		}
+		for _, z := range zones {
+			if z.ZoneName == zone {
+				zoneHosts = z.Hosts
+				break
+			}
+		}
+	}
+
+	var capacities []hostCapacity
+	for _, h := range hosts {
+		if zone != "" && !inZone(zoneHosts, h) {
+			continue
+		}
+		capacities = append(capacities, hostCapacity{
+			Host:        h.HypervisorHostname,
+			ServiceHost: h.Service.Host,
+			FreeRAMMB:   h.FreeRamMB,
+			FreeVCPUs:   h.VCPUs - h.VCPUsUsed,
+			Reported:    h.VCPUs > 0 || h.MemoryMB > 0,
+		})
+	}
+	return capacities, nil
+}
+
+// inZone reports whether the hypervisor's hostname or compute service host is one of zoneHosts
+func inZone(zoneHosts availabilityzones.Hosts, h hypervisors.Hypervisor) bool {
+	return len(matchingZoneHosts(zoneHosts, h)) > 0
+}
+
+// matchingZoneHosts returns the hosts of zoneHosts that name the hypervisor by its hostname or
+// compute service host. Names are also compared without their domain, as the hypervisor
+// hostname is often the FQDN of a compute host that zones list by its short name.
+func matchingZoneHosts(zoneHosts availabilityzones.Hosts, h hypervisors.Hypervisor) []string {
+	var matches []string
+	for zh := range zoneHosts {
+		for _, name := range []string{h.HypervisorHostname, h.Service.Host} {
+			if name != "" && (strings.EqualFold(zh, name) || strings.EqualFold(shortName(zh), shortName(name))) {
+				matches = append(matches, zh)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// shortName returns host without its domain
+func shortName(host string) string {
+	short, _, _ := strings.Cut(host, ".")
+	return short
+}
+
+// leastLoadedHost returns the host with the most free RAM among those the flavor fits on
+func leastLoadedHost(capacities []hostCapacity, flavor flavors.Flavor) (hostCapacity, bool) {
+	var best hostCapacity
+	found := false
+	for _, c := range capacities {
+		if !c.Reported || !c.fits(flavor) {
+			continue
+		}
+		if !found || c.FreeRAMMB > best.FreeRAMMB || (c.FreeRAMMB == best.FreeRAMMB && c.FreeVCPUs > best.FreeVCPUs) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/availabilityzones"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+)
+
+func TestMenuHosts(t *testing.T) {
+	hypervisor := func(hostname, serviceHost string) hypervisors.Hypervisor {
+		h := hypervisors.Hypervisor{HypervisorHostname: hostname}
+		h.Service.Host = serviceHost
+		return h
+	}
+	hosts := []hypervisors.Hypervisor{
+		hypervisor("compute-2.example.com", "compute-2"),
+		hypervisor("compute-1.example.com", ""),
+		hypervisor("compute-3.example.com", "compute-3"),
+	}
+	zoneHosts := availabilityzones.Hosts{"compute-1": nil, "compute-2": nil}
+	tests := []struct {
+		name      string
+		zone      string
+		zoneHosts availabilityzones.Hosts
+		want      []string
+	}{
+		{name: "no zone", want: []string{"compute-1.example.com", "compute-2", "compute-3"}},
+		{name: "zone lists short names", zone: "az1", zoneHosts: zoneHosts, want: []string{"compute-1", "compute-2"}},
+		{name: "unknown zone", zone: "az2", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := menuHosts(hosts, tt.zone, tt.zoneHosts); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,27 +12,63 @@ var log = logrus.New()
 
 // Config holds configuration parameters for VM operations
 type Config struct {
-	Verbose        bool
-	FilterStr      string // For info subcommand
-	OutputFormat   string
-	UseFlavorCache bool // For info subcommand
-	MaxRetries     int  // For info subcommand
-	MaxConcurrency int  // For info subcommand
-	Timeout        time.Duration
-	VM             string // For manage subcommand
-	Project        string // For manage subcommand
-	DryRun         bool   // For manage subcommand
-	State          string // For set-state action in manage subcommand
+	Verbose            bool
+	FilterStr          string        // For info subcommand, and for manage to select VMs instead of VM
+	TimeZone           string        // For info subcommand; IANA zone for table timestamps, "" or "Local" for the local zone
+	Long               bool          // For info subcommand; add the availability zone column to table and CSV output
+	Deleted            bool          // For info subcommand; include deleted VMs (admin only) with a Deleted At column
+	ResolveFIPs        bool          // For info subcommand; add the floating IPs Neutron reports to the IP column
+	NoEmail            bool          // For info subcommand; only VMs whose owner has no email on file
+	OutputFile         string        // For info subcommand; also write the inventory as JSON to this file
+	DiffAgainst        string        // For info subcommand; print the changes since this earlier JSON inventory instead
+	Watch              bool          // For info subcommand; rerun every WatchInterval until interrupted
+	WatchInterval      time.Duration // For info subcommand with Watch
+	OutputFormat       string
+	UseFlavorCache     bool          // For info subcommand
+	ActionRetries      int           // For manage subcommand; retries per VM after a transient failure (5xx or timeout)
+	RetryDelay         time.Duration // For manage subcommand; delay before the first retry, growing with each further one
+	MaxConcurrency     int           // For info and manage subcommands; 1 makes manage process VMs in input order
+	Timeout            time.Duration
+	RequestTimeout     time.Duration // For create and select-project subcommands; per-request limit, 0 for none
+	VM                 string        // For manage subcommand
+	Project            string        // For manage subcommand
+	DryRun             bool          // For manage subcommand
+	State              string        // For set-state action in manage subcommand
+	Metadata           []string      // For set-metadata (key=value) and unset-metadata (key) actions in manage subcommand
+	ConsoleLines       int           // For console-log action in manage subcommand; 0 fetches the whole log
+	ConsoleType        string        // For console-url action in manage subcommand; novnc or serial
+	Yes                bool          // For manage subcommand; skip the confirmation before destructive actions
+	ProtectedFile      string        // For manage subcommand; VM deny-list, defaults to DefaultProtectedFile()
+	OverrideProtection bool          // For manage subcommand; act on protected VMs after a typed confirmation
+	ShowTiming         bool          // For manage subcommand; show start time and duration of each action in table and CSV output
+	ShowRequestID      bool          // For manage subcommand; show the Nova request ID of each action in table and CSV output
+	FailFast           bool          // For manage subcommand; stop at the first VM that fails and return its error
+	Wait               bool          // For manage subcommand; poll each VM until it reaches the status of the action
+	WaitTimeout        time.Duration // For manage subcommand with Wait; per-VM limit, 0 for only Timeout
+	Force              bool          // For set-state action in manage subcommand; reset VMs that have a task in progress
+	Insecure           bool          // For create and select-project subcommands; skip TLS verification for OpenStack endpoints
+	Interface          string        // For create and select-project subcommands; public, internal or admin endpoints
+	Strict             bool          // For create subcommand; stop when the flavor does not fit on the chosen host
+	ComputeHost        string        // For create subcommand; place the VM on this host instead of prompting for zone and host
+	VerifySSH          bool          // For create subcommand; check that port 22 of the new VM accepts connections
+	VerifyTimeout      time.Duration // For create subcommand with VerifySSH; how long to keep retrying
+	Format             string        // For select-project subcommand; "text" or "openrc"
+	DiffOld            string        // For diff subcommand; earlier vm info JSON file
+	DiffNew            string        // For diff subcommand; later vm info JSON file
 }
 
 // filter holds filtering criteria for VMs
 type filter struct {
 	Host      string
+	AZ        string
 	Email     string
+	User      string
 	Status    string
 	Project   string
 	DaysOp    string
 	DaysValue int
+	FIP       string // Needs the floating IPs resolved from Neutron, so only vm info supports it
+	NoEmail   bool   // Set by vm info --no-email rather than a filter key
 }
 
 // FlavorDetails holds flavor information
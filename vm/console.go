@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/remoteconsoles"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// ReportFunc is a read-only manage action whose output becomes part of the VM's result
+type ReportFunc func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, result *Result) error
+
+// reportActions change nothing, so they run on dry runs too and need no confirmation
+var reportActions = map[string]ReportFunc{
+	"console-log": consoleLog,
+	"console-url": consoleURL,
+}
+
+// remoteConsoleMicroversion is the first compute microversion with the remote console API
+const remoteConsoleMicroversion = "2.6"
+
+// consoleProtocols maps the --console-type values to the remote console protocol
+var consoleProtocols = map[string]remoteconsoles.ConsoleProtocol{
+	"novnc":  remoteconsoles.ConsoleProtocolVNC,
+	"serial": remoteconsoles.ConsoleProtocolSerial,
+}
+
+// validateConsoleType rejects --console-type values other than novnc and serial
+func validateConsoleType(consoleType string) error {
+	if _, ok := consoleProtocols[consoleType]; !ok {
+		return fmt.Errorf("invalid console type '%s'; valid: novnc, serial", consoleType)
+	}
+	return nil
+}
+
+// consoleLog fetches the last cfg.ConsoleLines lines of the VM's console output, or all of it
+// when ConsoleLines is 0
+func consoleLog(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, result *Result) error {
+	log.Debugf("Fetching console output of VM: %s (ID: %s), lines: %d", vm.Name, vm.ID, cfg.ConsoleLines)
+	res := servers.ShowConsoleOutput(ctx, client.Compute, vm.ID, servers.ShowConsoleOutputOpts{
+		Length: cfg.ConsoleLines,
+	})
+	result.RequestID = requestID(res.Result)
+	consoleOutput, err := res.Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get console output of VM '%s' (ID: %s)", vm.Name, vm.ID)
+	}
+	result.ConsoleOutput = consoleOutput
+	lines := strings.Count(strings.TrimRight(consoleOutput, "\n"), "\n") + 1
+	if consoleOutput == "" {
+		lines = 0
+	}
+	result.Message = fmt.Sprintf("%d line(s) of console output", lines)
+	return nil
+}
+
+// consoleURL creates a remote console of cfg.ConsoleType for the VM and returns its URL
+func consoleURL(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, result *Result) error {
+	log.Debugf("Creating %s console for VM: %s (ID: %s)", cfg.ConsoleType, vm.Name, vm.ID)
+	compute := *client.Compute
+	compute.Microversion = remoteConsoleMicroversion
+	res := remoteconsoles.Create(ctx, &compute, vm.ID, remoteconsoles.CreateOpts{
+		Protocol: consoleProtocols[cfg.ConsoleType],
+		Type:     remoteconsoles.ConsoleType(cfg.ConsoleType),
+	})
+	result.RequestID = requestID(res.Result)
+	console, err := res.Extract()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s console for VM '%s' (ID: %s)", cfg.ConsoleType, vm.Name, vm.ID)
+	}
+	result.ConsoleURL = console.URL
+	result.Message = fmt.Sprintf("%s console: %s", cfg.ConsoleType, console.URL)
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,10 +21,20 @@ import (
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
 	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 // CreateVM handles the interactive creation of a new VM.
-func CreateVM(ctx context.Context) error {
+func CreateVM(ctx context.Context, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+	if cfg.OutputFormat == "" {
+		cfg.OutputFormat = "table"
+	}
+	// With structured output the menus and progress go to stderr like the logs, so that stdout
+	// holds only the result document
+	out := promptOutput(cfg)
+
 	// Check required environment variables
 	requiredEnvVars := []string{"OS_AUTH_URL", "OS_USERNAME", "OS_PASSWORD", "OS_REGION_NAME"}
 	for _, env := range requiredEnvVars {
@@ -30,89 +42,114 @@ func CreateVM(ctx context.Context) error {
 			return fmt.Errorf("missing required environment variable: %s", env)
 		}
 	}
-
-	// Auth from ENV
-	opts, err := openstack.AuthOptionsFromEnv()
-	if err != nil {
-		return fmt.Errorf("auth from env: %v", err)
-	}
-
-	// Unscoped auth to list projects
-	unauthProvider, err := openstack.NewClient(opts.IdentityEndpoint)
-	if err != nil {
-		return fmt.Errorf("unauth provider: %v", err)
-	}
-
-	err = openstack.Authenticate(ctx, unauthProvider, opts)
+	availability, err := auth.ParseInterface(cfg.Interface)
 	if err != nil {
-		return fmt.Errorf("unauth provider auth: %v", err)
+		return err
 	}
 
-	identityClient, err := openstack.NewIdentityV3(unauthProvider, gophercloud.EndpointOpts{})
+	opts, identityClient, err := envIdentityClient(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("identity v3: %v", err)
+		return err
 	}
 
-	projectID := selectProject(ctx, identityClient)
-	opts.TenantID = projectID
+	project := selectProject(ctx, identityClient, out)
+	opts.TenantID = project.ID
+	opts.Scope = nil // scope by the selected project ID rather than the env project
 
 	// Auth with selected project (scoped)
-	provider, err := openstack.AuthenticatedClient(ctx, opts)
+	provider, err := openstack.NewClient(opts.IdentityEndpoint)
 	if err != nil {
+		return fmt.Errorf("scoped provider: %v", err)
+	}
+	auth.ConfigureTLS(provider, cfg.Insecure)
+	auth.ConfigureRequestTimeout(provider, cfg.RequestTimeout)
+	if err := openstack.Authenticate(ctx, provider, opts); err != nil {
 		return fmt.Errorf("scoped auth: %v", err)
 	}
 
-	computeClient, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{})
+	endpointOpts := gophercloud.EndpointOpts{Availability: availability}
+	computeClient, err := openstack.NewComputeV2(provider, endpointOpts)
 	if err != nil {
 		return fmt.Errorf("compute client: %v", err)
 	}
 
-	imageClient, err := openstack.NewImageV2(provider, gophercloud.EndpointOpts{})
+	imageClient, err := openstack.NewImageV2(provider, endpointOpts)
 	if err != nil {
 		return fmt.Errorf("image client: %v", err)
 	}
 
-	networkClient, err := openstack.NewNetworkV2(provider, gophercloud.EndpointOpts{})
+	networkClient, err := openstack.NewNetworkV2(provider, endpointOpts)
 	if err != nil {
 		return fmt.Errorf("network client: %v", err)
 	}
 	// Interactive input
-	fmt.Println("==== OpenStack VM Creator ====")
-	name := prompt("Enter VM name: ")
+	fmt.Fprintln(out, "==== OpenStack VM Creator ====")
+	name := prompt(out, "Enter VM name: ")
 	if len(name) == 0 || len(name) > 255 {
 		return fmt.Errorf("invalid VM name: must be between 1 and 255 characters")
 	}
-	zone := selectAvailabilityZone(ctx, computeClient)
-	host := selectComputeHost(ctx, computeClient, zone)
-	fmt.Printf("Selected availability zone: %s, compute host: %s (host used for info only, zone applied to VM creation)\n", zone, host)
-	imageID := selectImage(ctx, imageClient)
-	flavorID := selectFlavor(ctx, computeClient)
-	networkID := selectNetwork(ctx, networkClient)
-	keypair := selectKeyPair(ctx, computeClient)
+	var zone, host string
+	if cfg.ComputeHost != "" {
+		host = cfg.ComputeHost
+		zone, err = hostZone(ctx, computeClient, host)
+		if err != nil {
+			return err
+		}
+	} else {
+		zone = selectAvailabilityZone(ctx, computeClient, out)
+		host = selectComputeHost(ctx, computeClient, zone, out)
+		// A host chosen without a zone is placed with the zone it belongs to
+		if zone == "" && host != "" {
+			zone, err = hostZone(ctx, computeClient, host)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Fprintf(out, "Selected availability zone: %s, compute host: %s\n", zone, host)
+	imageID := selectImage(ctx, imageClient, out)
+	flavor := selectFlavor(ctx, computeClient, out)
+	if host != "" {
+		if err := checkHostCapacity(ctx, computeClient, zone, host, flavor, cfg.Strict, out); err != nil {
+			return err
+		}
+	}
+	networkID := selectNetwork(ctx, networkClient, out)
+	keypair := selectKeyPair(ctx, computeClient, out)
 
-	// Create VM
+	// Create VM. Nova places the server on a given host with the zone:host form of the
+	// availability zone; only admins may use it.
+	availabilityZone := zone
+	if host != "" {
+		availabilityZone = zone + ":" + host
+	}
 	createOpts := servers.CreateOpts{
 		Name:             name,
 		ImageRef:         imageID,
-		FlavorRef:        flavorID,
+		FlavorRef:        flavor.ID,
 		Networks:         []servers.Network{{UUID: networkID}},
-		AvailabilityZone: zone,
+		AvailabilityZone: availabilityZone,
+	}
+	// Nova puts a server without requested security groups into the project's default group
+	securityGroups := createOpts.SecurityGroups
+	if len(securityGroups) == 0 {
+		securityGroups = []string{"default"}
 	}
 	// Add key pair
 	createOptsExt := keypairs.CreateOptsExt{
 		CreateOptsBuilder: createOpts,
 		KeyName:           keypair,
 	}
-	fmt.Println("Creating VM...")
+	fmt.Fprintln(out, "Creating VM...")
 	server, err := servers.Create(ctx, computeClient, createOptsExt, nil).Extract()
 	if err != nil {
 		return fmt.Errorf("create VM: %v", err)
 	}
 
-	fmt.Printf("✅ Created: %s (ID: %s)\n", server.Name, server.ID)
+	fmt.Fprintf(out, "✅ Created: %s (ID: %s)\n", server.Name, server.ID)
 
 	// Poll VM status
-	fmt.Println("Checking VM status...")
+	fmt.Fprintln(out, "Checking VM status...")
 	for i := 0; i < 30; i++ { // Timeout after ~60 seconds
 		server, err := servers.Get(ctx, computeClient, server.ID).Extract()
 		if err != nil {
@@ -121,36 +158,113 @@ func CreateVM(ctx context.Context) error {
 		if server.Status == "ACTIVE" || server.Status == "ERROR" {
 			break
 		}
-		fmt.Printf("Current status: %s,  waiting...\n", server.Status)
+		fmt.Fprintf(out, "Current status: %s,  waiting...\n", server.Status)
 		time.Sleep(10 * time.Second)
 	}
 	var ipAddress string
 	server, err = servers.Get(ctx, computeClient, server.ID).Extract()
-	addresses := server.Addresses
-	for _, network := range addresses {
-		networkList, ok := network.([]interface{})
-		if !ok {
-			continue
+	if err != nil {
+		return fmt.Errorf("get VM addresses: %v", err)
+	}
+	for _, addrMap := range serverAddresses(*server) {
+		if ip, ok := addrMap["addr"].(string); ok {
+			ipAddress = strings.Split(ip, "%")[0]
+			break
 		}
-		for _, addr := range networkList {
-			addrMap, ok := addr.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			if ip, ok := addrMap["addr"].(string); ok {
-				ip = strings.Split(ip, "%")[0]
-				ipAddress = ip
-				break
+	}
+
+	result := CreateResult{
+		Name:      server.Name,
+		ID:        server.ID,
+		Status:    server.Status,
+		IPAddress: ipAddress,
+		ProjectID: project.ID,
+		Verified:  verifyServer(server, keypair, securityGroups),
+	}
+	if cfg.VerifySSH {
+		switch {
+		case server.Status != "ACTIVE":
+			result.Verified.SSH = &SSHCheck{Error: fmt.Sprintf("not checked: VM status is %s", server.Status)}
+			log.Warnf("VM %s is %s, not checking SSH reachability", server.Name, server.Status)
+		case ipAddress == "":
+			result.Verified.SSH = &SSHCheck{Error: "not checked: VM has no address"}
+			log.Warnf("VM %s has no address, not checking SSH reachability", server.Name)
+		default:
+			fmt.Fprintf(out, "Checking SSH reachability of %s...\n", ipAddress)
+			result.Verified.SSH = checkSSH(ctx, ipAddress, cfg.VerifyTimeout, out)
+			if !result.Verified.SSH.Reachable {
+				log.Warnf("Port 22 of VM %s (%s) is not reachable: %s", server.Name, ipAddress, result.Verified.SSH.Error)
 			}
 		}
-		if ipAddress != "" {
-			break
-		}
 	}
-	fmt.Printf("IP ADDRESS IS: %s", ipAddress)
+	return printCreateResult(result, cfg.OutputFormat)
+}
+
+// SelectProject runs the interactive project selector on its own and prints the chosen
+// project. With cfg.Format "openrc" it prints export lines for eval; the menu then goes to
+// stderr so that stdout holds only the exports.
+func SelectProject(ctx context.Context, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+
+	if cfg.Format != "" && cfg.Format != "text" && cfg.Format != "openrc" {
+		return fmt.Errorf("invalid format '%s'; valid: text, openrc", cfg.Format)
+	}
+	_, identityClient, err := envIdentityClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Format != "openrc" {
+		project := selectProject(ctx, identityClient, os.Stdout)
+		fmt.Printf("%s (%s)\n", project.Name, project.ID)
+		return nil
+	}
+	project := selectProject(ctx, identityClient, os.Stderr)
+	fmt.Printf("export OS_PROJECT_ID=%s\n", shellQuote(project.ID))
+	fmt.Printf("export OS_PROJECT_NAME=%s\n", shellQuote(project.Name))
 	return nil
 }
 
+// envIdentityClient authenticates with the OS_* credentials and returns them with an identity
+// client for listing the projects the user can choose from
+func envIdentityClient(ctx context.Context, cfg Config) (gophercloud.AuthOptions, *gophercloud.ServiceClient, error) {
+	availability, err := auth.ParseInterface(cfg.Interface)
+	if err != nil {
+		return gophercloud.AuthOptions{}, nil, err
+	}
+	log.Debugf("Using %s endpoints", availability)
+
+	// Auth from ENV
+	opts, err := auth.AuthOptionsFromEnv()
+	if err != nil {
+		return opts, nil, fmt.Errorf("auth from env: %v", err)
+	}
+
+	// Unscoped auth to list projects
+	unauthProvider, err := openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return opts, nil, fmt.Errorf("unauth provider: %v", err)
+	}
+	auth.ConfigureTLS(unauthProvider, cfg.Insecure)
+	auth.ConfigureRequestTimeout(unauthProvider, cfg.RequestTimeout)
+
+	err = openstack.Authenticate(ctx, unauthProvider, opts)
+	if err != nil {
+		return opts, nil, fmt.Errorf("unauth provider auth: %v", err)
+	}
+
+	identityClient, err := openstack.NewIdentityV3(unauthProvider, gophercloud.EndpointOpts{Availability: availability})
+	if err != nil {
+		return opts, nil, fmt.Errorf("identity v3: %v", err)
+	}
+	return opts, identityClient, nil
+}
+
+// shellQuote single-quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func checkErr(context string, err error) {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ %s: %v\n", context, err)
@@ -158,27 +272,30 @@ func checkErr(context string, err error) {
 	}
 }
 
-func prompt(msg string) string {
-	fmt.Print(msg)
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return strings.TrimSpace(scanner.Text())
+// stdin is shared by all interactive prompts so buffered input is not lost between them
+var stdin = bufio.NewScanner(os.Stdin)
+
+func prompt(out io.Writer, msg string) string {
+	fmt.Fprint(out, msg)
+	stdin.Scan()
+	return strings.TrimSpace(stdin.Text())
 }
 
-func toChoice(input string, max int) int {
+func toChoice(out io.Writer, input string, max int) int {
 	i, err := strconv.Atoi(input)
 	if err != nil || i == 0 {
-		fmt.Println("Skipped.")
+		fmt.Fprintln(out, "Skipped.")
 		return 0
 	}
 	if err != nil || i < 1 || i > max {
-		fmt.Println("Invalid choice.")
+		fmt.Fprintln(out, "Invalid choice.")
 		return -1
 	}
 	return i
 }
 
-func selectProject(ctx context.Context, identityClient *gophercloud.ServiceClient) string {
+// selectProject prompts for one of the user's projects, writing the menu to out
+func selectProject(ctx context.Context, identityClient *gophercloud.ServiceClient, out io.Writer) projects.Project {
 	pages, err := projects.List(identityClient, nil).AllPages(ctx)
 	if err != nil {
 		checkErr("list projects", err)
@@ -189,23 +306,17 @@ func selectProject(ctx context.Context, identityClient *gophercloud.ServiceClien
 		checkErr("extract projects", err)
 	}
 
+	labels := make([]string, len(allProjects))
 	for i, p := range allProjects {
-		fmt.Printf("%d) %s (%s)\n", i+1, p.Name, p.ID)
-	}
-	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose project: "), len(allProjects)+1)
-		if idx >= 0 {
-			fmt.Printf("You Chose: %s\n", allProjects[idx-1].Name)
-			return allProjects[idx-1].ID
-		}
-		fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
+		labels[i] = fmt.Sprintf("%s (%s)", p.Name, p.ID)
 	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	idx, err := choose(stdin, out, "Choose project: ", labels, false)
+	checkErr("choose project", err)
+	fmt.Fprintf(out, "You Chose: %s\n", allProjects[idx].Name)
+	return allProjects[idx]
 }
 
-func selectAvailabilityZone(ctx context.Context, client *gophercloud.ServiceClient) string {
+func selectAvailabilityZone(ctx context.Context, client *gophercloud.ServiceClient, out io.Writer) string {
 	zones, err := availabilityzones.ListDetail(client).AllPages(ctx)
 	if err != nil {
 		checkErr("list availability zones", err)
@@ -217,7 +328,7 @@ func selectAvailabilityZone(ctx context.Context, client *gophercloud.ServiceClie
 	}
 
 	if len(allZones) == 0 {
-		fmt.Println("⚠️ No availability zones found. Proceeding without a zone.")
+		fmt.Fprintln(out, "⚠️ No availability zones found. Proceeding without a zone.")
 		return ""
 	}
 
@@ -230,31 +341,31 @@ func selectAvailabilityZone(ctx context.Context, client *gophercloud.ServiceClie
 	}
 
 	if len(availableZones) == 0 {
-		fmt.Println("⚠️ No available availability zones found (excluding internal). Proceeding without a zone.")
+		fmt.Fprintln(out, "⚠️ No available availability zones found (excluding internal). Proceeding without a zone.")
 		return ""
 	}
 
 	for i, zone := range availableZones {
-		fmt.Printf("%d) %s\n", i+1, zone.ZoneName)
+		fmt.Fprintf(out, "%d) %s\n", i+1, zone.ZoneName)
 	}
 	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose availability zone (or enter 0 to skip): "), len(availableZones)+1)
+		idx := toChoice(out, prompt(out, "Choose availability zone (or enter 0 to skip): "), len(availableZones))
 		if idx == -1 {
-			fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
+			fmt.Fprintf(out, "Invalid choice. %d retries left.\n", 2-retries)
 			continue
 		}
 		if idx == 0 {
 			return "" // Skip zone
 		}
-		fmt.Printf("You Chose: %s\n", availableZones[idx-1].ZoneName)
+		fmt.Fprintf(out, "You Chose: %s\n", availableZones[idx-1].ZoneName)
 		return availableZones[idx-1].ZoneName
 	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
+	fmt.Fprintln(out, "❌ Too many invalid attempts. Exiting.")
 	os.Exit(1)
 	return ""
 }
 
-func selectImage(ctx context.Context, client *gophercloud.ServiceClient) string {
+func selectImage(ctx context.Context, client *gophercloud.ServiceClient, out io.Writer) string {
 	pages, err := images.List(client, images.ListOpts{Status: "active"}).AllPages(ctx)
 	if err != nil {
 		checkErr("list images", err)
@@ -265,23 +376,17 @@ func selectImage(ctx context.Context, client *gophercloud.ServiceClient) string
 		checkErr("extract images", err)
 	}
 
+	labels := make([]string, len(imgs))
 	for i, img := range imgs {
-		fmt.Printf("%d) %s\n", i+1, img.Name)
+		labels[i] = img.Name
 	}
-	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose image: "), len(imgs)+1)
-		if idx >= 0 {
-			fmt.Printf("You Chose: %s\n", imgs[idx-1].Name)
-			return imgs[idx-1].ID
-		}
-		fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
-	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	idx, err := choose(stdin, out, "Choose image: ", labels, false)
+	checkErr("choose image", err)
+	fmt.Fprintf(out, "You Chose: %s\n", imgs[idx].Name)
+	return imgs[idx].ID
 }
 
-func selectFlavor(ctx context.Context, client *gophercloud.ServiceClient) string {
+func selectFlavor(ctx context.Context, client *gophercloud.ServiceClient, out io.Writer) flavors.Flavor {
 	pages, err := flavors.ListDetail(client, nil).AllPages(ctx)
 	if err != nil {
 		checkErr("list flavors", err)
@@ -292,23 +397,17 @@ func selectFlavor(ctx context.Context, client *gophercloud.ServiceClient) string
 		checkErr("extract flavors", err)
 	}
 
+	labels := make([]string, len(allFlavors))
 	for i, fl := range allFlavors {
-		fmt.Printf("%d) %s (%d vCPU, %dMB RAM)\n", i+1, fl.Name, fl.VCPUs, fl.RAM)
+		labels[i] = fmt.Sprintf("%s (%d vCPU, %dMB RAM)", fl.Name, fl.VCPUs, fl.RAM)
 	}
-	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose flavor: "), len(allFlavors)+1)
-		if idx >= 0 {
-			fmt.Printf("You Chose: %s\n", allFlavors[idx-1].Name)
-			return allFlavors[idx-1].ID
-		}
-		fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
-	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	idx, err := choose(stdin, out, "Choose flavor: ", labels, false)
+	checkErr("choose flavor", err)
+	fmt.Fprintf(out, "You Chose: %s\n", allFlavors[idx].Name)
+	return allFlavors[idx]
 }
 
-func selectNetwork(ctx context.Context, client *gophercloud.ServiceClient) string {
+func selectNetwork(ctx context.Context, client *gophercloud.ServiceClient, out io.Writer) string {
 	pages, err := networks.List(client, nil).AllPages(ctx)
 	if err != nil {
 		checkErr("list networks", err)
@@ -319,23 +418,17 @@ func selectNetwork(ctx context.Context, client *gophercloud.ServiceClient) strin
 		checkErr("extract networks", err)
 	}
 
+	labels := make([]string, len(nets))
 	for i, net := range nets {
-		fmt.Printf("%d) %s (%s)\n", i+1, net.Name, net.ID)
+		labels[i] = fmt.Sprintf("%s (%s)", net.Name, net.ID)
 	}
-	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose network: "), len(nets)+1)
-		if idx >= 0 {
-			fmt.Printf("You Chose: %s\n", nets[idx-1].Name)
-			return nets[idx-1].ID
-		}
-		fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
-	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	idx, err := choose(stdin, out, "Choose network: ", labels, false)
+	checkErr("choose network", err)
+	fmt.Fprintf(out, "You Chose: %s\n", nets[idx].Name)
+	return nets[idx].ID
 }
 
-func selectComputeHost(ctx context.Context, client *gophercloud.ServiceClient, zone string) string {
+func selectComputeHost(ctx context.Context, client *gophercloud.ServiceClient, zone string, out io.Writer) string {
 	pages, err := hypervisors.List(client, nil).AllPages(ctx)
 	if err != nil {
 		checkErr("list hypervisors", err)
@@ -347,74 +440,105 @@ func selectComputeHost(ctx context.Context, client *gophercloud.ServiceClient, z
 	}
 
 	if len(hosts) == 0 {
-		fmt.Println("⚠️ No hypervisors found. Proceeding without a host selection.")
+		fmt.Fprintln(out, "⚠️ No hypervisors found. Proceeding without a host selection.")
 		return ""
 	}
 
+	var zoneHosts availabilityzones.Hosts
 	if zone != "" {
-		fmt.Printf("Please choose a compute host from the availability zone '%s'. Use 'openstack hypervisor list --long' to verify hosts in this zone.\n", zone)
+		fmt.Fprintf(out, "Please choose a compute host from the availability zone '%s'. Use 'openstack hypervisor list --long' to verify hosts in this zone.\n", zone)
+		zones, err := availabilityzones.ListDetail(client).AllPages(ctx)
+		if err != nil {
+			checkErr("list availability zones", err)
+		}
+		allZones, err := availabilityzones.ExtractAvailabilityZones(zones)
+		if err != nil {
+			checkErr("extract availability zones", err)
+		}
+		for _, z := range allZones {
+			if z.ZoneName == zone && z.ZoneState.Available {
+				zoneHosts = z.Hosts
+			}
+		}
 	} else {
-		fmt.Println("No availability zone selected. Choose any compute host.")
+		fmt.Fprintln(out, "No availability zone selected. Choose any compute host.")
 	}
 
-	zones, err := availabilityzones.ListDetail(client).AllPages(ctx)
-	if err != nil {
-		checkErr("list availability zones", err)
-	}
-
-	allZones, err := availabilityzones.ExtractAvailabilityZones(zones)
-	if err != nil {
-		checkErr("extract availability zones", err)
-	}
-
-	if len(allZones) == 0 {
-		fmt.Println("⚠️ No availability zones found. Proceeding without a zone.")
+	// The menu and the selection use the same list: the hypervisors of the chosen zone, or all
+	// of them without a zone
+	computeHosts := menuHosts(hosts, zone, zoneHosts)
+	log.Debugf("Zone %q has %d compute hosts", zone, len(computeHosts))
+	if len(computeHosts) == 0 {
+		fmt.Fprintf(out, "⚠️ No hypervisors found in availability zone '%s'. Proceeding without a host selection.\n", zone)
 		return ""
 	}
 
-	// Collect only available zones, excluding 'internal'
-	var availableZones []availabilityzones.AvailabilityZone
-	for _, zone1 := range allZones {
-		if zone1.ZoneState.Available && zone1.ZoneName != "internal" {
-			availableZones = append(availableZones, zone1)
-		}
+	for i, ch := range computeHosts {
+		fmt.Fprintf(out, "%d) %s\n", i+1, ch)
 	}
-
-	var zoneHosts []string
-	for _, zone1 := range availableZones {
-		if zone1.ZoneName == zone {
-			for zoneHost := range zone1.Hosts {
-				zoneHosts = append(zoneHosts, zoneHost)
-			}
+	for retries := 0; retries < 3; retries++ {
+		idx := toChoice(out, prompt(out, "Choose compute host (or enter 0 to skip): "), len(computeHosts))
+		if idx == -1 {
+			fmt.Fprintf(out, "Invalid choice. %d retries left.\n", 2-retries)
+			continue
 		}
+		if idx == 0 {
+			return "" // Skip host which will pick any host from the availability zone
+		}
+		fmt.Fprintf(out, "You Chose: %s\n", computeHosts[idx-1])
+		return computeHosts[idx-1]
 	}
+	fmt.Fprintln(out, "❌ Too many invalid attempts. Exiting.")
+	os.Exit(1)
+	return ""
+}
 
+// menuHosts returns the sorted compute hosts to choose from: the hosts of zoneHosts that have a
+// hypervisor, or the compute service hosts of all hypervisors when zone is empty. Both are the
+// names zone:host placement expects.
+func menuHosts(hosts []hypervisors.Hypervisor, zone string, zoneHosts availabilityzones.Hosts) []string {
+	seen := make(map[string]bool, len(hosts))
+	var computeHosts []string
 	for _, h := range hosts {
-		for j, zh := range zoneHosts {
-			if h.HypervisorHostname == zh {
-				fmt.Printf("%d) %s\n", j+1, h.HypervisorHostname)
+		names := []string{h.Service.Host}
+		if zone != "" {
+			names = matchingZoneHosts(zoneHosts, h)
+		} else if h.Service.Host == "" {
+			names = []string{h.HypervisorHostname}
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				computeHosts = append(computeHosts, name)
 			}
 		}
 	}
-	for retries := 0; retries < 3; retries++ {
-		fmt.Printf("zoneHosts number: %d\n", len(zoneHosts))
-		idx := toChoice(prompt("Choose compute host (or enter 0 to skip): "), len(zoneHosts)+1)
-		if idx == -1 {
-			fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
+	sort.Strings(computeHosts)
+	return computeHosts
+}
+
+// hostZone returns the available zone that compute host belongs to, for --compute-host
+func hostZone(ctx context.Context, client *gophercloud.ServiceClient, host string) (string, error) {
+	pages, err := availabilityzones.ListDetail(client).AllPages(ctx)
+	if err != nil {
+		return "", fmt.Errorf("list availability zones: %v", err)
+	}
+	allZones, err := availabilityzones.ExtractAvailabilityZones(pages)
+	if err != nil {
+		return "", fmt.Errorf("extract availability zones: %v", err)
+	}
+	for _, zone := range allZones {
+		if !zone.ZoneState.Available || zone.ZoneName == "internal" {
 			continue
 		}
-		if idx == 0 {
-			return "" // Skip host which will pick any host from the availability zone
+		if _, ok := zone.Hosts[host]; ok {
+			return zone.ZoneName, nil
 		}
-		fmt.Printf("You Chose: %s\n", hosts[idx-1].HypervisorHostname)
-		return hosts[idx-1].HypervisorHostname
 	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	return "", fmt.Errorf("compute host '%s' not found in any available availability zone", host)
 }
 
-func selectKeyPair(ctx context.Context, client *gophercloud.ServiceClient) string {
+func selectKeyPair(ctx context.Context, client *gophercloud.ServiceClient, out io.Writer) string {
 	pages, err := keypairs.List(client, nil).AllPages(ctx)
 	if err != nil {
 		checkErr("list keypairs", err)
@@ -426,26 +550,19 @@ func selectKeyPair(ctx context.Context, client *gophercloud.ServiceClient) strin
 	}
 
 	if len(allKeypairs) == 0 {
-		fmt.Println("⚠️ No key pairs found. Proceeding without a key pair.")
+		fmt.Fprintln(out, "⚠️ No key pairs found. Proceeding without a key pair.")
 		return ""
 	}
 
+	labels := make([]string, len(allKeypairs))
 	for i, kp := range allKeypairs {
-		fmt.Printf("%d) %s\n", i+1, kp.Name)
+		labels[i] = kp.Name
 	}
-	for retries := 0; retries < 3; retries++ {
-		idx := toChoice(prompt("Choose key pair (or enter 0 to skip): "), len(allKeypairs)+1)
-		if idx == -1 {
-			fmt.Printf("Invalid choice. %d retries left.\n", 2-retries)
-			continue
-		}
-		if idx == 0 {
-			return ""
-		}
-		fmt.Printf("You chose: %s\n", allKeypairs[idx-1].Name)
-		return allKeypairs[idx-1].Name
+	idx, err := choose(stdin, out, "Choose key pair (or enter 0 to skip): ", labels, true)
+	checkErr("choose key pair", err)
+	if idx == -1 {
+		return ""
 	}
-	fmt.Println("❌ Too many invalid attempts. Exiting.")
-	os.Exit(1)
-	return ""
+	fmt.Fprintf(out, "You chose: %s\n", allKeypairs[idx].Name)
+	return allKeypairs[idx].Name
 }
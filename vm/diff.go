@@ -0,0 +1,188 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// InventoryVM holds the keys of a vm info JSON entry that --diff-against compares. Decoding
+// only these keys lets files written by older or newer versions of the tool be compared.
+type InventoryVM struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Hypervisor  string `json:"hypervisor"`
+	FlavorID    string `json:"flavor_id"`
+	ProjectName string `json:"project_name"`
+}
+
+// FieldChange is one field of a VM that differs between two inventories
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ChangedVM is a VM present in both inventories with a different status, host, flavor or project
+type ChangedVM struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// InventoryDiff is the result of vm info --diff-against and vm diff
+type InventoryDiff struct {
+	Added   []InventoryVM `json:"added"`
+	Removed []InventoryVM `json:"removed"`
+	Changed []ChangedVM   `json:"changed"`
+}
+
+// saveInventory writes inventory to path as the JSON vm info prints, for a later --diff-against
+func saveInventory(path string, inventory interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create output file")
+	}
+	p, err := output.New("json", f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := p.Print(&output.Result{Data: inventory}); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write inventory to %s", path)
+	}
+	return f.Close()
+}
+
+// RunDiff compares two vm info JSON files offline and prints the VMs added, removed and changed
+// between cfg.DiffOld and cfg.DiffNew
+func RunDiff(cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+	if cfg.DiffOld == "" || cfg.DiffNew == "" {
+		return fmt.Errorf("both --old and --new are required")
+	}
+	previous, err := loadInventory(cfg.DiffOld)
+	if err != nil {
+		return err
+	}
+	current, err := loadInventory(cfg.DiffNew)
+	if err != nil {
+		return err
+	}
+	return printInventoryDiff(diffInventory(previous, current), cfg.OutputFormat, cfg.DiffOld)
+}
+
+// loadInventory reads the VMs of a vm info JSON file
+func loadInventory(path string) ([]InventoryVM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read inventory")
+	}
+	var inventory struct {
+		VMs []InventoryVM `json:"vms"`
+	}
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse inventory %s; expected vm info JSON output", path)
+	}
+	log.Debugf("Loaded %d VMs from %s", len(inventory.VMs), path)
+	return inventory.VMs, nil
+}
+
+// toInventory reduces vm info results to the keys the diff compares
+func toInventory(vms []Vmdetails) []InventoryVM {
+	inventory := make([]InventoryVM, 0, len(vms))
+	for _, vm := range vms {
+		inventory = append(inventory, InventoryVM{ID: vm.ID, Name: vm.Name, Status: vm.Status, Hypervisor: vm.Hypervisor, FlavorID: vm.FlavorID, ProjectName: vm.ProjectName})
+	}
+	return inventory
+}
+
+// inventoryKey returns how VMs are matched between two inventories: by ID, or by name when
+// either was written by a version of the tool that did not record VM IDs
+func inventoryKey(inventories ...[]InventoryVM) func(InventoryVM) string {
+	for _, inventory := range inventories {
+		for _, vm := range inventory {
+			if vm.ID == "" {
+				log.Warnf("Inventory without VM IDs; matching VMs by name, so renamed VMs show as removed and added")
+				return func(vm InventoryVM) string { return vm.Name }
+			}
+		}
+	}
+	return func(vm InventoryVM) string { return vm.ID }
+}
+
+// diffInventory compares the current VMs with the previous inventory
+func diffInventory(previous, current []InventoryVM) InventoryDiff {
+	key := inventoryKey(previous, current)
+	byKey := make(map[string]InventoryVM, len(previous))
+	for _, vm := range previous {
+		byKey[key(vm)] = vm
+	}
+	diff := InventoryDiff{Added: []InventoryVM{}, Removed: []InventoryVM{}, Changed: []ChangedVM{}}
+	seen := make(map[string]bool, len(current))
+	for _, now := range current {
+		seen[key(now)] = true
+		before, ok := byKey[key(now)]
+		if !ok {
+			diff.Added = append(diff.Added, now)
+			continue
+		}
+		var changes []FieldChange
+		for _, f := range []struct{ field, before, after string }{
+			{"status", before.Status, now.Status},
+			{"hypervisor", before.Hypervisor, now.Hypervisor},
+			{"flavor_id", before.FlavorID, now.FlavorID},
+			{"project_name", before.ProjectName, now.ProjectName},
+		} {
+			if f.before != f.after {
+				changes = append(changes, FieldChange{Field: f.field, Before: f.before, After: f.after})
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, ChangedVM{ID: now.ID, Name: now.Name, Changes: changes})
+		}
+	}
+	for _, vm := range previous {
+		if !seen[key(vm)] {
+			diff.Removed = append(diff.Removed, vm)
+		}
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Name < diff.Changed[j].Name })
+	return diff
+}
+
+// printInventoryDiff prints one row per added or removed VM and per changed field
+func printInventoryDiff(diff InventoryDiff, outputFormat, previousFile string) error {
+	out := &output.Result{
+		Headers: []string{"Change", "VM ID", "Name", "Field", "Before", "After"},
+		Data:    diff,
+		Empty:   fmt.Sprintf("No changes since %s.", previousFile),
+	}
+	for _, vm := range diff.Added {
+		out.AddRow("added", vm.ID, vm.Name, "", "", "")
+	}
+	for _, vm := range diff.Removed {
+		out.AddRow("removed", vm.ID, vm.Name, "", "", "")
+	}
+	for _, vm := range diff.Changed {
+		for _, c := range vm.Changes {
+			out.AddRow("changed", vm.ID, vm.Name, c.Field, c.Before, c.After)
+		}
+	}
+	if err := output.Print(outputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print inventory diff")
+	}
+	if outputFormat == "table" {
+		fmt.Printf("\nAdded: %d, Removed: %d, Changed: %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package vm
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// fetchFloatingIPs lists the floating IPs of all projects from Neutron and returns their
+// addresses by the ID of the instance their port belongs to. Unassociated floating IPs and
+// those on ports of anything other than a VM are left out.
+func fetchFloatingIPs(ctx context.Context, client *auth.Client) (map[string][]string, error) {
+	networkClient, err := auth.NewNetworkV2(client)
+	if err != nil {
+		return nil, err
+	}
+	fipPages, err := floatingips.List(networkClient, floatingips.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list floating IPs")
+	}
+	fips, err := floatingips.ExtractFloatingIPs(fipPages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract floating IPs")
+	}
+	byPort := make(map[string][]string)
+	for _, fip := range fips {
+		if fip.PortID != "" {
+			byPort[fip.PortID] = append(byPort[fip.PortID], fip.FloatingIP)
+		}
+	}
+	byInstance := make(map[string][]string)
+	if len(byPort) == 0 {
+		return byInstance, nil
+	}
+
+	// One listing of all ports maps the ports to instances, instead of a lookup per floating IP
+	portPages, err := ports.List(networkClient, ports.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list ports")
+	}
+	allPorts, err := ports.ExtractPorts(portPages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract ports")
+	}
+	for _, p := range allPorts {
+		addrs, ok := byPort[p.ID]
+		if !ok || p.DeviceID == "" || !strings.HasPrefix(p.DeviceOwner, "compute:") {
+			continue
+		}
+		byInstance[p.DeviceID] = append(byInstance[p.DeviceID], addrs...)
+	}
+	log.Debugf("Resolved %d floating IPs to %d VMs", len(fips), len(byInstance))
+	return byInstance, nil
+}
+
+// serverFloatingIPs returns the floating IPs of server: those Nova reports under its addresses
+// and those resolved from Neutron, without duplicates
+func serverFloatingIPs(server servers.Server, resolved map[string][]string) []string {
+	seen := make(map[string]bool)
+	var fips []string
+	add := func(ip string) {
+		if ip != "" && !seen[ip] {
+			seen[ip] = true
+			fips = append(fips, ip)
+		}
+	}
+	for _, addrMap := range serverAddresses(server) {
+		if ip, ok := addrMap["addr"].(string); ok && addrMap["OS-EXT-IPS:type"] == "floating" {
+			add(ip)
+		}
+	}
+	for _, ip := range resolved[server.ID] {
+		add(ip)
+	}
+	sort.Strings(fips)
+	return fips
+}
+
+// ipColumn is the Fixed IP column of a VM, followed by its floating IPs marked "(fip)"
+func ipColumn(vm Vmdetails) string {
+	ips := make([]string, 0, len(vm.FloatingIPs)+1)
+	if vm.FixedIP != "" {
+		ips = append(ips, vm.FixedIP)
+	}
+	for _, ip := range vm.FloatingIPs {
+		ips = append(ips, ip+" (fip)")
+	}
+	return strings.Join(ips, ", ")
+}
@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
-	"text/tabwriter"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
@@ -19,32 +19,60 @@ import (
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
-// Vmdetails holds the details of a VM for output
+// Vmdetails holds the details of a VM for output (JSON tags define the stable output schema)
 type Vmdetails struct {
-	Name            string
-	FlavorID        string
-	Hypervisor      string
-	Email           string
-	ProjectName     string
-	Created         time.Time
-	Age             string
-	FixedIP         string
-	Status          string
-	FlavorVCPUs     int
-	FlavorMemory    int
-	FlavorProcUnits float64
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	FlavorID         string     `json:"flavor_id"`
+	Hypervisor       string     `json:"hypervisor"`
+	AvailabilityZone string     `json:"availability_zone"`
+	Email            string     `json:"email"`
+	UserName         string     `json:"user_name"`
+	ProjectName      string     `json:"project_name"`
+	Created          time.Time  `json:"created"`              // UTC
+	Updated          time.Time  `json:"updated"`              // UTC
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"` // UTC, only with --deleted
+	Age              string     `json:"age"`
+	FixedIP          string     `json:"fixed_ip"`
+	FloatingIPs      []string   `json:"floating_ips,omitempty"` // Only with --resolve-fips or a fip filter
+	Status           string     `json:"status"`
+	FlavorVCPUs      int        `json:"flavor_vcpus"`
+	FlavorMemory     int        `json:"flavor_memory_mb"`
+	FlavorProcUnits  float64    `json:"flavor_proc_units"`
+}
+
+// ResourceTotals is the summed flavor footprint of the VMs vm info matched
+type ResourceTotals struct {
+	VCPUs     int     `json:"vcpus"`
+	MemoryMB  int     `json:"memory_mb"`
+	MemoryGiB float64 `json:"memory_gib"`
+	ProcUnits float64 `json:"proc_units"`
+}
+
+// sumResources adds up the flavor resources of vms
+func sumResources(vms []Vmdetails) ResourceTotals {
+	var t ResourceTotals
+	for _, vm := range vms {
+		t.VCPUs += vm.FlavorVCPUs
+		t.MemoryMB += vm.FlavorMemory
+		t.ProcUnits += vm.FlavorProcUnits
+	}
+	t.MemoryGiB = float64(t.MemoryMB) / 1024
+	return t
 }
 
 // Run executes the VM info or manage logic based on the action
 func Run(ctx context.Context, client *auth.Client, action string, cfg Config) error {
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	if cfg.Verbose {
-		log.SetLevel(logrus.DebugLevel)
+	util.SetupLogger(log, cfg.Verbose)
+
+	// A watch runs until interrupted, with the timeout applied to each run instead
+	if action == "info" && cfg.Watch {
+		return watchInfo(ctx, client, cfg)
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
@@ -61,6 +89,24 @@ func Run(ctx context.Context, client *auth.Client, action string, cfg Config) er
 func runInfo(ctx context.Context, client *auth.Client, cfg Config) error {
 	log.Debugf("Starting VM info with config: %+v", cfg)
 
+	// Tables show times in the requested zone; JSON, CSV and YAML always use UTC
+	loc, err := loadTimeZone(cfg.TimeZone)
+	if err != nil {
+		return err
+	}
+	if cfg.OutputFormat != "table" {
+		loc = time.UTC
+	}
+
+	// Read the previous inventory first so a bad file fails before the fleet is fetched
+	var previous []InventoryVM
+	if cfg.DiffAgainst != "" {
+		previous, err = loadInventory(cfg.DiffAgainst)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Initialize flavor cache
 	fm := &flavorMap{data: make(map[string]FlavorDetails)}
 	if cfg.UseFlavorCache {
@@ -95,94 +141,185 @@ func runInfo(ctx context.Context, client *auth.Client, cfg Config) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to parse filter")
 	}
+	f.NoEmail = cfg.NoEmail
+
+	// Floating IPs associated through Neutron do not always show in the addresses Nova
+	// reports, so they are listed once for all VMs
+	var fips map[string][]string
+	if cfg.ResolveFIPs || f.FIP != "" {
+		fips, err = fetchFloatingIPs(ctx, client)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve floating IPs")
+		}
+	}
 
 	// List VMs
-	var results []Vmdetails
+	results := []Vmdetails{}
 	var totalVMs uint32
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, cfg.MaxConcurrency)
 	var mu sync.Mutex
 
-	err = servers.List(client.Compute, servers.ListOpts{AllTenants: true}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-		serverList, err := servers.ExtractServers(page)
-		if err != nil {
-			return false, errors.Wrap(err, "failed to extract servers")
-		}
-
-		atomic.AddUint32(&totalVMs, uint32(len(serverList)))
+	// Nova returns only deleted servers for deleted=true, so --deleted lists them in a second
+	// query; a server in both, as when Nova ignores the filter for non-admins, is listed once
+	queries := []servers.ListOptsBuilder{servers.ListOpts{AllTenants: true}}
+	if cfg.Deleted {
+		queries = append(queries, deletedListOpts{servers.ListOpts{AllTenants: true}})
+	}
+	seen := make(map[string]bool)
+	for _, listOpts := range queries {
+		err = servers.List(client.Compute, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+			serverList, err := servers.ExtractServers(page)
+			if err != nil {
+				return false, errors.Wrap(err, "failed to extract servers")
+			}
 
-		for _, server := range serverList {
-			wg.Add(1)
-			go func(s servers.Server) {
-				defer wg.Done()
-				sem <- struct{}{}
-				defer func() { <-sem }()
-				for i := 0; i < cfg.MaxRetries; i++ {
-					pairs, err := processServer(ctx, s, users, projects, fm, f)
+			for _, server := range serverList {
+				if seen[server.ID] {
+					continue
+				}
+				seen[server.ID] = true
+				atomic.AddUint32(&totalVMs, 1)
+				wg.Add(1)
+				go func(s servers.Server) {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					// Read requests are already retried by the client (--max-retries), so a server
+					// that still fails is reported and left out
+					pairs, err := processServer(ctx, s, users, projects, fm, fips, f)
 					if err != nil {
-						log.Warnf("Error processing server %s: %v, attempt %d/%d", s.ID, err, i+1, cfg.MaxRetries)
-						time.Sleep(time.Second * time.Duration(i+1))
-						continue
+						log.Warnf("Error processing server %s: %v", s.ID, err)
+						return
 					}
 					if pairs != nil {
 						vm := Vmdetails{
-							Name:            s.Name,
-							FlavorID:        s.Flavor["id"].(string),
-							Hypervisor:      s.Host,
-							Email:           pairs[6].Value,
-							ProjectName:     pairs[7].Value,
-							Created:         s.Created,
-							Age:             pairs[9].Value,
-							FixedIP:         pairs[10].Value,
-							Status:          s.Status,
-							FlavorVCPUs:     atoi(pairs[2].Value),
-							FlavorMemory:    atoi(pairs[3].Value),
-							FlavorProcUnits: atof(pairs[4].Value),
+							ID:               s.ID,
+							Name:             s.Name,
+							FlavorID:         pairs[1].Value,
+							Hypervisor:       s.Host,
+							AvailabilityZone: s.AvailabilityZone,
+							Email:            pairs[6].Value,
+							UserName:         pairs[12].Value,
+							ProjectName:      pairs[7].Value,
+							Created:          s.Created.UTC(),
+							Updated:          s.Updated.UTC(),
+							Age:              pairs[9].Value,
+							FixedIP:          pairs[10].Value,
+							Status:           s.Status,
+							FlavorVCPUs:      atoi(pairs[2].Value),
+							FlavorMemory:     atoi(pairs[3].Value),
+							FlavorProcUnits:  atof(pairs[4].Value),
+						}
+						if fips != nil {
+							vm.FloatingIPs = serverFloatingIPs(s, fips)
+						}
+						if cfg.Deleted && !s.TerminatedAt.IsZero() {
+							deletedAt := s.TerminatedAt.UTC()
+							vm.DeletedAt = &deletedAt
 						}
 						mu.Lock()
 						results = append(results, vm)
 						mu.Unlock()
 					}
-					break
-				}
-			}(server)
+				}(server)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list servers")
 		}
-		return true, nil
-	})
-	if err != nil {
-		return errors.Wrap(err, "failed to list servers")
 	}
 	wg.Wait()
 
-	if cfg.OutputFormat == "json" {
-		output := struct {
-			VMs      []Vmdetails `json:"vms"`
-			TotalVMs uint32      `json:"total_vms"`
-		}{
-			VMs:      results,
-			TotalVMs: atomic.LoadUint32(&totalVMs),
+	total := atomic.LoadUint32(&totalVMs)
+	totals := sumResources(results)
+	inventory := struct {
+		VMs      []Vmdetails    `json:"vms"`
+		TotalVMs uint32         `json:"total_vms"`
+		Totals   ResourceTotals `json:"totals"`
+	}{
+		VMs:      results,
+		TotalVMs: total,
+		Totals:   totals,
+	}
+	if cfg.OutputFile != "" {
+		if err := saveInventory(cfg.OutputFile, inventory); err != nil {
+			return err
 		}
-		data, err := json.MarshalIndent(output, "", "  ")
-		if err != nil {
-			return errors.Wrap(err, "failed to marshal JSON")
+		log.Debugf("Saved inventory of %d VMs to %s", len(results), cfg.OutputFile)
+	}
+	if cfg.DiffAgainst != "" {
+		return printInventoryDiff(diffInventory(previous, toInventory(results)), cfg.OutputFormat, cfg.DiffAgainst)
+	}
+
+	out := &output.Result{
+		Headers: []string{"Name", "Flavor VCPUs", "Flavor Memory", "Flavor ProcUnits", "Hypervisor", "User Name", "Email", "Project", "Created", "Updated", "Age", "Fixed IP", "Status"},
+		Data:    inventory,
+		Empty:   "No VMs found.",
+	}
+	if cfg.Long {
+		out.Headers = append(out.Headers, "Availability Zone")
+	}
+	if cfg.Deleted {
+		out.Headers = append(out.Headers, "Deleted At")
+	}
+	for _, vm := range results {
+		row := []interface{}{vm.Name, vm.FlavorVCPUs, vm.FlavorMemory, fmt.Sprintf("%.2f", vm.FlavorProcUnits),
+			vm.Hypervisor, vm.UserName, vm.Email, vm.ProjectName, vm.Created.In(loc).Format(time.RFC3339),
+			vm.Updated.In(loc).Format(time.RFC3339), vm.Age, ipColumn(vm), vm.Status}
+		if cfg.Long {
+			row = append(row, vm.AvailabilityZone)
 		}
-		fmt.Println(string(data))
-	} else {
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Name\tFlavor VCPUs\tFlavor Memory\tFlavor ProcUnits\tHypervisor\tEmail\tProject\tCreated\tAge\tFixed IP\tStatus")
-		for _, vm := range results {
-			fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				vm.Name, vm.FlavorVCPUs, vm.FlavorMemory, vm.FlavorProcUnits,
-				vm.Hypervisor, vm.Email, vm.ProjectName, vm.Created.Format(time.RFC3339),
-				vm.Age, vm.FixedIP, vm.Status)
+		if cfg.Deleted {
+			deletedAt := ""
+			if vm.DeletedAt != nil {
+				deletedAt = vm.DeletedAt.In(loc).Format(time.RFC3339)
+			}
+			row = append(row, deletedAt)
 		}
-		w.Flush()
-		fmt.Printf("\nTotal VMs: %d\n", atomic.LoadUint32(&totalVMs))
+		out.AddRow(row...)
+	}
+	if err := output.Print(cfg.OutputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print VM details")
+	}
+	if cfg.OutputFormat == "table" {
+		fmt.Printf("\nTotal VMs: %d\n", total)
+		fmt.Printf("Matched VMs: %d (%d vCPUs, %.2f GiB RAM, %.2f proc units)\n", len(results), totals.VCPUs, totals.MemoryGiB, totals.ProcUnits)
 	}
 
 	return nil
 }
 
+// deletedListOpts lists deleted servers. gophercloud's ListOpts has no deleted parameter, and
+// Nova only honours it for admins.
+type deletedListOpts struct {
+	servers.ListOpts
+}
+
+func (opts deletedListOpts) ToServerListQuery() (string, error) {
+	q, err := opts.ListOpts.ToServerListQuery()
+	if err != nil {
+		return "", err
+	}
+	if q == "" {
+		return "?deleted=true", nil
+	}
+	return q + "&deleted=true", nil
+}
+
+// loadTimeZone resolves a --time-zone value, treating "" like "Local"
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone '%s': use an IANA name such as 'UTC' or 'Europe/Berlin', or 'Local'", name)
+	}
+	return loc, nil
+}
+
 func atoi(s string) int {
 	n, _ := strconv.Atoi(s)
 	return n
@@ -200,56 +337,109 @@ func parseFilter(filterStr string) (*filter, error) {
 	}
 	pairs := strings.Split(filterStr, ",")
 	for _, pair := range pairs {
-		kv := strings.SplitN(pair, "=", 2)
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("invalid filter format: %s", pair)
-		}
-		key := strings.TrimSpace(kv[0])
-		value := strings.TrimSpace(kv[1])
-		switch key {
-		case "host":
-			f.Host = value
-		case "email":
-			f.Email = value
-		case "status":
-			f.Status = value
-		case "project":
-			f.Project = value
-		case "days":
-			if strings.HasPrefix(value, ">") {
-				f.DaysOp = ">"
-				value = strings.TrimPrefix(value, ">")
-			} else if strings.HasPrefix(value, "<") {
-				f.DaysOp = "<"
-				value = strings.TrimPrefix(value, "<")
-			} else {
-				return nil, fmt.Errorf("invalid days filter operator: %s", value)
+		if path, ok := strings.CutPrefix(strings.TrimSpace(pair), "@"); ok {
+			if err := parseFilterFile(f, path); err != nil {
+				return nil, err
 			}
-			days, err := strconv.Atoi(value)
-			if err != nil {
-				return nil, fmt.Errorf("invalid days value: %s", value)
-			}
-			f.DaysValue = days
-		default:
-			return nil, fmt.Errorf("unknown filter key: %s", key)
+			continue
+		}
+		if err := f.set(pair); err != nil {
+			return nil, err
 		}
 	}
 	return f, nil
 }
 
+// parseFilterFile adds the conditions of an @file filter to f. The file has one condition per
+// line in the --filter syntax; blank lines and lines starting with # are skipped.
+func parseFilterFile(f *filter, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read filter file: %v", err)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, pair := range strings.Split(line, ",") {
+			if err := f.set(pair); err != nil {
+				return fmt.Errorf("%s:%d: %v", path, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// set applies one key=value condition to f, replacing an earlier condition on the same key
+func (f *filter) set(pair string) error {
+	kv := strings.SplitN(pair, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("invalid filter format: %s", pair)
+	}
+	key := strings.TrimSpace(kv[0])
+	value := strings.TrimSpace(kv[1])
+	switch key {
+	case "host":
+		f.Host = value
+	case "az":
+		f.AZ = value
+	case "email":
+		f.Email = value
+	case "user":
+		f.User = value
+	case "status":
+		f.Status = value
+	case "project":
+		f.Project = value
+	case "fip":
+		f.FIP = value
+	case "days":
+		if strings.HasPrefix(value, ">") {
+			f.DaysOp = ">"
+			value = strings.TrimPrefix(value, ">")
+		} else if strings.HasPrefix(value, "<") {
+			f.DaysOp = "<"
+			value = strings.TrimPrefix(value, "<")
+		} else {
+			return fmt.Errorf("invalid days filter operator: %s", value)
+		}
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid days value: %s", value)
+		}
+		f.DaysValue = days
+	default:
+		return fmt.Errorf("unknown filter key: %s", key)
+	}
+	return nil
+}
+
 func matchesFilter(vm Vmdetails, f *filter) bool {
 	if f.Host != "" && !strings.EqualFold(vm.Hypervisor, f.Host) {
 		return false
 	}
+	if f.AZ != "" && !strings.EqualFold(vm.AvailabilityZone, f.AZ) {
+		return false
+	}
 	if f.Email != "" && !strings.Contains(strings.ToLower(vm.Email), strings.ToLower(f.Email)) {
 		return false
 	}
+	if f.NoEmail && strings.TrimSpace(vm.Email) != "" {
+		return false
+	}
+	if f.User != "" && !strings.Contains(strings.ToLower(vm.UserName), strings.ToLower(f.User)) {
+		return false
+	}
 	if f.Status != "" && !strings.EqualFold(vm.Status, f.Status) {
 		return false
 	}
 	if f.Project != "" && !strings.EqualFold(vm.ProjectName, f.Project) {
 		return false
 	}
+	if f.FIP != "" && !slices.Contains(vm.FloatingIPs, f.FIP) {
+		return false
+	}
 	if f.DaysOp != "" {
 		daysSince := int(time.Since(vm.Created).Hours() / 24)
 		if f.DaysOp == ">" && daysSince <= f.DaysValue {
@@ -426,15 +616,18 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
-func processData(server servers.Server, users []users.User, projects []projects.Project, flavors *flavorMap) (Vmdetails, UserDetails, ProjectDetails, error) {
+func processData(server servers.Server, users []users.User, projects []projects.Project, flavors *flavorMap, fips map[string][]string) (Vmdetails, UserDetails, ProjectDetails, error) {
 	var vm Vmdetails
 	var user UserDetails
 	var project ProjectDetails
 
+	vm.ID = server.ID
 	vm.Name = server.Name
-	vm.FlavorID = server.Flavor["id"].(string)
+	vm.FlavorID = serverFlavorID(server)
 	vm.Hypervisor = server.Host
-	vm.Created = server.Created
+	vm.AvailabilityZone = server.AvailabilityZone
+	vm.Created = server.Created.UTC()
+	vm.Updated = server.Updated.UTC()
 	vm.Age = formatDuration(time.Now().Sub(server.Created))
 	vm.Status = server.Status
 
@@ -445,39 +638,23 @@ func processData(server servers.Server, users []users.User, projects []projects.
 		vm.FlavorVCPUs = flavor.Vcpus
 		vm.FlavorMemory = flavor.Memory
 		vm.FlavorProcUnits = flavor.ProcUnits
-	} else {
+	} else if vm.FlavorID != "" {
 		log.Warnf("Flavor %s not found for server %s", vm.FlavorID, server.ID)
 	}
 
-	for _, network := range server.Addresses {
-		for _, addr := range network.([]interface{}) {
-			addrMap := addr.(map[string]interface{})
-			if addrMap["OS-EXT-IPS:type"] == "fixed" {
-				vm.FixedIP = addrMap["addr"].(string)
-			}
+	for _, addrMap := range serverAddresses(server) {
+		if ip, ok := addrMap["addr"].(string); ok && addrMap["OS-EXT-IPS:type"] == "fixed" {
+			vm.FixedIP = ip
 		}
 	}
-
-	for _, u := range users {
-		if u.ID == server.UserID {
-			user = UserDetails{
-				ID:   u.ID,
-				Name: u.Name,
-			}
-			if email, ok := u.Extra["email"].(string); ok && email != "" {
-				user.Email = email
-			} else {
-				user.Email = extractEmailFromDescription(u.Description)
-				if user.Email == "" {
-					log.Warnf("No email found for user %s (ID: %s); Extra: %v, Description: %q; using empty string",
-						u.Name, u.ID, u.Extra, u.Description)
-				}
-			}
-			vm.Email = user.Email
-			break
-		}
+	if fips != nil {
+		vm.FloatingIPs = serverFloatingIPs(server, fips)
 	}
 
+	user = resolveOwner(server, users)
+	vm.Email = user.Email
+	vm.UserName = user.Name
+
 	for _, p := range projects {
 		if p.ID == server.TenantID {
 			project = ProjectDetails{
@@ -492,8 +669,66 @@ func processData(server servers.Server, users []users.User, projects []projects.
 	return vm, user, project, nil
 }
 
-func processServer(ctx context.Context, server servers.Server, users []users.User, projects []projects.Project, flavors *flavorMap, f *filter) ([]Pair, error) {
-	vm, user, project, err := processData(server, users, projects, flavors)
+// serverAddresses returns the address entries of all networks of server. Entries of another
+// shape than Nova's list of objects are skipped with a warning rather than panicking.
+func serverAddresses(server servers.Server) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for network, value := range server.Addresses {
+		addrs, ok := value.([]interface{})
+		if !ok {
+			log.Warnf("Unexpected addresses of network %s on server %s: %v; skipping", network, server.ID, value)
+			continue
+		}
+		for _, addr := range addrs {
+			addrMap, ok := addr.(map[string]interface{})
+			if !ok {
+				log.Warnf("Unexpected address on network %s of server %s: %v; skipping", network, server.ID, addr)
+				continue
+			}
+			entries = append(entries, addrMap)
+		}
+	}
+	return entries
+}
+
+// serverFlavorID returns the flavor ID of server, or "" with a warning when Nova did not return
+// one. From microversion 2.47 the flavor is embedded without an id, and a server being rebuilt
+// may have no flavor at all; neither should abort the listing.
+func serverFlavorID(server servers.Server) string {
+	id, ok := server.Flavor["id"].(string)
+	if !ok {
+		log.Warnf("No flavor ID for server %s (flavor: %v); flavor details will be empty", server.ID, server.Flavor)
+		return ""
+	}
+	return id
+}
+
+// resolveOwner returns the user who created server with the email used for notifications, taken
+// from the user's email attribute or else from an address in its description. The name falls
+// back to the user ID when the user is not in the identity listing so the owner stays traceable.
+func resolveOwner(server servers.Server, users []users.User) UserDetails {
+	owner := UserDetails{ID: server.UserID, Name: server.UserID}
+	for _, u := range users {
+		if u.ID != server.UserID {
+			continue
+		}
+		owner.Name = u.Name
+		if email, ok := u.Extra["email"].(string); ok && email != "" {
+			owner.Email = email
+		} else {
+			owner.Email = extractEmailFromDescription(u.Description)
+			if owner.Email == "" {
+				log.Warnf("No email found for user %s (ID: %s); Extra: %v, Description: %q; using empty string",
+					u.Name, u.ID, u.Extra, u.Description)
+			}
+		}
+		break
+	}
+	return owner
+}
+
+func processServer(ctx context.Context, server servers.Server, users []users.User, projects []projects.Project, flavors *flavorMap, fips map[string][]string, f *filter) ([]Pair, error) {
+	vm, user, project, err := processData(server, users, projects, flavors, fips)
 	if err != nil {
 		return nil, err
 	}
@@ -515,6 +750,7 @@ func processServer(ctx context.Context, server servers.Server, users []users.Use
 		{Key: "Age", Value: vm.Age},
 		{Key: "Fixed IP", Value: vm.FixedIP},
 		{Key: "Status", Value: vm.Status},
+		{Key: "User Name", Value: vm.UserName},
 	}
 	return pairs, nil
 }
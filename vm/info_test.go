@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+// writeJSON answers a fake API request with body
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+// newFakeEmptyCloud serves the Keystone and Nova listings of vm info for a cloud without
+// users, projects, flavors or servers
+func newFakeEmptyCloud(t *testing.T) *auth.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/v3/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"users": [], "links": {}}`)
+	})
+	mux.HandleFunc("/identity/v3/projects", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"projects": [], "links": {}}`)
+	})
+	mux.HandleFunc("/compute/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"flavors": []}`)
+	})
+	mux.HandleFunc("/compute/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"servers": []}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	return &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+		Compute:  &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/compute/"},
+	}
+}
+
+func TestRunInfoEmpty(t *testing.T) {
+	tests := []struct {
+		format     string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			format: "json",
+			wantStdout: "{\n  \"vms\": [],\n  \"total_vms\": 0,\n  \"totals\": {\n    \"vcpus\": 0,\n    \"memory_mb\": 0,\n" +
+				"    \"memory_gib\": 0,\n    \"proc_units\": 0\n  }\n}\n",
+		},
+		{
+			format:     "yaml",
+			wantStdout: "vms: []\ntotal_vms: 0\ntotals:\n  vcpus: 0\n  memory_mb: 0\n  memory_gib: 0\n  proc_units: 0\n",
+		},
+		{
+			format:     "table",
+			wantStdout: "\nTotal VMs: 0\nMatched VMs: 0 (0 vCPUs, 0.00 GiB RAM, 0.00 proc units)\n",
+			wantStderr: "No VMs found.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			client := newFakeEmptyCloud(t)
+			cfg := Config{OutputFormat: tt.format, MaxConcurrency: 1}
+			var err error
+			stdout, stderr := testutil.CaptureOutput(t, func() {
+				err = runInfo(context.Background(), client, cfg)
+			})
+			if err != nil {
+				t.Fatalf("runInfo: %v", err)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("got stdout %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunInfoCancelledContext(t *testing.T) {
+	client := newFakeEmptyCloud(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var err error
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		err = runInfo(ctx, client, Config{OutputFormat: "json", MaxConcurrency: 1})
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if stdout != "" {
+		t.Errorf("got stdout %q from a cancelled listing, want none", stdout)
+	}
+}
@@ -1,53 +1,86 @@
 package vm
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 	"github.com/pkg/errors"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 // Result holds the result of a VM operation
 type Result struct {
-	VMName  string `json:"vm_name"`
-	VMID    string `json:"vm_id"`
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	VMName   string `json:"vm_name"`
+	VMID     string `json:"vm_id"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	UserName string `json:"user_name,omitempty"` // Owner, set for VMs selected with --filter
+	Email    string `json:"email,omitempty"`     // Owner email, set for VMs selected with --filter
+	// Metadata the VM has after set-metadata or unset-metadata (what it would have on a dry run)
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Output of the console-log and console-url actions
+	ConsoleOutput string `json:"console_output,omitempty"`
+	ConsoleURL    string `json:"console_url,omitempty"`
+	// When the action was issued and Nova accepted or rejected it; zero when the action never ran
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Attempts   int       `json:"attempts"` // Calls made, more than 1 when transient failures were retried
+	// x-openstack-request-id of the last Nova request of the action, for escalations to the
+	// cloud provider; set for failed requests too when Nova answered
+	RequestID string `json:"request_id"`
+	// State of the VM before set-state, recorded so the results explain why it was reset
+	PriorState *ServerState `json:"prior_state,omitempty"`
+	// Status a dry run expects the VM to end in, e.g. SHUTOFF for stop or DELETED for delete
+	PlannedState string `json:"planned_state,omitempty"`
 }
 
-// ActionFunc defines the signature for action handler functions
-type ActionFunc func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error
+// ActionFunc defines the signature for action handler functions. Handlers record the Nova
+// request ID of their API calls in result.
+type ActionFunc func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error
+
+// manageTarget is a VM given on the command line and the server it resolved to
+type manageTarget struct {
+	input  string
+	vm     *servers.Server
+	err    error
+	result *Result
+	owner  UserDetails  // Set when the VM was selected with --filter
+	prior  *ServerState // Set for set-state before confirmation
+}
+
+// destructiveActions are confirmed once for all VMs before any of them is touched (skipped with --yes)
+var destructiveActions = map[string]bool{
+	"delete":       true,
+	"force-delete": true,
+	"set-state":    true,
+}
 
 // actionHandlers maps subcommands to their handler functions
 var actionHandlers = map[string]ActionFunc{
-	"delete": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"delete": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering delete handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping delete for VM: %s", vmName)
-			return nil
-		}
-		fmt.Printf("Type 'confirm' to delete VM '%s' (ID: %s): ", vmName, vm.ID)
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		response := strings.TrimSpace(scanner.Text())
-		log.Debugf("User response for delete confirmation: %s", response)
-		if strings.ToLower(response) != "confirm" {
-			log.Debugf("Delete aborted by user for VM: %s (ID: %s)", vmName, vm.ID)
-			return fmt.Errorf("delete aborted by user for VM '%s' (ID: %s)", vmName, vm.ID)
+			return planDryRun("delete", cfg, vm, result)
 		}
 		log.Debugf("Initiating delete API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Delete(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Delete(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Delete failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to delete VM '%s' (ID: %s)", vmName, vm.ID)
@@ -55,23 +88,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Delete successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"force-delete": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"force-delete": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering force-delete handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping force-delete for VM: %s", vmName)
-			return nil
-		}
-		fmt.Printf("Type 'confirm' to force delete VM '%s' (ID: %s): ", vmName, vm.ID)
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		response := strings.TrimSpace(scanner.Text())
-		log.Debugf("User response for force-delete confirmation: %s", response)
-		if strings.ToLower(response) != "confirm" {
-			log.Debugf("Force-delete aborted by user for VM: %s (ID: %s)", vmName, vm.ID)
-			return fmt.Errorf("force delete aborted by user for VM '%s' (ID: %s)", vmName, vm.ID)
+			return planDryRun("force-delete", cfg, vm, result)
 		}
 		log.Debugf("Initiating force-delete API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.ForceDelete(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.ForceDelete(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Force-delete failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to force delete VM '%s' (ID: %s)", vmName, vm.ID)
@@ -79,18 +105,20 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Force-delete successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"start": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"start": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering start handler for VM: %s (ID: %s)", vmName, vm.ID)
+		if cfg.DryRun {
+			log.Debugf("Dry-run enabled, skipping start for VM: %s", vmName)
+			return planDryRun("start", cfg, vm, result)
+		}
 		if strings.ToUpper(vm.Status) == "ACTIVE" {
 			log.Debugf("VM %s (ID: %s) already active, skipping start", vmName, vm.ID)
 			return fmt.Errorf("VM '%s' (ID: %s) is already active", vmName, vm.ID)
 		}
-		if cfg.DryRun {
-			log.Debugf("Dry-run enabled, skipping start for VM: %s", vmName)
-			return nil
-		}
 		log.Debugf("Initiating start API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Start(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Start(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Start failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to start VM '%s' (ID: %s)", vmName, vm.ID)
@@ -98,18 +126,20 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Start successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"stop": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"stop": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering stop handler for VM: %s (ID: %s)", vmName, vm.ID)
+		if cfg.DryRun {
+			log.Debugf("Dry-run enabled, skipping stop for VM: %s", vmName)
+			return planDryRun("stop", cfg, vm, result)
+		}
 		if strings.ToUpper(vm.Status) == "SHUTOFF" {
 			log.Debugf("VM %s (ID: %s) already stopped, skipping stop", vmName, vm.ID)
 			return fmt.Errorf("VM '%s' (ID: %s) is already stopped", vmName, vm.ID)
 		}
-		if cfg.DryRun {
-			log.Debugf("Dry-run enabled, skipping stop for VM: %s", vmName)
-			return nil
-		}
 		log.Debugf("Initiating stop API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Stop(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Stop(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Stop failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to stop VM '%s' (ID: %s)", vmName, vm.ID)
@@ -117,14 +147,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Stop successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"pause": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"pause": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering pause handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping pause for VM: %s", vmName)
-			return nil
+			return planDryRun("pause", cfg, vm, result)
 		}
 		log.Debugf("Initiating pause API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Pause(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Pause(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Pause failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to pause VM '%s' (ID: %s)", vmName, vm.ID)
@@ -132,14 +164,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Pause successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"unpause": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"unpause": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering unpause handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping unpause for VM: %s", vmName)
-			return nil
+			return planDryRun("unpause", cfg, vm, result)
 		}
 		log.Debugf("Initiating unpause API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Unpause(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Unpause(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Unpause failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to unpause VM '%s' (ID: %s)", vmName, vm.ID)
@@ -147,14 +181,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Unpause successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"suspend": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"suspend": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering suspend handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping suspend for VM: %s", vmName)
-			return nil
+			return planDryRun("suspend", cfg, vm, result)
 		}
 		log.Debugf("Initiating suspend API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Suspend(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Suspend(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Suspend failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to suspend VM '%s' (ID: %s)", vmName, vm.ID)
@@ -162,14 +198,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Suspend successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"resume": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"resume": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering resume handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping resume for VM: %s", vmName)
-			return nil
+			return planDryRun("resume", cfg, vm, result)
 		}
 		log.Debugf("Initiating resume API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Resume(ctx, client.Compute, vm.ID).ExtractErr()
+		res := servers.Resume(ctx, client.Compute, vm.ID)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Resume failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to resume VM '%s' (ID: %s)", vmName, vm.ID)
@@ -177,14 +215,16 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Resume successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"reboot": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"reboot": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering reboot handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping reboot for VM: %s", vmName)
-			return nil
+			return planDryRun("reboot", cfg, vm, result)
 		}
 		log.Debugf("Initiating reboot API call for VM: %s (ID: %s)", vmName, vm.ID)
-		err := servers.Reboot(ctx, client.Compute, vm.ID, servers.RebootOpts{Type: servers.SoftReboot}).ExtractErr()
+		res := servers.Reboot(ctx, client.Compute, vm.ID, servers.RebootOpts{Type: servers.SoftReboot})
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
 		if err != nil {
 			log.Debugf("Reboot failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
 			return errors.Wrapf(err, "failed to reboot VM '%s' (ID: %s)", vmName, vm.ID)
@@ -192,11 +232,11 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Reboot successful for VM: %s (ID: %s)", vmName, vm.ID)
 		return nil
 	},
-	"set-state": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string) error {
+	"set-state": func(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
 		log.Debugf("Entering set-state handler for VM: %s (ID: %s)", vmName, vm.ID)
 		if cfg.DryRun {
 			log.Debugf("Dry-run enabled, skipping set-state for VM: %s to %s", vmName, cfg.State)
-			return nil
+			return planDryRun("set-state", cfg, vm, result)
 		}
 		desiredState := strings.ToUpper(cfg.State)
 		if desiredState != "ACTIVE" && desiredState != "ERROR" {
@@ -209,28 +249,24 @@ var actionHandlers = map[string]ActionFunc{
 			return fmt.Errorf("VM '%s' (ID: %s) is already in state %s", vmName, vm.ID, desiredState)
 		}
 
-		fmt.Printf("Type 'confirm' to set state of VM '%s' (ID: %s) to %s: ", vmName, vm.ID, desiredState)
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		response := strings.TrimSpace(scanner.Text())
-		log.Debugf("User response for set-state confirmation: %s", response)
-		if strings.ToLower(response) != "confirm" {
-			log.Debugf("Set-state aborted by user for VM: %s (ID: %s) to %s", vmName, vm.ID, desiredState)
-			return fmt.Errorf("set-state aborted by user for VM '%s' (ID: %s) to %s", vmName, vm.ID, desiredState)
-		}
-
 		var err error
 		switch desiredState {
 		case "ACTIVE":
 			if currentState == "SHUTOFF" {
 				log.Debugf("Initiating start API call for VM: %s (ID: %s)", vmName, vm.ID)
-				err = servers.Start(ctx, client.Compute, vm.ID).ExtractErr()
+				res := servers.Start(ctx, client.Compute, vm.ID)
+				result.RequestID = requestID(res.Result)
+				err = res.ExtractErr()
 			} else if currentState == "PAUSED" {
 				log.Debugf("Initiating unpause API call for VM: %s (ID: %s)", vmName, vm.ID)
-				err = servers.Unpause(ctx, client.Compute, vm.ID).ExtractErr()
+				res := servers.Unpause(ctx, client.Compute, vm.ID)
+				result.RequestID = requestID(res.Result)
+				err = res.ExtractErr()
 			} else if currentState == "SUSPENDED" {
 				log.Debugf("Initiating resume API call for VM: %s (ID: %s)", vmName, vm.ID)
-				err = servers.Resume(ctx, client.Compute, vm.ID).ExtractErr()
+				res := servers.Resume(ctx, client.Compute, vm.ID)
+				result.RequestID = requestID(res.Result)
+				err = res.ExtractErr()
 			}
 		case "ERROR":
 			log.Debugf("Initiating set to ERROR state for VM: %s (ID: %s)", vmName, vm.ID)
@@ -244,26 +280,63 @@ var actionHandlers = map[string]ActionFunc{
 		log.Debugf("Set-state successful for VM: %s (ID: %s) to %s", vmName, vm.ID, desiredState)
 		return nil
 	},
+	"set-metadata":   setMetadata,
+	"unset-metadata": unsetMetadata,
+}
+
+// ValidateActionRetries rejects a negative --max-retries of vm manage, which would leave no
+// attempt at all
+func ValidateActionRetries(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid --max-retries %d; must be 0 or more", n)
+	}
+	return nil
 }
 
 func runManage(ctx context.Context, client *auth.Client, action string, cfg Config) error {
-	if cfg.VM == "" {
-		log.Debugf("Validation failed: VM flag is empty")
-		return fmt.Errorf("vm flag is required")
+	if cfg.VM == "" && cfg.FilterStr == "" {
+		log.Debugf("Validation failed: VM and filter flags are empty")
+		return fmt.Errorf("vm or filter flag is required")
+	}
+	if cfg.VM != "" && cfg.FilterStr != "" {
+		return fmt.Errorf("vm and filter flags cannot be combined")
 	}
 	if cfg.Project == "" {
 		log.Debugf("Validation failed: Project flag is empty")
 		return fmt.Errorf("project flag is required")
 	}
-	log.Debugf("Validated inputs: VM=%s, Project=%s", cfg.VM, cfg.Project)
+	if err := ValidateActionRetries(cfg.ActionRetries); err != nil {
+		return err
+	}
+	log.Debugf("Validated inputs: VM=%s, Filter=%s, Project=%s", cfg.VM, cfg.FilterStr, cfg.Project)
 
 	action = strings.ToLower(action)
 	handler, ok := actionHandlers[action]
-	if !ok {
+	report, isReport := reportActions[action]
+	if !ok && !isReport {
 		log.Debugf("Invalid action: %s, available actions: %v", action, listActions())
 		return fmt.Errorf("invalid subcommand: %s; valid subcommands: %v", action, listActions())
 	}
 	log.Debugf("Selected action handler: %s", action)
+	if metadataActions[action] {
+		if _, err := parseMetadata(action, cfg.Metadata); err != nil {
+			return err
+		}
+	}
+	if action == "console-url" {
+		if err := validateConsoleType(cfg.ConsoleType); err != nil {
+			return err
+		}
+	}
+	if action == "console-log" && cfg.ConsoleLines < 0 {
+		return fmt.Errorf("invalid --lines %d: must not be negative", cfg.ConsoleLines)
+	}
+
+	// Structured output must be the only thing on stdout: JSON cannot wait for a typed
+	// confirmation, and any remaining prompts go to stderr like the logs
+	if cfg.OutputFormat == "json" && (destructiveActions[action] || cfg.FilterStr != "") && !isReport && !cfg.DryRun && !cfg.Yes {
+		return fmt.Errorf("--output=json requires --yes for %s; confirmation prompts are not shown with JSON output", action)
+	}
 
 	projectID, err := getProjectID(ctx, client, cfg.Project)
 	if err != nil {
@@ -290,119 +363,405 @@ func runManage(ctx context.Context, client *auth.Client, action string, cfg Conf
 		}
 	}
 
-	vmNamesOrIDs := strings.Split(cfg.VM, ",")
-	log.Debugf("Parsed VM list: %v", vmNamesOrIDs)
-	var results []Result
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5)
-	var mu sync.Mutex
-	totalCount := 0
-	successCount := 0
-
-	for _, vmNameOrID := range vmNamesOrIDs {
+	var targets []*manageTarget
+	if cfg.FilterStr != "" {
+		targets, err = selectTargets(ctx, client, cfg.FilterStr, projectID, cfg.Project)
+		if err != nil {
+			return err
+		}
+	}
+	for _, vmNameOrID := range strings.Split(cfg.VM, ",") {
 		vmNameOrID = strings.TrimSpace(vmNameOrID)
 		if vmNameOrID == "" {
 			log.Debugf("Skipping empty VM name/ID")
 			continue
 		}
-		totalCount++
-		log.Debugf("Processing VM %d/%d: %s", totalCount, len(vmNamesOrIDs), vmNameOrID)
+		targets = append(targets, &manageTarget{input: vmNameOrID})
+	}
+	log.Debugf("Parsed VM list: %d entries", len(targets))
+	totalCount := len(targets)
 
-		isID := uuidRegex.MatchString(vmNameOrID)
-		log.Debugf("VM: %s identified as %s", vmNameOrID, map[bool]string{true: "ID", false: "Name"}[isID])
+	// With --concurrency=1 VMs are processed strictly in input order and each
+	// result is printed as soon as it is known
+	serial := cfg.MaxConcurrency == 1
+	concurrency := cfg.MaxConcurrency
+	if concurrency < 1 {
+		concurrency = 5
+	}
 
+	// Resolve every VM before acting so destructive actions can be confirmed once
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, t := range targets {
+		if t.vm != nil {
+			continue // Selected with --filter
+		}
+		if serial {
+			t.vm, t.err = resolveVM(ctx, client, t.input, projectID, cfg.Project)
+			continue
+		}
 		wg.Add(1)
-		go func(vmNameOrID string, isID bool) {
+		go func(t *manageTarget) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			log.Debugf("Acquired semaphore for VM: %s", vmNameOrID)
-
-			if isID {
-				log.Debugf("Validating VM ID: %s", vmNameOrID)
-				if len(vmNameOrID) != 36 {
-					mu.Lock()
-					results = append(results, Result{
-						VMName:  vmNameOrID,
-						VMID:    "",
-						Status:  "error",
-						Message: fmt.Sprintf("Invalid VM ID format: %s", vmNameOrID),
-					})
-					mu.Unlock()
-					log.Debugf("Invalid VM ID format for: %s", vmNameOrID)
-					return
-				}
-			} else {
-				log.Debugf("Validating VM Name: %s", vmNameOrID)
-				if strings.Contains(vmNameOrID, "-") && uuidRegex.MatchString(vmNameOrID) {
-					log.Warnf("VM name %s resembles an ID; proceeding as name but verify input", vmNameOrID)
-				}
+			t.vm, t.err = resolveVM(ctx, client, t.input, projectID, cfg.Project)
+		}(t)
+	}
+	wg.Wait()
+
+	var resolved []*manageTarget
+	for _, t := range targets {
+		if t.err != nil && cfg.FailFast {
+			return errors.Wrapf(t.err, "failed to find VM %s", t.input)
+		}
+		if t.err != nil {
+			log.Errorf("Error finding VM %s: %v", t.input, t.err)
+			t.result = &Result{
+				VMName:  t.input,
+				VMID:    "",
+				Status:  "error",
+				Message: t.err.Error(),
 			}
+			continue
+		}
+		resolved = append(resolved, t)
+	}
 
-			log.Debugf("Initiating findVM for: %s in project %s", vmNameOrID, cfg.Project)
-			vm, err := findVM(ctx, client, vmNameOrID, projectID, isID)
-			if err != nil {
-				mu.Lock()
-				results = append(results, Result{
-					VMName:  vmNameOrID,
-					VMID:    "",
-					Status:  "error",
-					Message: fmt.Errorf("failed to find VM: %v", err).Error(),
-				})
-				mu.Unlock()
-				log.Errorf("Error finding VM %s: %v", vmNameOrID, err)
-				return
+	if isProtectedAction(action, cfg) {
+		protectedFile, required := cfg.ProtectedFile, true
+		if protectedFile == "" {
+			protectedFile, required = DefaultProtectedFile(), false
+		}
+		protection, err := loadProtectionList(protectedFile, required)
+		if err != nil {
+			return err
+		}
+		var unprotected, protected []*manageTarget
+		for _, t := range resolved {
+			pattern, ok := protection.match(t.vm)
+			if !ok {
+				unprotected = append(unprotected, t)
+				continue
+			}
+			log.Debugf("VM %s (ID: %s) is protected by pattern %q", t.vm.Name, t.vm.ID, pattern)
+			if cfg.OverrideProtection {
+				protected = append(protected, t)
+				continue
 			}
+			t.result = &Result{
+				VMName:  t.input,
+				VMID:    t.vm.ID,
+				Status:  "skipped (protected)",
+				Message: fmt.Sprintf("matches pattern '%s' in %s", pattern, protection.file),
+			}
+		}
+		if len(protected) > 0 && !cfg.DryRun {
+			if err := confirmOverride(promptOutput(cfg), action, protected); err != nil {
+				return err
+			}
+		}
+		resolved = append(unprotected, protected...)
+	}
 
-			err = handler(ctx, client, cfg, vm, vmNameOrID)
-			if err != nil {
-				mu.Lock()
-				results = append(results, Result{
-					VMName:  vmNameOrID,
-					VMID:    vm.ID,
-					Status:  "error",
-					Message: err.Error(),
+	if action == "set-state" && len(resolved) > 0 {
+		resolved = checkServerStates(promptOutput(cfg), cfg, resolved)
+	}
+
+	// A filter can select many VMs, so its dry run lists them and any action that changes them is confirmed
+	filtered := cfg.FilterStr != ""
+	if filtered && cfg.DryRun {
+		return printSelection(action, cfg, resolved)
+	}
+	if (destructiveActions[action] || filtered) && !isReport && !cfg.DryRun && !cfg.Yes && len(resolved) > 0 {
+		if err := confirmBulkAction(action, cfg, resolved); err != nil {
+			return err
+		}
+	}
+
+	// With --fail-fast the first failure cancels actCtx: actions not yet started are skipped
+	// and those in flight see the cancellation in their API calls
+	actCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var failOnce sync.Once
+	var firstErr error
+	run := func(t *manageTarget) {
+		if cfg.FailFast && actCtx.Err() != nil {
+			t.result = &Result{
+				VMName:  t.input,
+				VMID:    t.vm.ID,
+				Status:  "skipped",
+				Message: "not run after an earlier failure (--fail-fast)",
+			}
+			return
+		}
+		t.result = &Result{
+			VMName:   t.input,
+			VMID:     t.vm.ID,
+			Status:   "success",
+			Message:  fmt.Sprintf("Action %s completed", action),
+			UserName: t.owner.Name,
+			Email:    t.owner.Email,
+		}
+		if t.prior != nil {
+			t.result.PriorState = t.prior
+			defer func() { t.result.Message += "; before: " + t.prior.String() }()
+		}
+		// Transient failures (5xx, timeouts) are retried; a dry run changes nothing and is not
+		attempts := cfg.ActionRetries + 1
+		if cfg.DryRun {
+			attempts = 1
+		}
+		retryable := func(err error) bool {
+			if actCtx.Err() != nil || !util.IsTransient(err) {
+				return false
+			}
+			log.Warnf("Transient failure of action %s on VM %s (attempt %d/%d), retrying: %v", action, t.input, t.result.Attempts, attempts, err)
+			return true
+		}
+		started := time.Now()
+		err := util.WithRetryIf(actCtx, attempts, cfg.RetryDelay, retryable, func() error {
+			t.result.Attempts++
+			if isReport {
+				return report(actCtx, client, cfg, t.vm, t.result)
+			}
+			return handler(actCtx, client, cfg, t.vm, t.input, t.result)
+		})
+		finished := time.Now()
+		t.result.StartedAt, t.result.FinishedAt = started.UTC(), finished.UTC()
+		t.result.DurationMs = finished.Sub(started).Milliseconds()
+		if t.result.Attempts > 1 {
+			defer func() { t.result.Message += fmt.Sprintf(" (%d attempts)", t.result.Attempts) }()
+		}
+		if err != nil {
+			log.Errorf("Error executing action %s on VM %s%s: %v", action, t.input, requestIDSuffix(t.result.RequestID), err)
+			t.result.Status, t.result.Message = "error", err.Error()
+			if cfg.FailFast {
+				failOnce.Do(func() {
+					firstErr = errors.Wrapf(err, "action %s failed on VM %s", action, t.input)
+					cancel()
 				})
-				mu.Unlock()
-				log.Errorf("Error executing action %s on VM %s: %v", action, vmNameOrID, err)
+			}
+			return
+		}
+		log.Debugf("Action %s successful for VM: %s (ID: %s)%s in %dms", action, t.input, t.vm.ID, requestIDSuffix(t.result.RequestID), t.result.DurationMs)
+		if cfg.Wait && !cfg.DryRun {
+			waitForState(actCtx, client, action, cfg, t.vm, t.result)
+		}
+		if metadataActions[action] {
+			metadata, err := resultingMetadata(actCtx, client, action, cfg, t.vm)
+			if err != nil {
+				log.Warnf("Action %s completed on VM %s but its metadata could not be read: %v", action, t.input, err)
 				return
 			}
+			t.result.Metadata = metadata
+			label := "metadata"
+			if cfg.DryRun {
+				label = "metadata would be"
+			}
+			t.result.Message = fmt.Sprintf("%s; %s: %s", t.result.Message, label, formatMetadata(metadata))
+		}
+	}
 
-			mu.Lock()
-			results = append(results, Result{
-				VMName:  vmNameOrID,
-				VMID:    vm.ID,
-				Status:  "success",
-				Message: fmt.Sprintf("Action %s completed", action),
-			})
+	printTable := cfg.OutputFormat == "table"
+	runStarted := time.Now()
+	if serial {
+		for _, t := range targets {
+			if t.result == nil {
+				run(t)
+			}
+			if printTable {
+				printResult(os.Stdout, *t.result, cfg.ShowTiming, cfg.ShowRequestID)
+			}
+		}
+	} else {
+		for _, t := range resolved {
+			if t.result != nil {
+				continue
+			}
+			wg.Add(1)
+			go func(t *manageTarget) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				log.Debugf("Acquired semaphore for VM: %s", t.input)
+				run(t)
+			}(t)
+		}
+		wg.Wait()
+	}
+	wallClock := time.Since(runStarted)
+
+	results := make([]Result, 0, len(targets))
+	successCount := 0
+	var slowest *Result
+	for _, t := range targets {
+		results = append(results, *t.result)
+		if t.result.Status == "success" {
 			successCount++
-			mu.Unlock()
-			log.Debugf("Action %s successful for VM: %s (ID: %s)", action, vmNameOrID, vm.ID)
-		}(vmNameOrID, isID)
+		}
+		if !t.result.StartedAt.IsZero() && (slowest == nil || t.result.DurationMs > slowest.DurationMs) {
+			slowest = t.result
+		}
 	}
-	wg.Wait()
 
-	if cfg.OutputFormat == "json" {
-		data, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			return errors.Wrap(err, "failed to marshal JSON")
+	if !printTable {
+		out := &output.Result{Headers: []string{"VM Name", "VM ID", "Status", "Message"}, Data: results}
+		if filtered {
+			out.Headers = append(out.Headers, "User Name", "Email")
+		}
+		if cfg.ShowTiming {
+			out.Headers = append(out.Headers, "Started At", "Finished At", "Duration (ms)")
+		}
+		if cfg.ShowRequestID {
+			out.Headers = append(out.Headers, "Request ID")
+		}
+		if action == "console-log" {
+			out.Headers = append(out.Headers, "Console Output")
+		}
+		for _, r := range results {
+			row := []interface{}{r.VMName, r.VMID, r.Status, r.Message}
+			if filtered {
+				row = append(row, r.UserName, r.Email)
+			}
+			if cfg.ShowTiming {
+				row = append(row, formatTimestamp(r.StartedAt), formatTimestamp(r.FinishedAt), r.DurationMs)
+			}
+			if cfg.ShowRequestID {
+				row = append(row, r.RequestID)
+			}
+			if action == "console-log" {
+				row = append(row, r.ConsoleOutput)
+			}
+			out.AddRow(row...)
+		}
+		if err := output.Print(cfg.OutputFormat, out); err != nil {
+			return errors.Wrap(err, "failed to print results")
 		}
-		fmt.Println(string(data))
 	} else {
 		fmt.Printf("Total VMs processed: %d, Successful: %d\n", totalCount, successCount)
-		for _, result := range results {
-			fmt.Printf("VM: %s (ID: %s) - Status: %s, Message: %s\n", result.VMName, result.VMID, result.Status, result.Message)
+		if slowest != nil {
+			fmt.Printf("Total time: %s, Slowest VM: %s (%dms)\n", wallClock.Round(time.Millisecond), slowest.VMName, slowest.DurationMs)
+		}
+		if !serial {
+			for _, result := range results {
+				printResult(os.Stdout, result, cfg.ShowTiming, cfg.ShowRequestID)
+			}
 		}
 	}
 
+	return firstErr
+}
+
+// printResult prints one table-mode result line, with the action's start time and duration when showTiming is set
+// and its Nova request ID when showRequestID is set, followed by the console output of console-log
+func printResult(w io.Writer, result Result, showTiming, showRequestID bool) {
+	var extra string
+	if showRequestID && result.RequestID != "" {
+		extra = ", Request ID: " + result.RequestID
+	}
+	if showTiming && !result.StartedAt.IsZero() {
+		fmt.Fprintf(w, "VM: %s (ID: %s) - Status: %s, Message: %s, Started: %s, Duration: %dms%s\n", result.VMName, result.VMID,
+			result.Status, result.Message, result.StartedAt.Format(time.RFC3339Nano), result.DurationMs, extra)
+	} else {
+		fmt.Fprintf(w, "VM: %s (ID: %s) - Status: %s, Message: %s%s\n", result.VMName, result.VMID, result.Status, result.Message, extra)
+	}
+	if result.ConsoleOutput != "" {
+		fmt.Fprintln(w, strings.TrimRight(result.ConsoleOutput, "\n"))
+	}
+}
+
+// requestIDHeader is the response header in which Nova returns the ID of a request
+const requestIDHeader = "X-Openstack-Request-Id"
+
+// requestID returns the Nova request ID of r, also of a failed request when Nova answered it
+func requestID(r gophercloud.Result) string {
+	if id := r.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+	var codeErr gophercloud.ErrUnexpectedResponseCode
+	if errors.As(r.Err, &codeErr) {
+		return codeErr.ResponseHeader.Get(requestIDHeader)
+	}
+	return ""
+}
+
+// requestIDSuffix formats id for log messages, leaving them unchanged when there is none
+func requestIDSuffix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (request ID: %s)", id)
+}
+
+// formatTimestamp formats t for CSV cells, leaving actions that never ran empty
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// resolveVM validates a VM name or ID from the command line and looks up the server it refers to
+func resolveVM(ctx context.Context, client *auth.Client, vmNameOrID, projectID, projectName string) (*servers.Server, error) {
+	isID := uuidRegex.MatchString(vmNameOrID)
+	log.Debugf("VM: %s identified as %s", vmNameOrID, map[bool]string{true: "ID", false: "Name"}[isID])
+	if isID && len(vmNameOrID) != 36 {
+		log.Debugf("Invalid VM ID format for: %s", vmNameOrID)
+		return nil, fmt.Errorf("Invalid VM ID format: %s", vmNameOrID)
+	}
+
+	log.Debugf("Initiating findVM for: %s in project %s", vmNameOrID, projectName)
+	vm, err := findVM(ctx, client, vmNameOrID, projectID, isID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find VM: %v", err)
+	}
+	return vm, nil
+}
+
+// promptOutput is where confirmation prompts go: stdout for tables, stderr for structured
+// output so that stdout holds only the document
+func promptOutput(cfg Config) io.Writer {
+	if cfg.OutputFormat == "table" {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// confirmBulkAction lists the VMs a destructive or filtered action will touch and asks for a single confirmation
+func confirmBulkAction(action string, cfg Config, targets []*manageTarget) error {
+	out := promptOutput(cfg)
+	what := action
+	if action == "set-state" {
+		what = fmt.Sprintf("set state to %s for", strings.ToUpper(cfg.State))
+	}
+	fmt.Fprintf(out, "About to %s %d VM(s) in project %s:\n", what, len(targets), cfg.Project)
+	for _, t := range targets {
+		if cfg.FilterStr != "" {
+			fmt.Fprintf(out, " - %s (ID: %s, Status: %s, Age: %s, Owner: %s %s)\n", t.vm.Name, t.vm.ID, t.vm.Status,
+				formatDuration(time.Since(t.vm.Created)), t.owner.Name, t.owner.Email)
+			continue
+		}
+		fmt.Fprintf(out, " - %s (ID: %s, Status: %s)\n", t.vm.Name, t.vm.ID, t.vm.Status)
+	}
+	fmt.Fprint(out, "Type 'confirm' to continue: ")
+	stdin.Scan()
+	response := strings.TrimSpace(stdin.Text())
+	log.Debugf("User response for %s confirmation: %s", action, response)
+	if strings.ToLower(response) != "confirm" {
+		return fmt.Errorf("%s aborted by user", action)
+	}
 	return nil
 }
 
 func listActions() []string {
-	actions := make([]string, 0, len(actionHandlers))
+	actions := make([]string, 0, len(actionHandlers)+len(reportActions))
 	for k := range actionHandlers {
 		actions = append(actions, k)
 	}
+	for k := range reportActions {
+		actions = append(actions, k)
+	}
 	return actions
 }
 
@@ -0,0 +1,152 @@
+package vm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+// newFakeOpenStack serves the Keystone and Nova calls of manage for project "demo" with the
+// ACTIVE VM "web-1"
+func newFakeOpenStack(t *testing.T) *auth.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/v3/projects", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"projects": [{"id": "p1", "name": "demo"}], "links": {}}`)
+	})
+	mux.HandleFunc("/identity/v3/role_assignments", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"role_assignments": [], "links": {}}`)
+	})
+	mux.HandleFunc("/compute/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"servers": [{"id": "7a1c3a52-0d6f-4b8e-9b59-2f4c1e7d9a10", "name": "web-1", "status": "ACTIVE", "tenant_id": "p1"}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	return &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+		Compute:  &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/compute/"},
+	}
+}
+
+func TestRunManageDryRunJSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // No protected-VMs file
+	client := newFakeOpenStack(t)
+	cfg := Config{VM: "web-1", Project: "demo", DryRun: true, OutputFormat: "json", MaxConcurrency: 1}
+
+	var runErr error
+	out, _ := testutil.CaptureOutput(t, func() {
+		runErr = runManage(context.Background(), client, "stop", cfg)
+	})
+	if runErr != nil {
+		t.Fatalf("runManage: %v", runErr)
+	}
+	var results []Result
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, out)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1: %s", len(results), out)
+	}
+	if got := results[0]; got.VMName != "web-1" || got.Status != "success" || got.PlannedState != "SHUTOFF" {
+		t.Errorf("got result %+v, want web-1 planned to go SHUTOFF", got)
+	}
+}
+
+func TestRunManageMetadataDryRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // No protected-VMs file
+	tests := []struct {
+		action   string
+		metadata []string
+		want     string
+	}{
+		{action: "set-metadata", metadata: []string{"owner=alice", "cost_center=42"}, want: "would set cost_center=42, owner=alice; metadata would be: cost_center=42, owner=alice"},
+		{action: "unset-metadata", metadata: []string{"owner"}, want: "would unset owner; metadata would be: (none)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			client := newFakeOpenStack(t)
+			cfg := Config{VM: "web-1", Project: "demo", DryRun: true, Metadata: tt.metadata, OutputFormat: "json", MaxConcurrency: 1}
+			var runErr error
+			out, _ := testutil.CaptureOutput(t, func() {
+				runErr = runManage(context.Background(), client, tt.action, cfg)
+			})
+			if runErr != nil {
+				t.Fatalf("runManage: %v", runErr)
+			}
+			var results []Result
+			if err := json.Unmarshal([]byte(out), &results); err != nil {
+				t.Fatalf("stdout is not JSON: %v\n%s", err, out)
+			}
+			if len(results) != 1 || results[0].Message != tt.want {
+				t.Errorf("got results %+v, want message %q", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintResultConsoleOutput(t *testing.T) {
+	result := Result{
+		VMName:        "web-1",
+		VMID:          "7a1c3a52-0d6f-4b8e-9b59-2f4c1e7d9a10",
+		Status:        "success",
+		Message:       "Action console-log completed",
+		StartedAt:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		DurationMs:    42,
+		ConsoleOutput: "login: \n",
+	}
+	for _, showTiming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("timing=%v", showTiming), func(t *testing.T) {
+			var out bytes.Buffer
+			printResult(&out, result, showTiming, false)
+			lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+			if len(lines) != 2 || lines[1] != "login: " {
+				t.Fatalf("got %q, want the result line followed by the console output", out.String())
+			}
+			if got := strings.Contains(lines[0], "Duration: 42ms"); got != showTiming {
+				t.Errorf("result line %q shows timing: %v, want %v", lines[0], got, showTiming)
+			}
+		})
+	}
+}
+
+func TestValidateActionRetries(t *testing.T) {
+	for _, tt := range []struct {
+		retries int
+		wantErr bool
+	}{{-1, true}, {0, false}, {3, false}} {
+		if err := ValidateActionRetries(tt.retries); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateActionRetries(%d) = %v, want error: %v", tt.retries, err, tt.wantErr)
+		}
+	}
+}
+
+// TestRunManageNegativeRetries checks that a negative --max-retries fails instead of reporting
+// success for a VM that was never acted on
+func TestRunManageNegativeRetries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	client := newFakeOpenStack(t)
+	cfg := Config{VM: "web-1", Project: "demo", OutputFormat: "json", Yes: true, MaxConcurrency: 1, ActionRetries: -1}
+
+	var runErr error
+	out, _ := testutil.CaptureOutput(t, func() {
+		runErr = runManage(context.Background(), client, "start", cfg)
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), "--max-retries") {
+		t.Errorf("got error %v, want one naming --max-retries", runErr)
+	}
+	if out != "" {
+		t.Errorf("got stdout %q, want no results", out)
+	}
+}
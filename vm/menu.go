@@ -0,0 +1,107 @@
+package vm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// menuPageSize is the number of entries shown per page in interactive selectors
+const menuPageSize = 20
+
+// choose prompts for one of labels and returns its index into labels, or -1 when the
+// user skips (only allowed when allowSkip is set). Lists longer than menuPageSize are
+// paged with n/p, and "/pattern" narrows the list to labels containing pattern.
+func choose(in *bufio.Scanner, out io.Writer, promptMsg string, labels []string, allowSkip bool) (int, error) {
+	visible := allIndices(len(labels))
+	page := 0
+	render := true
+	for retries := 0; retries < 3; {
+		pages := (len(visible) + menuPageSize - 1) / menuPageSize
+		paged := len(visible) > menuPageSize
+		if render {
+			start, end := 0, len(visible)
+			if paged {
+				start = page * menuPageSize
+				end = min(start+menuPageSize, len(visible))
+			}
+			for i := start; i < end; i++ {
+				fmt.Fprintf(out, "%d) %s\n", i+1, labels[visible[i]])
+			}
+			if paged {
+				fmt.Fprintf(out, "-- Page %d/%d (n: next, p: previous, /pattern: search) --\n", page+1, pages)
+			} else if len(labels) > menuPageSize {
+				fmt.Fprintln(out, "-- /pattern: search, / to show all --")
+			}
+			render = false
+		}
+
+		fmt.Fprint(out, promptMsg)
+		if !in.Scan() {
+			if err := in.Err(); err != nil {
+				return -1, err
+			}
+			return -1, io.ErrUnexpectedEOF
+		}
+		input := strings.TrimSpace(in.Text())
+
+		switch {
+		case paged && strings.EqualFold(input, "n"):
+			if page < pages-1 {
+				page++
+			}
+			render = true
+			continue
+		case paged && strings.EqualFold(input, "p"):
+			if page > 0 {
+				page--
+			}
+			render = true
+			continue
+		case strings.HasPrefix(input, "/"):
+			pattern := strings.ToLower(strings.TrimPrefix(input, "/"))
+			matches := filterIndices(labels, pattern)
+			if len(matches) == 0 {
+				fmt.Fprintf(out, "No matches for '%s'.\n", pattern)
+				continue
+			}
+			visible, page, render = matches, 0, true
+			continue
+		case allowSkip && (input == "" || input == "0"):
+			fmt.Fprintln(out, "Skipped.")
+			return -1, nil
+		}
+
+		n, err := strconv.Atoi(input)
+		if err == nil && n >= 1 && n <= len(visible) {
+			return visible[n-1], nil
+		}
+		retries++
+		fmt.Fprintf(out, "Invalid choice. %d retries left.\n", 3-retries)
+	}
+	return -1, fmt.Errorf("too many invalid attempts")
+}
+
+func allIndices(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+// filterIndices returns the indices of labels containing pattern (case-insensitive)
+func filterIndices(labels []string, pattern string) []int {
+	if pattern == "" {
+		return allIndices(len(labels))
+	}
+	var idx []int
+	for i, l := range labels {
+		if strings.Contains(strings.ToLower(l), pattern) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
@@ -0,0 +1,152 @@
+package vm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// hostLabels returns n labels host-01, host-02, ...
+func hostLabels(n int) []string {
+	labels := make([]string, n)
+	for i := range labels {
+		labels[i] = fmt.Sprintf("host-%02d", i+1)
+	}
+	return labels
+}
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    []string
+		allowSkip bool
+		input     string
+		want      int
+		wantErr   error
+		wantOut   []string // Substrings of the output, in order
+	}{
+		{
+			name:   "number",
+			labels: hostLabels(3),
+			input:  "2\n",
+			want:   1,
+		},
+		{
+			name:    "next page",
+			labels:  hostLabels(45),
+			input:   "n\n25\n",
+			want:    24,
+			wantOut: []string{"20) host-20", "-- Page 1/3", "21) host-21", "-- Page 2/3"},
+		},
+		{
+			name:    "next page stops at the last page",
+			labels:  hostLabels(45),
+			input:   "n\nn\nn\n45\n",
+			want:    44,
+			wantOut: []string{"-- Page 2/3", "41) host-41", "-- Page 3/3", "-- Page 3/3"},
+		},
+		{
+			name:    "previous page",
+			labels:  hostLabels(45),
+			input:   "n\np\n3\n",
+			want:    2,
+			wantOut: []string{"-- Page 1/3", "-- Page 2/3", "1) host-01", "-- Page 1/3"},
+		},
+		{
+			name:    "search",
+			labels:  hostLabels(45),
+			input:   "/HOST-3\n2\n",
+			want:    30,
+			wantOut: []string{"1) host-30", "2) host-31", "10) host-39", "-- /pattern: search, / to show all --"},
+		},
+		{
+			name:    "search without matches",
+			labels:  hostLabels(45),
+			input:   "/zzz\n7\n",
+			want:    6,
+			wantOut: []string{"No matches for 'zzz'."},
+		},
+		{
+			name:    "empty search shows all",
+			labels:  hostLabels(45),
+			input:   "/host-4\n/\nn\n22\n",
+			want:    21,
+			wantOut: []string{"1) host-40", "6) host-45", "-- Page 1/3", "-- Page 2/3"},
+		},
+		{
+			name:      "skip with empty input",
+			labels:    hostLabels(3),
+			allowSkip: true,
+			input:     "\n",
+			want:      -1,
+			wantOut:   []string{"Skipped."},
+		},
+		{
+			name:      "skip with 0",
+			labels:    hostLabels(3),
+			allowSkip: true,
+			input:     "0\n",
+			want:      -1,
+			wantOut:   []string{"Skipped."},
+		},
+		{
+			name:    "empty input without skip is invalid",
+			labels:  hostLabels(3),
+			input:   "\n1\n",
+			want:    0,
+			wantOut: []string{"Invalid choice. 2 retries left."},
+		},
+		{
+			name:    "re-prompt after invalid input",
+			labels:  hostLabels(3),
+			input:   "abc\n4\n3\n",
+			want:    2,
+			wantOut: []string{"Invalid choice. 2 retries left.", "Pick: ", "Invalid choice. 1 retries left.", "Pick: "},
+		},
+		{
+			name:    "n is invalid without paging",
+			labels:  hostLabels(3),
+			input:   "n\n1\n",
+			want:    0,
+			wantOut: []string{"Invalid choice. 2 retries left."},
+		},
+		{
+			name:    "too many invalid attempts",
+			labels:  hostLabels(3),
+			input:   "x\ny\nz\n1\n",
+			want:    -1,
+			wantErr: errors.New("too many invalid attempts"),
+		},
+		{
+			name:    "end of input",
+			labels:  hostLabels(3),
+			input:   "",
+			want:    -1,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := choose(bufio.NewScanner(strings.NewReader(tt.input)), &out, "Pick: ", tt.labels, tt.allowSkip)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			rest := out.String()
+			for _, want := range tt.wantOut {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("output does not contain %q after the earlier matches:\n%s", want, out.String())
+				}
+				rest = rest[i+len(want):]
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// metadataActions are the manage actions that edit server metadata; their results carry the
+// metadata the VM ends up with
+var metadataActions = map[string]bool{
+	"set-metadata":   true,
+	"unset-metadata": true,
+}
+
+// parseMetadata parses --metadata values. set-metadata takes key=value pairs; unset-metadata
+// takes keys, ignoring any =value so the same flags can be reused for both.
+func parseMetadata(action string, pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("--metadata is required for %s", action)
+	}
+	metadata := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, hasValue := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid --metadata '%s': empty key", pair)
+		}
+		if action == "set-metadata" && !hasValue {
+			return nil, fmt.Errorf("invalid --metadata '%s': expected key=value", pair)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// setMetadata adds or replaces the --metadata keys of a VM, leaving other keys untouched
+func setMetadata(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
+	log.Debugf("Entering set-metadata handler for VM: %s (ID: %s)", vmName, vm.ID)
+	metadata, err := parseMetadata("set-metadata", cfg.Metadata)
+	if err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		log.Debugf("Dry-run enabled, skipping set-metadata for VM: %s", vmName)
+		result.Message = "would set " + formatMetadata(metadata)
+		return nil
+	}
+	res := servers.UpdateMetadata(ctx, client.Compute, vm.ID, servers.MetadataOpts(metadata))
+	result.RequestID = requestID(res.Result)
+	if _, err := res.Extract(); err != nil {
+		log.Debugf("Set-metadata failed for VM: %s (ID: %s), error: %v", vmName, vm.ID, err)
+		return errors.Wrapf(err, "failed to set metadata of VM '%s' (ID: %s)", vmName, vm.ID)
+	}
+	log.Debugf("Set-metadata successful for VM: %s (ID: %s)", vmName, vm.ID)
+	return nil
+}
+
+// unsetMetadata removes the --metadata keys of a VM; keys that are not set are skipped
+func unsetMetadata(ctx context.Context, client *auth.Client, cfg Config, vm *servers.Server, vmName string, result *Result) error {
+	log.Debugf("Entering unset-metadata handler for VM: %s (ID: %s)", vmName, vm.ID)
+	metadata, err := parseMetadata("unset-metadata", cfg.Metadata)
+	if err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		log.Debugf("Dry-run enabled, skipping unset-metadata for VM: %s", vmName)
+		result.Message = "would unset " + strings.Join(sortedKeys(metadata), ", ")
+		return nil
+	}
+	for _, key := range sortedKeys(metadata) {
+		res := servers.DeleteMetadatum(ctx, client.Compute, vm.ID, key)
+		result.RequestID = requestID(res.Result)
+		err := res.ExtractErr()
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			log.Debugf("Metadata key %s not set on VM: %s (ID: %s), skipping", key, vmName, vm.ID)
+			continue
+		}
+		if err != nil {
+			log.Debugf("Unset-metadata failed for VM: %s (ID: %s), key %s, error: %v", vmName, vm.ID, key, err)
+			return errors.Wrapf(err, "failed to unset metadata key '%s' of VM '%s' (ID: %s)", key, vmName, vm.ID)
+		}
+	}
+	log.Debugf("Unset-metadata successful for VM: %s (ID: %s)", vmName, vm.ID)
+	return nil
+}
+
+// resultingMetadata returns the metadata of vm after a metadata action: read back from Nova,
+// or on a dry run computed from the metadata the VM had when it was resolved
+func resultingMetadata(ctx context.Context, client *auth.Client, action string, cfg Config, vm *servers.Server) (map[string]string, error) {
+	if !cfg.DryRun {
+		metadata, err := servers.Metadata(ctx, client.Compute, vm.ID).Extract()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read metadata of VM %s", vm.ID)
+		}
+		return metadata, nil
+	}
+	changes, err := parseMetadata(action, cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(vm.Metadata)+len(changes))
+	for k, v := range vm.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range changes {
+		if action == "unset-metadata" {
+			delete(metadata, k)
+			continue
+		}
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
+// formatMetadata formats metadata as sorted key=value pairs for table and CSV output
+func formatMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return "(none)"
+	}
+	pairs := make([]string, 0, len(metadata))
+	for _, k := range sortedKeys(metadata) {
+		pairs = append(pairs, k+"="+metadata[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
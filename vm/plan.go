@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// transition is what an action does to the status of a VM: the statuses Nova accepts it in
+// (any when empty) and the status the VM ends in
+type transition struct {
+	from []string
+	to   string
+}
+
+// transitions are the planned status changes of the state-changing actions on a dry run
+var transitions = map[string]transition{
+	"delete":       {to: "DELETED"},
+	"force-delete": {to: "DELETED"},
+	"start":        {from: []string{"SHUTOFF"}, to: "ACTIVE"},
+	"stop":         {to: "SHUTOFF"},
+	"pause":        {from: []string{"ACTIVE"}, to: "PAUSED"},
+	"unpause":      {from: []string{"PAUSED"}, to: "ACTIVE"},
+	"suspend":      {from: []string{"ACTIVE"}, to: "SUSPENDED"},
+	"resume":       {from: []string{"SUSPENDED"}, to: "ACTIVE"},
+	"reboot":       {from: []string{"ACTIVE"}, to: "ACTIVE"},
+}
+
+// setStateTransition is the transition of set-state: the handler only brings stopped, paused
+// and suspended VMs back to ACTIVE
+func setStateTransition(state string) transition {
+	if strings.ToUpper(state) == "ACTIVE" {
+		return transition{from: []string{"SHUTOFF", "PAUSED", "SUSPENDED"}, to: "ACTIVE"}
+	}
+	return transition{to: strings.ToUpper(state)}
+}
+
+// planDryRun records on result the status a real run would leave vm in, as PlannedState and
+// as "would change ACTIVE → SHUTOFF" in the message. A VM already in the target status is
+// marked skipped; one in a status the action is not accepted in returns the error Nova would
+// give, so the preview shows the failures a real run would have.
+func planDryRun(action string, cfg Config, vm *servers.Server, result *Result) error {
+	t, ok := transitions[action]
+	if action == "set-state" {
+		t, ok = setStateTransition(cfg.State), true
+	}
+	if !ok {
+		return nil
+	}
+	current := strings.ToUpper(vm.Status)
+	result.PlannedState = t.to
+	switch {
+	case current == t.to && action != "reboot":
+		result.Status = "skipped"
+		result.Message = fmt.Sprintf("already %s → skip", current)
+		return nil
+	case action == "set-state" && t.to == "ERROR":
+		result.PlannedState = current
+		return fmt.Errorf("VM '%s' (ID: %s) would stay %s: setting ERROR state is not supported", vm.Name, vm.ID, current)
+	case len(t.from) > 0 && !slices.Contains(t.from, current):
+		result.PlannedState = current
+		return fmt.Errorf("VM '%s' (ID: %s) would stay %s: %s needs a VM in status %s", vm.Name, vm.ID, current, action, strings.Join(t.from, " or "))
+	}
+	result.Message = fmt.Sprintf("would change %s → %s", current, t.to)
+	return nil
+}
@@ -0,0 +1,137 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProtectedFile returns the protection file used when --protected-file is not given
+func DefaultProtectedFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "openstack-tool", "protected-vms.yaml")
+}
+
+// protectionList holds VM names, IDs or glob patterns that destructive actions must not touch
+type protectionList struct {
+	file     string
+	patterns []string
+}
+
+// loadProtectionList reads a protection file. The file is a YAML list of strings, either at
+// the top level or under a "protected:" key. A missing file is only an error when required.
+func loadProtectionList(file string, required bool) (*protectionList, error) {
+	pl := &protectionList{file: file}
+	if file == "" {
+		return pl, nil
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) && !required {
+		log.Debugf("Protection file %s not found, no VMs are protected", file)
+		return pl, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read protection file")
+	}
+	entries, err := parseProtectionList(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q: %v", file, entry, err)
+		}
+		pl.patterns = append(pl.patterns, entry)
+	}
+	log.Debugf("Loaded %d protection patterns from %s", len(pl.patterns), file)
+	return pl, nil
+}
+
+// parseProtectionList decodes the entries of a protection file: a YAML sequence of strings,
+// or a mapping whose only key "protected" holds one. An empty document has no entries.
+func parseProtectionList(data []byte) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode {
+		var list *yaml.Node
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if key := root.Content[i].Value; key != "protected" {
+				return nil, fmt.Errorf("line %d: unknown key %q, expected \"protected\"", root.Content[i].Line, key)
+			}
+			list = root.Content[i+1]
+		}
+		if list == nil || list.Tag == "!!null" {
+			return nil, nil
+		}
+		root = list
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of VM names, IDs or patterns", root.Line)
+	}
+	var entries []string
+	if err := root.Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// match returns the pattern protecting vm, comparing against both its name and ID case-insensitively
+func (pl *protectionList) match(vm *servers.Server) (string, bool) {
+	name, id := strings.ToLower(vm.Name), strings.ToLower(vm.ID)
+	for _, p := range pl.patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return p, true
+		}
+		if ok, _ := path.Match(p, id); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// isProtectedAction reports whether action is one the protection file guards against
+func isProtectedAction(action string, cfg Config) bool {
+	switch action {
+	case "delete", "force-delete":
+		return true
+	case "set-state":
+		return strings.ToUpper(cfg.State) == "ERROR"
+	}
+	return false
+}
+
+// confirmOverride asks on out for an extra typed confirmation before acting on protected VMs
+func confirmOverride(out io.Writer, action string, protected []*manageTarget) error {
+	fmt.Fprintf(out, "WARNING: --override-protection will %s %d protected VM(s):\n", action, len(protected))
+	for _, t := range protected {
+		fmt.Fprintf(out, " - %s (ID: %s)\n", t.vm.Name, t.vm.ID)
+	}
+	fmt.Fprint(out, "Type 'override protection' to continue: ")
+	stdin.Scan()
+	response := strings.TrimSpace(stdin.Text())
+	log.Debugf("User response for protection override: %s", response)
+	if strings.ToLower(response) != "override protection" {
+		return fmt.Errorf("protection override not confirmed")
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+func TestLoadProtectionList(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "protected key",
+			content: "protected:\n  - infra-*\n  - DNS-01 # primary resolver\n  - \"0b5c3a4e-1111-2222-3333-444455556666\"\n",
+			want:    []string{"infra-*", "dns-01", "0b5c3a4e-1111-2222-3333-444455556666"},
+		},
+		{
+			name:    "top-level list",
+			content: "---\n# critical VMs\n- db-*\n- 'web 1'\n",
+			want:    []string{"db-*", "web 1"},
+		},
+		{
+			name:    "flow list",
+			content: "protected: [db-*, dns-01]\n",
+			want:    []string{"db-*", "dns-01"},
+		},
+		{name: "empty file", content: ""},
+		{name: "comments only", content: "# nothing protected yet\n"},
+		{name: "empty protected key", content: "protected:\n"},
+		{
+			name:    "unknown key",
+			content: "protect:\n  - db-*\n",
+			wantErr: `line 1: unknown key "protect", expected "protected"`,
+		},
+		{
+			name:    "not a list",
+			content: "protected: db-*\n",
+			wantErr: "line 1: expected a list of VM names, IDs or patterns",
+		},
+		{
+			name:    "entry that is not a string",
+			content: "protected:\n  - name: db-1\n",
+			wantErr: "cannot unmarshal",
+		},
+		{
+			name:    "invalid YAML",
+			content: "protected:\n  - db-*\n - dns-01\n",
+			wantErr: "yaml:",
+		},
+		{
+			name:    "invalid pattern",
+			content: "- db-[\n",
+			wantErr: `invalid pattern "db-["`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "protected-vms.yaml")
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			pl, err := loadProtectionList(file, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadProtectionList: %v", err)
+			}
+			if !reflect.DeepEqual(pl.patterns, tt.want) {
+				t.Errorf("got patterns %q, want %q", pl.patterns, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProtectionListMissingFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "missing.yaml")
+	if pl, err := loadProtectionList(file, false); err != nil || len(pl.patterns) != 0 {
+		t.Errorf("got %v, %v for a missing default file, want no patterns", pl, err)
+	}
+	if _, err := loadProtectionList(file, true); err == nil {
+		t.Error("got no error for a missing --protected-file")
+	}
+}
+
+func TestProtectionListMatch(t *testing.T) {
+	pl := &protectionList{patterns: []string{"infra-*", "0b5c3a4e-1111-2222-3333-444455556666"}}
+	tests := []struct {
+		vm   servers.Server
+		want bool
+	}{
+		{vm: servers.Server{Name: "INFRA-dns", ID: "1"}, want: true},
+		{vm: servers.Server{Name: "web-1", ID: "0B5C3A4E-1111-2222-3333-444455556666"}, want: true},
+		{vm: servers.Server{Name: "web-1", ID: "2"}, want: false},
+	}
+	for _, tt := range tests {
+		if _, got := pl.match(&tt.vm); got != tt.want {
+			t.Errorf("match(%s, %s) = %v, want %v", tt.vm.Name, tt.vm.ID, got, tt.want)
+		}
+	}
+}
+
+func TestIsProtectedAction(t *testing.T) {
+	tests := []struct {
+		action string
+		state  string
+		want   bool
+	}{
+		{action: "delete", want: true},
+		{action: "force-delete", want: true},
+		{action: "set-state", state: "error", want: true},
+		{action: "set-state", state: "active", want: false},
+		{action: "stop", want: false},
+		{action: "rebuild", want: false},
+	}
+	for _, tt := range tests {
+		if got := isProtectedAction(tt.action, Config{State: tt.state}); got != tt.want {
+			t.Errorf("isProtectedAction(%s, state %q) = %v, want %v", tt.action, tt.state, got, tt.want)
+		}
+	}
+}
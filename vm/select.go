@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"context"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// SelectedVM is a VM chosen by vm manage --filter, as listed by a dry run
+type SelectedVM struct {
+	Name     string    `json:"name"`
+	ID       string    `json:"id"`
+	Status   string    `json:"status"`
+	Created  time.Time `json:"created"`
+	Age      string    `json:"age"`
+	UserName string    `json:"user_name"`
+	Email    string    `json:"email"`
+}
+
+// selectTargets returns the VMs of a project matching the vm info filter expression, with their
+// owners resolved the same way as vm info
+func selectTargets(ctx context.Context, client *auth.Client, filterStr, projectID, projectName string) ([]*manageTarget, error) {
+	f, err := parseFilter(filterStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse filter")
+	}
+	if f.FIP != "" {
+		return nil, errors.New("the fip filter key is only supported by vm info")
+	}
+	users, err := fetchAllUsers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*manageTarget
+	err = servers.List(client.Compute, servers.ListOpts{AllTenants: true, TenantID: projectID}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		serverList, err := servers.ExtractServers(page)
+		if err != nil {
+			return false, err
+		}
+		for i := range serverList {
+			s := serverList[i]
+			owner := resolveOwner(s, users)
+			details := Vmdetails{
+				Name:             s.Name,
+				Hypervisor:       s.Host,
+				AvailabilityZone: s.AvailabilityZone,
+				Email:            owner.Email,
+				UserName:         owner.Name,
+				ProjectName:      projectName,
+				Created:          s.Created,
+				Status:           s.Status,
+			}
+			if !matchesFilter(details, f) {
+				continue
+			}
+			targets = append(targets, &manageTarget{input: s.Name, vm: &s, owner: owner})
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list servers")
+	}
+	log.Debugf("Filter %q selected %d VMs in project %s", filterStr, len(targets), projectName)
+	return targets, nil
+}
+
+// printSelection lists the VMs a filtered manage action would act on, with their age and owner
+func printSelection(action string, cfg Config, targets []*manageTarget) error {
+	selected := make([]SelectedVM, 0, len(targets))
+	out := &output.Result{
+		Headers: []string{"Name", "ID", "Status", "Age", "User Name", "Email"},
+		Empty:   "No VMs match the filter.",
+	}
+	for _, t := range targets {
+		vm := SelectedVM{
+			Name:     t.vm.Name,
+			ID:       t.vm.ID,
+			Status:   t.vm.Status,
+			Created:  t.vm.Created.UTC(),
+			Age:      formatDuration(time.Since(t.vm.Created)),
+			UserName: t.owner.Name,
+			Email:    t.owner.Email,
+		}
+		selected = append(selected, vm)
+		out.AddRow(vm.Name, vm.ID, vm.Status, vm.Age, vm.UserName, vm.Email)
+	}
+	out.Data = selected
+	if cfg.OutputFormat == "table" {
+		log.Infof("Dry run: %s would act on %d VM(s) in project %s matching %q", action, len(targets), cfg.Project, cfg.FilterStr)
+	}
+	if err := output.Print(cfg.OutputFormat, out); err != nil {
+		return errors.Wrap(err, "failed to print selected VMs")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// ServerState is the Nova state of a VM before set-state changed it
+type ServerState struct {
+	VMState    string `json:"vm_state"`
+	TaskState  string `json:"task_state"` // Empty when no task is in progress
+	PowerState string `json:"power_state"`
+	Locked     bool   `json:"locked"`
+}
+
+func captureState(vm *servers.Server) *ServerState {
+	s := &ServerState{VMState: vm.VmState, TaskState: vm.TaskState, PowerState: vm.PowerState.String()}
+	if vm.Locked != nil {
+		s.Locked = *vm.Locked
+	}
+	return s
+}
+
+func (s *ServerState) String() string {
+	task := s.TaskState
+	if task == "" {
+		task = "none"
+	}
+	return fmt.Sprintf("vm_state=%s, task_state=%s, power_state=%s, locked=%t", s.VMState, task, s.PowerState, s.Locked)
+}
+
+// checkServerStates prints the state of each VM set-state will change and returns those it may
+// change. A VM with a task in progress is skipped unless cfg.Force is set, because resetting it
+// races the task; a locked VM is only warned about.
+func checkServerStates(out io.Writer, cfg Config, targets []*manageTarget) []*manageTarget {
+	fmt.Fprintln(out, "Current state before set-state:")
+	var allowed []*manageTarget
+	for _, t := range targets {
+		t.prior = captureState(t.vm)
+		fmt.Fprintf(out, " - %s (ID: %s): %s\n", t.vm.Name, t.vm.ID, t.prior)
+		if t.prior.Locked {
+			log.Warnf("VM %s (ID: %s) is locked; someone may be working on it", t.vm.Name, t.vm.ID)
+		}
+		if t.prior.TaskState != "" && !cfg.Force {
+			t.result = &Result{
+				VMName:     t.input,
+				VMID:       t.vm.ID,
+				Status:     "skipped (task in progress)",
+				Message:    fmt.Sprintf("task %s in progress, use --force to reset anyway; before: %s", t.prior.TaskState, t.prior),
+				PriorState: t.prior,
+			}
+			continue
+		}
+		if t.prior.TaskState != "" {
+			log.Warnf("VM %s (ID: %s) has task %s in progress; resetting its state anyway (--force)", t.vm.Name, t.vm.ID, t.prior.TaskState)
+		}
+		allowed = append(allowed, t)
+	}
+	return allowed
+}
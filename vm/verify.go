@@ -0,0 +1,171 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// sshDialTimeout limits a single connection attempt of --verify-ssh; sshRetryDelay is the wait
+// between attempts
+const (
+	sshDialTimeout = 5 * time.Second
+	sshRetryDelay  = 5 * time.Second
+)
+
+// CreateResult is the outcome of vm create
+type CreateResult struct {
+	Name      string       `json:"name"`
+	ID        string       `json:"id"`
+	Status    string       `json:"status"`
+	IPAddress string       `json:"ip"`
+	ProjectID string       `json:"project_id"`
+	Verified  Verification `json:"verified"`
+}
+
+// Verification compares the created server with what was requested and, with --verify-ssh,
+// records whether port 22 of its address accepted a connection
+type Verification struct {
+	KeyPair        KeyPairCheck       `json:"key_pair"`
+	SecurityGroups SecurityGroupCheck `json:"security_groups"`
+	SSH            *SSHCheck          `json:"ssh,omitempty"`
+}
+
+// KeyPairCheck is the requested and the actual key pair of the server; "" means none
+type KeyPairCheck struct {
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Match    bool   `json:"match"`
+}
+
+// SecurityGroupCheck is the requested and the actual security groups of the server
+type SecurityGroupCheck struct {
+	Expected []string `json:"expected"`
+	Actual   []string `json:"actual"`
+	Match    bool     `json:"match"`
+}
+
+// SSHCheck is the result of the TCP reachability check of port 22
+type SSHCheck struct {
+	Address   string `json:"address"`
+	Reachable bool   `json:"reachable"`
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error,omitempty"`
+}
+
+// verifyServer checks the key pair and security groups of server against the request and
+// warns about each mismatch
+func verifyServer(server *servers.Server, keyPair string, securityGroups []string) Verification {
+	actualGroups := []string{}
+	seen := make(map[string]bool)
+	for _, sg := range server.SecurityGroups {
+		// Nova lists a group once per port, so a server with two ports has every group twice
+		if name, ok := sg["name"].(string); ok && !seen[name] {
+			seen[name] = true
+			actualGroups = append(actualGroups, name)
+		}
+	}
+	sort.Strings(actualGroups)
+	expectedGroups := append([]string{}, securityGroups...)
+	sort.Strings(expectedGroups)
+
+	v := Verification{
+		KeyPair:        KeyPairCheck{Expected: keyPair, Actual: server.KeyName, Match: keyPair == server.KeyName},
+		SecurityGroups: SecurityGroupCheck{Expected: expectedGroups, Actual: actualGroups, Match: strings.Join(expectedGroups, ",") == strings.Join(actualGroups, ",")},
+	}
+	if !v.KeyPair.Match {
+		log.Warnf("VM %s has key pair '%s', but '%s' was requested", server.Name, server.KeyName, keyPair)
+	}
+	if !v.SecurityGroups.Match {
+		log.Warnf("VM %s has security groups [%s], but [%s] were requested", server.Name, strings.Join(actualGroups, ", "), strings.Join(expectedGroups, ", "))
+	}
+	return v
+}
+
+// checkSSH dials port 22 of ip until a connection succeeds, timeout has passed or ctx ends,
+// writing retry notices to out.
+// Only the TCP handshake is tried, so no credentials are needed; a reachable port does not
+// mean the login works.
+func checkSSH(ctx context.Context, ip string, timeout time.Duration, out io.Writer) *SSHCheck {
+	check := &SSHCheck{Address: net.JoinHostPort(ip, "22")}
+	deadline := time.Now().Add(timeout)
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	for {
+		check.Attempts++
+		conn, err := dialer.DialContext(ctx, "tcp", check.Address)
+		if err == nil {
+			conn.Close()
+			check.Reachable = true
+			check.Error = ""
+			log.Debugf("Connected to %s after %d attempt(s)", check.Address, check.Attempts)
+			return check
+		}
+		check.Error = err.Error()
+		log.Debugf("SSH check attempt %d to %s failed: %v", check.Attempts, check.Address, err)
+		if time.Now().Add(sshRetryDelay).After(deadline) {
+			return check
+		}
+		fmt.Fprintf(out, "Port 22 of %s not reachable yet, retrying...\n", ip)
+		select {
+		case <-ctx.Done():
+			check.Error = ctx.Err().Error()
+			return check
+		case <-time.After(sshRetryDelay):
+		}
+	}
+}
+
+// printCreateResult prints the verification results; JSON and YAML give the whole result with
+// the checks under "verified"
+func printCreateResult(result CreateResult, outputFormat string) error {
+	if outputFormat == "table" {
+		fmt.Printf("IP ADDRESS IS: %s\n", noIPIfEmpty(result.IPAddress))
+	}
+	v := result.Verified
+	r := &output.Result{
+		Headers: []string{"Check", "Expected", "Actual", "Result"},
+		Data:    result,
+	}
+	r.AddRow("Key pair", noneIfEmpty(v.KeyPair.Expected), noneIfEmpty(v.KeyPair.Actual), matchLabel(v.KeyPair.Match))
+	r.AddRow("Security groups", noneIfEmpty(strings.Join(v.SecurityGroups.Expected, ", ")), noneIfEmpty(strings.Join(v.SecurityGroups.Actual, ", ")), matchLabel(v.SecurityGroups.Match))
+	if v.SSH != nil {
+		outcome := "reachable"
+		if !v.SSH.Reachable {
+			outcome = "unreachable: " + v.SSH.Error
+		}
+		r.AddRow("SSH", v.SSH.Address, fmt.Sprintf("%d attempt(s)", v.SSH.Attempts), outcome)
+	}
+	if err := output.Print(outputFormat, r); err != nil {
+		return errors.Wrap(err, "failed to print create result")
+	}
+	return nil
+}
+
+func noIPIfEmpty(ip string) string {
+	if ip == "" {
+		return "no IP assigned yet"
+	}
+	return ip
+}
+
+func noneIfEmpty(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func matchLabel(match bool) string {
+	if match {
+		return "ok"
+	}
+	return "mismatch"
+}
@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/util"
+)
+
+// waitPollInterval is the time between two status checks of a VM with --wait
+const waitPollInterval = 5 * time.Second
+
+// waitForState polls vm after action until it reaches the status a dry run plans for the
+// action with no task in progress, is gone after a delete, or goes to ERROR. Each VM is
+// bounded by cfg.WaitTimeout when set, and always by ctx; a VM that does not get there in
+// time is marked "timeout" so the rest of the batch is not held up.
+func waitForState(ctx context.Context, client *auth.Client, action string, cfg Config, vm *servers.Server, result *Result) {
+	t, ok := transitions[action]
+	if action == "set-state" {
+		t, ok = setStateTransition(cfg.State), true
+	}
+	if !ok {
+		return
+	}
+	waitCtx := ctx
+	if cfg.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, cfg.WaitTimeout)
+		defer cancel()
+	}
+	last := strings.ToUpper(vm.Status)
+	for {
+		server, err := servers.Get(waitCtx, client.Compute, vm.ID).Extract()
+		switch {
+		case t.to == "DELETED" && gophercloud.ResponseCodeIs(err, http.StatusNotFound):
+			result.Message += "; VM is gone"
+			return
+		case err != nil && waitCtx.Err() == nil:
+			log.Warnf("Failed to check the status of VM %s (ID: %s), retrying: %v", vm.Name, vm.ID, err)
+		case err == nil:
+			last = strings.ToUpper(server.Status)
+			if last == t.to && server.TaskState == "" {
+				result.Message += fmt.Sprintf("; now %s", last)
+				return
+			}
+			if last == "ERROR" && t.to != "ERROR" {
+				result.Status = "error"
+				result.Message += "; VM went to ERROR"
+				return
+			}
+			log.Debugf("VM %s (ID: %s) is %s (task: %q), waiting for %s", vm.Name, vm.ID, last, server.TaskState, t.to)
+		}
+		if util.Sleep(waitCtx, waitPollInterval) != nil {
+			break
+		}
+	}
+	result.Status = "timeout"
+	if ctx.Err() != nil {
+		result.Message += fmt.Sprintf("; still %s when the command timed out, not %s", last, t.to)
+		return
+	}
+	log.Warnf("VM %s (ID: %s) did not reach %s within %s", vm.Name, vm.ID, t.to, cfg.WaitTimeout)
+	result.Message += fmt.Sprintf("; still %s after %s, not %s", last, cfg.WaitTimeout, t.to)
+}
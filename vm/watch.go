@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sudeeshjohn/openstack-tool/auth"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// watchInfo runs vm info every cfg.WatchInterval until ctx is cancelled, for example by
+// Ctrl-C. Each run gets its own cfg.Timeout. A failed run is reported on stderr and the
+// watch goes on, so a transient API error does not end it.
+func watchInfo(ctx context.Context, client *auth.Client, cfg Config) error {
+	if cfg.WatchInterval <= 0 {
+		return fmt.Errorf("invalid watch interval %v: must be positive", cfg.WatchInterval)
+	}
+	// Clearing only makes sense for a table on a terminal; piped output keeps every run
+	clear := cfg.OutputFormat == "table" && isTerminal(os.Stdout)
+	for {
+		if clear {
+			fmt.Print(clearScreen)
+		}
+		if cfg.OutputFormat == "table" {
+			fmt.Printf("Every %s: vm info (%s)\n\n", cfg.WatchInterval, time.Now().Format(time.RFC3339))
+		}
+		runCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err := runInfo(runCtx, client, cfg)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cfg.WatchInterval):
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,217 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// AttachmentResult reports an in-use volume whose attachment references a server Nova no longer knows
+type AttachmentResult struct {
+	VolumeName   string `json:"volume_name"`
+	VolumeID     string `json:"volume_id"`
+	Status       string `json:"status"`
+	AttachmentID string `json:"attachment_id"`
+	ServerID     string `json:"server_id"`
+	Outcome      string `json:"outcome"`
+	Message      string `json:"message"`
+}
+
+// serverExistsCache remembers which server IDs Nova knows so each is looked up once
+type serverExistsCache struct {
+	mu     sync.Mutex
+	exists map[string]bool
+}
+
+func (c *serverExistsCache) check(ctx context.Context, computeClient *gophercloud.ServiceClient, serverID string) (bool, error) {
+	c.mu.Lock()
+	exists, ok := c.exists[serverID]
+	c.mu.Unlock()
+	if ok {
+		return exists, nil
+	}
+	_, err := servers.Get(ctx, computeClient, serverID).Extract()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return false, err
+	}
+	exists = err == nil
+	c.mu.Lock()
+	c.exists[serverID] = exists
+	c.mu.Unlock()
+	return exists, nil
+}
+
+// auditAttachments reports in-use volumes attached to servers that no longer exist and, with
+// cfg.Fix, force-detaches them and resets them to available after a typed confirmation or cfg.Yes
+func auditAttachments(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, cfg Config) error {
+	if cfg.Fix && !cfg.DryRun && !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for audit-attachments --fix; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
+	tenantIDs, err := auditProjectIDs(ctx, authClient, cfg)
+	if err != nil {
+		return err
+	}
+
+	var inUse []volumes.Volume
+	for _, tenantID := range tenantIDs {
+		listOpts := volumes.ListOpts{
+			AllTenants: true,
+			TenantID:   tenantID,
+			Status:     "in-use",
+		}
+		err := volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+			vols, err := volumes.ExtractVolumes(page)
+			if err != nil {
+				return false, err
+			}
+			inUse = append(inUse, vols...)
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list in-use volumes")
+		}
+	}
+	log.Debugf("Auditing attachments of %d in-use volumes", len(inUse))
+
+	cache := &serverExistsCache{exists: make(map[string]bool)}
+	results := []AttachmentResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for _, vol := range inUse {
+		wg.Add(1)
+		go func(vol volumes.Volume) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, att := range vol.Attachments {
+				exists, err := cache.check(ctx, authClient.Compute, att.ServerID)
+				if err != nil {
+					log.Warnf("Failed to look up server %s for volume %s: %v", att.ServerID, vol.ID, err)
+					continue
+				}
+				if exists {
+					continue
+				}
+				mu.Lock()
+				results = append(results, AttachmentResult{
+					VolumeName:   vol.Name,
+					VolumeID:     vol.ID,
+					Status:       vol.Status,
+					AttachmentID: att.AttachmentID,
+					ServerID:     att.ServerID,
+					Outcome:      "dangling",
+					Message:      "server not found in Nova",
+				})
+				mu.Unlock()
+			}
+		}(vol)
+	}
+	wg.Wait()
+
+	if cfg.Fix && len(results) > 0 {
+		if cfg.DryRun {
+			for i := range results {
+				results[i].Outcome = "dry-run"
+				results[i].Message = "would force-detach and reset status to available"
+			}
+		} else if cfg.Yes || confirmFix(len(results)) {
+			for i := range results {
+				fixAttachment(ctx, volumeClient, &results[i])
+			}
+		} else {
+			log.Info("Fix aborted by user; no volumes were changed")
+		}
+	}
+
+	result := &output.Result{
+		Headers: []string{"Volume", "Volume ID", "Status", "Dangling Server ID", "Outcome", "Message"},
+		Data: struct {
+			VolumesChecked int                `json:"volumes_checked"`
+			Inconsistent   []AttachmentResult `json:"inconsistent"`
+		}{len(inUse), results},
+		Empty: fmt.Sprintf("✅ No dangling attachments found (%d in-use volumes checked)", len(inUse)),
+	}
+	for _, r := range results {
+		result.AddRow(r.VolumeName, r.VolumeID, r.Status, r.ServerID, r.Outcome, r.Message)
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print audit results")
+	}
+	if cfg.OutputFormat == "table" && len(results) > 0 {
+		fmt.Printf("Inconsistent attachments: %d (%d in-use volumes checked)\n", len(results), len(inUse))
+	}
+	return nil
+}
+
+// auditProjectIDs returns the IDs of the projects named by the comma-separated cfg.ProjectName,
+// each once, or a single empty ID, which lists all projects, with cfg.AllProjects
+func auditProjectIDs(ctx context.Context, authClient *auth.Client, cfg Config) ([]string, error) {
+	if cfg.AllProjects {
+		return []string{""}, nil
+	}
+	names := splitProjects(cfg.ProjectName)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("project name must be provided via --project or OS_PROJECT_NAME, or use --all-projects")
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		id, err := getProjectID(ctx, authClient, name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func confirmFix(count int) bool {
+	fmt.Printf("About to force-detach %d dangling attachment(s) and reset those volumes to available. Type 'confirm' to continue: ", count)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "confirm"
+}
+
+// fixAttachment runs the os-force_detach / os-reset_status sequence for one dangling attachment
+func fixAttachment(ctx context.Context, volumeClient *gophercloud.ServiceClient, r *AttachmentResult) {
+	err := volumeAction(ctx, volumeClient, r.VolumeID, map[string]interface{}{
+		"os-force_detach": map[string]interface{}{
+			"attachment_id": r.AttachmentID,
+			"connector":     nil,
+		},
+	})
+	if err != nil {
+		log.Debugf("os-force_detach failed for volume %s: %v", r.VolumeID, err)
+		r.Outcome = "fix-failed"
+		r.Message = fmt.Sprintf("force detach: %v", err)
+		return
+	}
+	err = volumeAction(ctx, volumeClient, r.VolumeID, map[string]interface{}{
+		"os-reset_status": map[string]string{
+			"status":        "available",
+			"attach_status": "detached",
+		},
+	})
+	if err != nil {
+		log.Debugf("os-reset_status failed for volume %s: %v", r.VolumeID, err)
+		r.Outcome = "fix-failed"
+		r.Message = fmt.Sprintf("detached, but reset status: %v", err)
+		return
+	}
+	r.Outcome = "fixed"
+	r.Message = "force-detached and reset to available"
+}
@@ -0,0 +1,231 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// ForceDeleteStep is one Cinder action of delete --force: an os-force_detach of a stale
+// attachment or the final os-force_delete of the volume
+type ForceDeleteStep struct {
+	VolumeName   string `json:"volume_name"`
+	VolumeID     string `json:"volume_id"`
+	Action       string `json:"action"` // os-force_detach or os-force_delete
+	AttachmentID string `json:"attachment_id,omitempty"`
+	ServerID     string `json:"server_id,omitempty"`
+	Outcome      string `json:"outcome"`
+	Message      string `json:"message"`
+}
+
+// forceDeletePlan is the sequence of steps for one volume; blocked is set when the volume is
+// attached to a server that still exists, which delete --force refuses to break
+type forceDeletePlan struct {
+	steps   []ForceDeleteStep
+	blocked string
+}
+
+// forceDeleteVolumes deletes the named volumes with delete --force: each stale attachment (to a
+// server Nova no longer knows) is force-detached and the volume is then force-deleted. This
+// bypasses Cinder's state checks, so it needs a typed confirmation or cfg.Yes; with cfg.DryRun
+// the steps are only printed.
+func forceDeleteVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, projectName string, cfg Config) error {
+	if !cfg.DryRun && !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for delete --force; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
+	projectID, err := getProjectID(ctx, authClient, projectName)
+	if err != nil {
+		return err
+	}
+
+	imageClient, err := auth.NewImageV2(authClient)
+	if err != nil {
+		log.Warnf("Failed to initialize image client: %v, not checking whether volumes back images", err)
+	}
+	var imageCache sync.Map
+	cache := &serverExistsCache{exists: make(map[string]bool)}
+	var plans []*forceDeletePlan
+	for _, volumeName := range strings.Split(cfg.VolumeNames, ",") {
+		volumeName = strings.TrimSpace(volumeName)
+		if volumeName == "" {
+			continue
+		}
+		vol, err := findVolume(ctx, volumeClient, volumeName, projectID)
+		if err != nil {
+			return err
+		}
+		if vol == nil {
+			if cfg.FailFast {
+				return fmt.Errorf("volume %s not found in project %s", volumeName, projectName)
+			}
+			log.Warnf("Volume %s not found in project %s", volumeName, projectName)
+			continue
+		}
+		plan, err := planForceDelete(ctx, authClient, cache, vol)
+		if err != nil {
+			return err
+		}
+		// --force overrides the image check of a normal delete, but the result still says so
+		if imageClient != nil {
+			if imageName, err := getAssociatedImageName(ctx, imageClient, vol.ID, &imageCache); err != nil {
+				log.Warnf("Failed to check images using volume %s: %v", vol.ID, err)
+			} else if imageName != "N/A" {
+				last := &plan.steps[len(plan.steps)-1]
+				last.Message += fmt.Sprintf("; backs image %s", imageName)
+			}
+		}
+		plans = append(plans, plan)
+	}
+
+	var firstErr error
+	failed := 0
+	switch {
+	case len(plans) == 0:
+	case cfg.DryRun:
+		for _, plan := range plans {
+			for i := range plan.steps {
+				plan.steps[i].Outcome = "dry-run"
+				if plan.blocked != "" {
+					plan.steps[i].Outcome = "blocked"
+					plan.steps[i].Message = plan.blocked
+				}
+			}
+		}
+	case !cfg.Yes && !confirmForceDelete(plans):
+		log.Info("Force delete aborted by user; no volumes were changed")
+		return nil
+	default:
+		for _, plan := range plans {
+			if firstErr != nil && cfg.FailFast {
+				markSkipped(plan.steps, "skipped: an earlier volume failed (--fail-fast)")
+				continue
+			}
+			if err := runForceDelete(ctx, volumeClient, plan); err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				if !cfg.FailFast {
+					log.Warnf("Force delete failed: %v", err)
+				}
+			}
+		}
+	}
+
+	var steps []ForceDeleteStep
+	for _, plan := range plans {
+		steps = append(steps, plan.steps...)
+	}
+	result := &output.Result{
+		Headers: []string{"Volume", "Volume ID", "Action", "Attachment ID", "Server ID", "Outcome", "Message"},
+		Data:    steps,
+		Empty:   "No volumes to force-delete.",
+	}
+	for _, s := range steps {
+		result.AddRow(s.VolumeName, s.VolumeID, s.Action, s.AttachmentID, s.ServerID, s.Outcome, s.Message)
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print force-delete results")
+	}
+	if failed > 0 {
+		return fmt.Errorf("force delete failed for %d of %d volume(s); first error: %w", failed, len(plans), firstErr)
+	}
+	return nil
+}
+
+// planForceDelete lists the steps delete --force runs for vol, in order
+func planForceDelete(ctx context.Context, authClient *auth.Client, cache *serverExistsCache, vol *volumes.Volume) (*forceDeletePlan, error) {
+	plan := &forceDeletePlan{}
+	for _, att := range vol.Attachments {
+		exists, err := cache.check(ctx, authClient.Compute, att.ServerID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to look up server %s of volume %s", att.ServerID, vol.Name)
+		}
+		if exists {
+			plan.blocked = fmt.Sprintf("attached to existing server %s; detach it from the server first", att.ServerID)
+			continue
+		}
+		plan.steps = append(plan.steps, ForceDeleteStep{
+			VolumeName:   vol.Name,
+			VolumeID:     vol.ID,
+			Action:       "os-force_detach",
+			AttachmentID: att.AttachmentID,
+			ServerID:     att.ServerID,
+			Message:      "server not found in Nova",
+		})
+	}
+	plan.steps = append(plan.steps, ForceDeleteStep{
+		VolumeName: vol.Name,
+		VolumeID:   vol.ID,
+		Action:     "os-force_delete",
+		Message:    fmt.Sprintf("status %s", vol.Status),
+	})
+	return plan, nil
+}
+
+// runForceDelete runs the steps of one plan, skipping the rest after the first failure
+func runForceDelete(ctx context.Context, volumeClient *gophercloud.ServiceClient, plan *forceDeletePlan) error {
+	if plan.blocked != "" {
+		markSkipped(plan.steps, plan.blocked)
+		return fmt.Errorf("volume %s: %s", plan.steps[0].VolumeName, plan.blocked)
+	}
+	for i := range plan.steps {
+		s := &plan.steps[i]
+		var err error
+		if s.Action == "os-force_detach" {
+			err = volumeAction(ctx, volumeClient, s.VolumeID, map[string]interface{}{
+				"os-force_detach": map[string]interface{}{
+					"attachment_id": s.AttachmentID,
+					"connector":     nil,
+				},
+			})
+		} else {
+			err = volumes.ForceDelete(ctx, volumeClient, s.VolumeID).ExtractErr()
+		}
+		if err != nil {
+			log.Debugf("%s failed for volume %s: %v", s.Action, s.VolumeID, err)
+			s.Outcome = "failed"
+			s.Message = err.Error()
+			markSkipped(plan.steps[i+1:], fmt.Sprintf("skipped: %s failed", s.Action))
+			return errors.Wrapf(err, "%s of volume %s", s.Action, s.VolumeName)
+		}
+		s.Outcome = "done"
+		log.Infof("%s of volume %s (ID: %s) succeeded", s.Action, s.VolumeName, s.VolumeID)
+	}
+	return nil
+}
+
+func markSkipped(steps []ForceDeleteStep, message string) {
+	for i := range steps {
+		steps[i].Outcome = "skipped"
+		steps[i].Message = message
+	}
+}
+
+// confirmForceDelete asks before running plans; blocked volumes are left out of the counts as
+// they are never touched
+func confirmForceDelete(plans []*forceDeletePlan) bool {
+	fmt.Print(forceDeletePrompt(plans))
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "confirm"
+}
+
+func forceDeletePrompt(plans []*forceDeletePlan) string {
+	detaches, deletes := 0, 0
+	for _, plan := range plans {
+		if plan.blocked != "" {
+			continue
+		}
+		detaches += len(plan.steps) - 1
+		deletes++
+	}
+	return fmt.Sprintf("About to force-detach %d stale attachment(s) and force-delete %d volume(s), bypassing Cinder safety checks. Type 'confirm' to continue: ", detaches, deletes)
+}
@@ -0,0 +1,95 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+)
+
+// newFakeForceDeleteCloud serves project "demo" with the unattached volume "data-1" and the
+// volume "data-2", which is attached to the existing server s1; force-deleting v1 succeeds
+func newFakeForceDeleteCloud(t *testing.T) (*auth.Client, *gophercloud.ServiceClient) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/v3/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"projects": [{"id": "p1", "name": "demo"}], "links": {}}`)
+	})
+	mux.HandleFunc("/volume/v3/volumes/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("name") {
+		case "data-1":
+			fmt.Fprint(w, `{"volumes": [{"id": "v1", "name": "data-1", "status": "error", "size": 10}]}`)
+		case "data-2":
+			fmt.Fprint(w, `{"volumes": [{"id": "v2", "name": "data-2", "status": "in-use", "size": 10,
+				"attachments": [{"attachment_id": "a1", "server_id": "s1"}]}]}`)
+		default:
+			fmt.Fprint(w, `{"volumes": []}`)
+		}
+	})
+	mux.HandleFunc("/volume/v3/volumes/v1/action", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/compute/servers/s1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"server": {"id": "s1", "name": "web-1"}}`)
+	})
+	mux.HandleFunc("/v2/images", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"images": []}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	authClient := &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+		Compute:  &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/compute/"},
+		Image:    &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/"},
+	}
+	return authClient, &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/volume/v3/"}
+}
+
+func TestForceDeleteVolumesBlocked(t *testing.T) {
+	t.Setenv("OS_PROJECT_NAME", "")
+	authClient, volumeClient := newFakeForceDeleteCloud(t)
+	cfg := Config{ProjectName: "demo", VolumeNames: "data-1,data-2", Yes: true, OutputFormat: "json"}
+	var err error
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		err = forceDeleteVolumes(context.Background(), authClient, volumeClient, "demo", cfg)
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 volume(s)") {
+		t.Errorf("got error %v, want force delete failed for 1 of 2 volume(s)", err)
+	}
+	var steps []ForceDeleteStep
+	if err := json.Unmarshal([]byte(stdout), &steps); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	outcomes := make(map[string]string)
+	for _, s := range steps {
+		outcomes[s.VolumeName] = s.Outcome
+	}
+	if outcomes["data-1"] != "done" || outcomes["data-2"] != "skipped" {
+		t.Errorf("got outcomes %v, want data-1 done and data-2 skipped", outcomes)
+	}
+}
+
+func TestForceDeletePrompt(t *testing.T) {
+	plans := []*forceDeletePlan{
+		{steps: []ForceDeleteStep{{Action: "os-force_detach"}, {Action: "os-force_delete"}}},
+		{steps: []ForceDeleteStep{{Action: "os-force_delete"}}},
+		{steps: []ForceDeleteStep{{Action: "os-force_detach"}, {Action: "os-force_delete"}}, blocked: "attached to existing server s1"},
+	}
+	want := "About to force-detach 1 stale attachment(s) and force-delete 2 volume(s)"
+	if got := forceDeletePrompt(plans); !strings.HasPrefix(got, want) {
+		t.Errorf("got prompt %q, want it to start with %q", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package volume
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// ProjectGroup is the chargeback line of one project in list-all --group-by=project
+type ProjectGroup struct {
+	Volumes    int `json:"volumes"`
+	SizeGB     int `json:"size_gb"`
+	Unattached int `json:"unattached"`
+}
+
+// groupByProject sums the volumes of each project by project name, the name coming from the
+// project cache of listAllVolumes ("Unknown" when it could not be resolved)
+func groupByProject(details []VolumeDetails) map[string]ProjectGroup {
+	groups := make(map[string]ProjectGroup)
+	for _, d := range details {
+		g := groups[d.ProjectName]
+		g.Volumes++
+		g.SizeGB += d.Size
+		if d.AttachedTo == "" {
+			g.Unattached++
+		}
+		groups[d.ProjectName] = g
+	}
+	return groups
+}
+
+// printProjectGroups prints one row per project, sorted by name, and a total line after the
+// table. JSON and YAML get the groups as a map keyed by project name.
+func printProjectGroups(groups map[string]ProjectGroup, outputFormat string) error {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &output.Result{
+		Headers: []string{"Project", "Volumes", "Size (GB)", "Unattached"},
+		Data:    groups,
+		Empty:   "No volumes found.",
+	}
+	var total ProjectGroup
+	for _, name := range names {
+		g := groups[name]
+		result.AddRow(name, g.Volumes, g.SizeGB, g.Unattached)
+		total.Volumes += g.Volumes
+		total.SizeGB += g.SizeGB
+		total.Unattached += g.Unattached
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print volume groups")
+	}
+	if outputFormat == "table" && len(groups) > 0 {
+		fmt.Printf("\nTotal: %d volumes, %d GB, %d unattached in %d projects\n", total.Volumes, total.SizeGB, total.Unattached, len(groups))
+	}
+	return nil
+}
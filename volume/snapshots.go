@@ -0,0 +1,236 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/snapshots"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"github.com/pkg/errors"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// OrphanSnapshot reports a snapshot whose source volume no longer exists
+type OrphanSnapshot struct {
+	SnapshotName string    `json:"snapshot_name"`
+	SnapshotID   string    `json:"snapshot_id"`
+	VolumeID     string    `json:"volume_id"` // The deleted source volume
+	Project      string    `json:"project"`
+	SizeGB       int       `json:"size_gb"`
+	Created      time.Time `json:"created"` // UTC
+	Age          string    `json:"age"`
+	Outcome      string    `json:"outcome"`
+	Message      string    `json:"message"`
+}
+
+// ProjectSnapshotTotals sums the orphaned snapshots of one project
+type ProjectSnapshotTotals struct {
+	Project   string `json:"project"`
+	Snapshots int    `json:"snapshots"`
+	SizeGB    int    `json:"size_gb"`
+}
+
+// volumeExistsCache remembers which volume IDs Cinder knows so each is looked up once
+type volumeExistsCache struct {
+	mu     sync.Mutex
+	exists map[string]bool
+}
+
+func (c *volumeExistsCache) check(ctx context.Context, volumeClient *gophercloud.ServiceClient, volumeID string) (bool, error) {
+	c.mu.Lock()
+	exists, ok := c.exists[volumeID]
+	c.mu.Unlock()
+	if ok {
+		return exists, nil
+	}
+	_, err := volumes.Get(ctx, volumeClient, volumeID).Extract()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return false, err
+	}
+	exists = err == nil
+	c.mu.Lock()
+	c.exists[volumeID] = exists
+	c.mu.Unlock()
+	return exists, nil
+}
+
+// reportOrphanSnapshots reports snapshots whose source volume is gone, with totals per project,
+// and with cfg.Delete deletes them after a typed confirmation or cfg.Yes
+func reportOrphanSnapshots(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, cfg Config) error {
+	if cfg.Delete && !cfg.DryRun && !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for snapshot report-orphans --delete; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
+	tenantIDs, err := auditProjectIDs(ctx, authClient, cfg)
+	if err != nil {
+		return err
+	}
+
+	var allSnapshots []snapshots.Snapshot
+	for _, tenantID := range tenantIDs {
+		listOpts := snapshots.ListOpts{AllTenants: true, TenantID: tenantID}
+		err := snapshots.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+			snaps, err := snapshots.ExtractSnapshots(page)
+			if err != nil {
+				return false, err
+			}
+			allSnapshots = append(allSnapshots, snaps...)
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to list snapshots")
+		}
+	}
+	log.Debugf("Checking source volumes of %d snapshots", len(allSnapshots))
+
+	cache := &volumeExistsCache{exists: make(map[string]bool)}
+	var orphans []snapshots.Snapshot
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for _, snap := range allSnapshots {
+		wg.Add(1)
+		go func(snap snapshots.Snapshot) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			exists, err := cache.check(ctx, volumeClient, snap.VolumeID)
+			if err != nil {
+				log.Warnf("Failed to look up volume %s of snapshot %s: %v", snap.VolumeID, snap.ID, err)
+				return
+			}
+			if exists {
+				return
+			}
+			mu.Lock()
+			orphans = append(orphans, snap)
+			mu.Unlock()
+		}(snap)
+	}
+	wg.Wait()
+
+	projectNames := make(map[string]string)
+	results := []OrphanSnapshot{}
+	for _, snap := range orphans {
+		name, ok := projectNames[snap.ProjectID]
+		if !ok {
+			name = snap.ProjectID
+			if project, err := projects.Get(ctx, authClient.Identity, snap.ProjectID).Extract(); err == nil {
+				name = project.Name
+			} else {
+				log.Warnf("Failed to get project name for ID %s: %v", snap.ProjectID, err)
+			}
+			projectNames[snap.ProjectID] = name
+		}
+		results = append(results, OrphanSnapshot{
+			SnapshotName: snap.Name,
+			SnapshotID:   snap.ID,
+			VolumeID:     snap.VolumeID,
+			Project:      name,
+			SizeGB:       snap.Size,
+			Created:      snap.CreatedAt.UTC(),
+			Age:          fmt.Sprintf("%dd", int(time.Since(snap.CreatedAt).Hours()/24)),
+			Outcome:      "orphaned",
+			Message:      "source volume not found",
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Project != results[j].Project {
+			return results[i].Project < results[j].Project
+		}
+		return results[i].Created.Before(results[j].Created)
+	})
+
+	if cfg.Delete && len(results) > 0 {
+		if cfg.DryRun {
+			for i := range results {
+				results[i].Outcome = "dry-run"
+				results[i].Message = "would delete"
+			}
+		} else if cfg.Yes || confirmSnapshotDelete(results) {
+			for i := range results {
+				deleteOrphanSnapshot(ctx, volumeClient, &results[i])
+			}
+		} else {
+			log.Info("Delete aborted by user; no snapshots were deleted")
+		}
+	}
+
+	totals := sumOrphanSnapshots(results)
+	result := &output.Result{
+		Headers: []string{"Snapshot", "Snapshot ID", "Deleted Volume ID", "Project", "Size (GB)", "Age", "Outcome", "Message"},
+		Data: struct {
+			SnapshotsChecked int                     `json:"snapshots_checked"`
+			Orphans          []OrphanSnapshot        `json:"orphans"`
+			Totals           []ProjectSnapshotTotals `json:"totals"`
+		}{len(allSnapshots), results, totals},
+		Empty: fmt.Sprintf("✅ No orphaned snapshots found (%d snapshots checked)", len(allSnapshots)),
+	}
+	for _, r := range results {
+		result.AddRow(r.SnapshotName, r.SnapshotID, r.VolumeID, r.Project, r.SizeGB, r.Age, r.Outcome, r.Message)
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print orphaned snapshots")
+	}
+	if cfg.OutputFormat == "table" && len(results) > 0 {
+		fmt.Println()
+		for _, t := range totals {
+			fmt.Printf("Project %s: %d orphaned snapshot(s), %d GB\n", t.Project, t.Snapshots, t.SizeGB)
+		}
+		fmt.Printf("Orphaned snapshots: %d (%d snapshots checked)\n", len(results), len(allSnapshots))
+	}
+	return nil
+}
+
+// sumOrphanSnapshots returns the snapshot count and size of each project, sorted by project
+func sumOrphanSnapshots(results []OrphanSnapshot) []ProjectSnapshotTotals {
+	byProject := make(map[string]*ProjectSnapshotTotals)
+	totals := []ProjectSnapshotTotals{}
+	var order []string
+	for _, r := range results {
+		t, ok := byProject[r.Project]
+		if !ok {
+			t = &ProjectSnapshotTotals{Project: r.Project}
+			byProject[r.Project] = t
+			order = append(order, r.Project)
+		}
+		t.Snapshots++
+		t.SizeGB += r.SizeGB
+	}
+	sort.Strings(order)
+	for _, p := range order {
+		totals = append(totals, *byProject[p])
+	}
+	return totals
+}
+
+func confirmSnapshotDelete(results []OrphanSnapshot) bool {
+	size := 0
+	for _, r := range results {
+		size += r.SizeGB
+	}
+	fmt.Printf("About to delete %d orphaned snapshot(s) totalling %d GB. Type 'confirm' to continue: ", len(results), size)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "confirm"
+}
+
+// deleteOrphanSnapshot deletes one orphaned snapshot and records the outcome
+func deleteOrphanSnapshot(ctx context.Context, volumeClient *gophercloud.ServiceClient, r *OrphanSnapshot) {
+	if err := snapshots.Delete(ctx, volumeClient, r.SnapshotID).ExtractErr(); err != nil {
+		log.Debugf("Failed to delete snapshot %s: %v", r.SnapshotID, err)
+		r.Outcome = "delete-failed"
+		r.Message = err.Error()
+		return
+	}
+	r.Outcome = "deleted"
+	r.Message = "deletion requested"
+}
@@ -6,9 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
-	"text/tabwriter"
+	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack"
@@ -20,17 +21,68 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/output"
+	"github.com/sudeeshjohn/openstack-tool/util"
 )
 
 // Logger for structured logging
 var log = logrus.New()
 
+// Config holds configuration parameters for the volume module
+type Config struct {
+	Verbose         bool
+	OutputFormat    string
+	Subcommand      string
+	VolumeNames     string // Comma-separated volume names
+	ProjectName     string // Comma-separated project names for list
+	Status          string // Target status for change-status
+	Long            bool
+	NotAssociated   bool
+	AttachedOnly    bool   // list, list-all: only volumes attached to a server, regardless of image
+	UnattachedOnly  bool   // list, list-all: only volumes not attached to a server, regardless of image
+	Summary         bool   // list, list-all: print volume count and size totals
+	GroupBy         string // list-all: "project" prints per-project totals instead of the volumes
+	ShowAssociation bool   // list, list-all with Long: add a column naming what the volume is associated with
+	Force           bool   // Allow change-status to a status outside validStatuses; delete: force-detach stale attachments and force-delete
+	Yes             bool   // change-status, delete --force, audit-attachments --fix, report-orphans --delete: skip the confirmation prompt
+	DryRun          bool
+	MaxResults      int    // Stop list-all pagination after this many volumes (0 for no cap)
+	AllProjects     bool   // audit-attachments: check volumes in every project
+	Fix             bool   // audit-attachments: force-detach dangling attachments after confirmation
+	Delete          bool   // snapshot report-orphans: delete orphaned snapshots after confirmation
+	Strict          bool   // list with several projects: fail when any project cannot be listed
+	FailFast        bool   // delete, change-status: stop at the first volume that fails and return its error
+	SkipImageCheck  bool   // list, list-all with NotAssociated: go on without image names when the image client fails
+	AZ              string // list, list-all: only volumes in this availability zone
+	Backend         string // list, list-all: only volumes whose backend host contains this
+}
+
+// projectListConcurrency bounds the projects listed at once by list with several projects
+const projectListConcurrency = 4
+
+// ErrStatusChangeFailed is returned by change-status when at least one volume was not found or
+// could not be changed
+var ErrStatusChangeFailed = errors.New("volume status could not be changed")
+
+// validStatuses are the Cinder volume statuses change-status accepts without --force
+var validStatuses = []string{"available", "in-use", "error", "error_deleting", "maintenance", "reserved", "detaching", "attaching"}
+
+// StatusResult holds the outcome of a status change for one volume
+type StatusResult struct {
+	VolumeName    string `json:"volume_name"`
+	VolumeID      string `json:"volume_id"`
+	CurrentStatus string `json:"current_status"`
+	TargetStatus  string `json:"target_status"`
+	Result        string `json:"result"`
+	Message       string `json:"message"`
+}
+
 // Run executes the volume management logic
-func Run(ctx context.Context, client *auth.Client, verbose bool, outputFormat, subcommand, volumeNames, projectName, status string, long, notAssociated bool) error {
-	log.SetOutput(os.Stdout)
-	log.SetLevel(logrus.InfoLevel)
-	if verbose {
-		log.SetLevel(logrus.DebugLevel)
+func Run(ctx context.Context, client *auth.Client, cfg Config) error {
+	util.SetupLogger(log, cfg.Verbose)
+
+	if cfg.Subcommand == "change-status" && !cfg.Force && !isValidStatus(cfg.Status) {
+		return fmt.Errorf("invalid status '%s'; valid: %s (use --force to set other statuses)", cfg.Status, strings.Join(validStatuses, ", "))
 	}
 
 	// Initialize block storage client
@@ -39,24 +91,49 @@ func Run(ctx context.Context, client *auth.Client, verbose bool, outputFormat, s
 		return errors.Wrap(err, "failed to initialize block storage client")
 	}
 
-	switch subcommand {
+	projectName := cfg.ProjectName
+	switch cfg.Subcommand {
 	case "list":
 		// Use projectName from flag or OS_PROJECT_NAME
 		if projectName == "" {
 			projectName = os.Getenv("OS_PROJECT_NAME")
 		}
-		return listVolumes(ctx, client, volumeClient, projectName, outputFormat, long, notAssociated)
+		return listVolumes(ctx, client, volumeClient, splitProjects(projectName), cfg.OutputFormat, cfg.Long, cfg.listFilter(), cfg.Summary, cfg.ShowAssociation, cfg.Strict)
 	case "list-all":
-		return listAllVolumes(ctx, volumeClient, client, outputFormat, long, notAssociated)
+		return listAllVolumes(ctx, volumeClient, client, cfg.OutputFormat, cfg.Long, cfg.listFilter(), cfg.MaxResults, cfg.Summary, cfg.ShowAssociation, cfg.GroupBy)
 	case "change-status":
-		return changeVolumeStatus(ctx, client, volumeClient, volumeNames, projectName, status)
+		return changeVolumeStatus(ctx, client, volumeClient, cfg)
 	case "delete":
-		return deleteVolumes(ctx, client, volumeClient, volumeNames, projectName)
+		if cfg.Force {
+			return forceDeleteVolumes(ctx, client, volumeClient, projectName, cfg)
+		}
+		return deleteVolumes(ctx, client, volumeClient, cfg.VolumeNames, projectName, cfg.OutputFormat, cfg.FailFast)
+	case "audit-attachments":
+		if projectName == "" && !cfg.AllProjects {
+			projectName = os.Getenv("OS_PROJECT_NAME")
+		}
+		cfg.ProjectName = projectName
+		return auditAttachments(ctx, client, volumeClient, cfg)
+	case "snapshot report-orphans":
+		if projectName == "" && !cfg.AllProjects {
+			projectName = os.Getenv("OS_PROJECT_NAME")
+		}
+		cfg.ProjectName = projectName
+		return reportOrphanSnapshots(ctx, client, volumeClient, cfg)
 	default:
-		return fmt.Errorf("unsupported subcommand: %s", subcommand)
+		return fmt.Errorf("unsupported subcommand: %s", cfg.Subcommand)
 	}
 }
 
+func isValidStatus(status string) bool {
+	for _, s := range validStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // VolumeDetails holds the output data for a volume
 type VolumeDetails struct {
 	Name        string
@@ -64,9 +141,57 @@ type VolumeDetails struct {
 	Size        int
 	VolumeType  string
 	ProjectName string
-	AttachedTo  string
+	AttachedTo  string // Server names, comma-separated
+	Attachments []Attachment
 	WWN         string
 	ImageName   string
+	// AvailabilityZone and BackendHost (os-vol-host-attr:host, admin only) are "n/a" when
+	// Cinder does not report them
+	AvailabilityZone string
+	BackendHost      string
+}
+
+// notAvailable stands for a volume attribute Cinder did not report
+const notAvailable = "n/a"
+
+// imageUnknown is the image name of a volume whose image lookup failed; such a volume may back
+// an image, so --not-associated leaves it out
+const imageUnknown = "unknown"
+
+// imageLookupFailed is the image dependent of a volume whose images could not be checked
+const imageLookupFailed = "unknown (lookup failed)"
+
+// Attachment is one attachment of a volume; a multi-attach volume has several
+type Attachment struct {
+	ServerID   string     `json:"server_id"`
+	ServerName string     `json:"server_name"`
+	Device     string     `json:"device"`                // As Nova reports it, e.g. /dev/vdb
+	AttachedAt *time.Time `json:"attached_at,omitempty"` // UTC; omitted when Cinder has no time
+}
+
+// String formats the attachment as "server (device, attached 2024-01-02)", leaving out what
+// Cinder does not report
+func (a Attachment) String() string {
+	var details []string
+	if a.Device != "" {
+		details = append(details, a.Device)
+	}
+	if a.AttachedAt != nil {
+		details = append(details, "attached "+a.AttachedAt.Format("2006-01-02"))
+	}
+	if len(details) == 0 {
+		return a.ServerName
+	}
+	return fmt.Sprintf("%s (%s)", a.ServerName, strings.Join(details, ", "))
+}
+
+// formatAttachments joins the attachments for the Attached to column of --long tables
+func formatAttachments(attachments []Attachment) string {
+	parts := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		parts = append(parts, a.String())
+	}
+	return strings.Join(parts, ", ")
 }
 
 // processVolumes processes volumes concurrently and assigns image names
@@ -86,6 +211,7 @@ func processVolumes(ctx context.Context, authClient *auth.Client, volumeClient,
 				VolumeType: vol.VolumeType,
 				WWN:        vol.Metadata["volume_wwn"],
 			}
+			detail.AvailabilityZone, detail.BackendHost = orNotAvailable(vol.AvailabilityZone), orNotAvailable(vol.Host)
 
 			// Assign project name
 			if projectName != "" {
@@ -102,12 +228,19 @@ func processVolumes(ctx context.Context, authClient *auth.Client, volumeClient,
 
 			// Format Attached to
 			var attachedTo []string
+			detail.Attachments = []Attachment{}
 			for _, attachment := range vol.Attachments {
 				serverName, err := getServerName(ctx, authClient, attachment.ServerID, serverNameCache)
 				if err != nil || serverName == "" {
 					continue
 				}
 				attachedTo = append(attachedTo, serverName)
+				a := Attachment{ServerID: attachment.ServerID, ServerName: serverName, Device: attachment.Device}
+				if !attachment.AttachedAt.IsZero() {
+					attachedAt := attachment.AttachedAt.UTC()
+					a.AttachedAt = &attachedAt
+				}
+				detail.Attachments = append(detail.Attachments, a)
 			}
 			detail.AttachedTo = strings.Join(attachedTo, ", ")
 
@@ -115,7 +248,8 @@ func processVolumes(ctx context.Context, authClient *auth.Client, volumeClient,
 			if imageClient != nil {
 				imageName, err := getAssociatedImageName(ctx, imageClient, vol.ID, &imageCache)
 				if err != nil {
-					log.Warnf("Failed to get image for volume %s: %v", vol.ID, err)
+					log.Warnf("Failed to get image for volume %s: %v, reporting its image as %s", vol.ID, err, imageUnknown)
+					imageName = imageUnknown
 				}
 				detail.ImageName = imageName
 			} else {
@@ -164,6 +298,12 @@ func getServerName(ctx context.Context, authClient *auth.Client, serverID string
 	return server.Name, nil
 }
 
+// associatingImageStatus lists the image statuses whose block_device_mapping counts as using a volume
+var associatingImageStatus = map[images.ImageStatus]bool{
+	images.ImageStatusActive: true,
+	images.ImageStatusQueued: true,
+}
+
 // getAssociatedImageName finds the image associated with a volume by checking image block_device_mapping
 func getAssociatedImageName(ctx context.Context, imageClient *gophercloud.ServiceClient, volumeID string, imageCache *sync.Map) (string, error) {
 	if cached, exists := imageCache.Load(volumeID); exists {
@@ -185,8 +325,13 @@ func getAssociatedImageName(ctx context.Context, imageClient *gophercloud.Servic
 		return "N/A", errors.Wrap(err, "failed to list images")
 	}
 
-	// Check each image's block_device_mapping for the volume ID
+	// Check each image's block_device_mapping for the volume ID. Deleted, killed and other
+	// unusable images no longer keep their volumes in use.
 	for _, img := range imageList {
+		if !associatingImageStatus[img.Status] {
+			log.Debugf("Ignoring block_device_mapping of image %s in status %s", img.ID, img.Status)
+			continue
+		}
 		bdmStr, exists := img.Properties["block_device_mapping"]
 		if !exists {
 			continue
@@ -209,95 +354,192 @@ func getAssociatedImageName(ctx context.Context, imageClient *gophercloud.Servic
 	return "N/A", nil
 }
 
-func listVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, projectName, outputFormat string, long, notAssociated bool) error {
-	if projectName == "" {
-		return fmt.Errorf("project name must be provided via --project or OS_PROJECT_NAME")
+// volumeFilter selects volumes for list and list-all; all set conditions must hold
+type volumeFilter struct {
+	NotAssociated  bool   // No image and no attachment
+	AttachedOnly   bool   // Attached to at least one server
+	UnattachedOnly bool   // Attached to no server
+	SkipImageCheck bool   // Accept NotAssociated without image names when the image client fails
+	AZ             string // Availability zone, matched exactly ignoring case
+	Backend        string // Substring of the backend host, matched ignoring case
+}
+
+func (cfg Config) listFilter() volumeFilter {
+	return volumeFilter{NotAssociated: cfg.NotAssociated, AttachedOnly: cfg.AttachedOnly, UnattachedOnly: cfg.UnattachedOnly, SkipImageCheck: cfg.SkipImageCheck,
+		AZ: cfg.AZ, Backend: cfg.Backend}
+}
+
+// orNotAvailable returns s, or notAvailable when it is empty
+func orNotAvailable(s string) string {
+	if s == "" {
+		return notAvailable
 	}
+	return s
+}
 
-	// Get project ID
-	projectID, err := getProjectID(ctx, authClient, projectName)
-	if err != nil {
-		return err
+// newListImageClient returns the image client of list and list-all, or nil when image names are
+// not needed. Without image names every volume looks unused by images, so --not-associated would
+// report image-backed volumes as orphans; a client that cannot be created therefore fails the
+// command unless --skip-image-check is given. For other listings the image name is only
+// informational and the listing goes on without it.
+func newListImageClient(authClient *auth.Client, outputFormat string, long bool, filter volumeFilter) (*gophercloud.ServiceClient, error) {
+	if !long && outputFormat != "json" && outputFormat != "yaml" && !filter.NotAssociated {
+		return nil, nil
 	}
+	imageClient, err := auth.NewImageV2(authClient)
+	if err == nil {
+		return imageClient, nil
+	}
+	if filter.NotAssociated && !filter.SkipImageCheck {
+		return nil, errors.Wrap(err, "failed to initialize image client, which --not-associated needs to keep image-backed volumes out (use --skip-image-check to list without it)")
+	}
+	if filter.NotAssociated {
+		log.Warnf("Failed to initialize image client: %v, --skip-image-check given, so image-backed volumes may be listed as not associated", err)
+	} else {
+		log.Warnf("Failed to initialize image client: %v, proceeding without image names", err)
+	}
+	return nil, nil
+}
 
-	// Initialize image client (only needed if long=true, JSON output, or notAssociated=true)
-	var imageClient *gophercloud.ServiceClient
-	if long || strings.ToLower(outputFormat) == "json" || notAssociated {
-		imageClient, err = auth.NewImageV2(authClient)
-		if err != nil {
-			log.Warnf("Failed to initialize image client: %v, proceeding without image names", err)
-		}
+// association names what keeps the volume out of --not-associated: "image:<name>",
+// "server:<names>", "unknown" when the image lookup failed, or "none"
+func (d VolumeDetails) association() string {
+	switch {
+	case d.ImageName == imageUnknown:
+		return imageUnknown
+	case d.ImageName != "" && d.ImageName != "N/A":
+		return "image:" + d.ImageName
+	case d.AttachedTo != "":
+		return "server:" + d.AttachedTo
 	}
+	return "none"
+}
 
-	// List volumes for the specific project
-	listOpts := volumes.ListOpts{
-		TenantID: projectID,
+// apply returns the volumes matching f
+func (f volumeFilter) apply(details []VolumeDetails) []VolumeDetails {
+	if !f.NotAssociated && !f.AttachedOnly && !f.UnattachedOnly && f.AZ == "" && f.Backend == "" {
+		return details
 	}
-	var projectVolumes []volumes.Volume
-	err = volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-		volumeList, err := volumes.ExtractVolumes(page)
-		if err != nil {
-			return false, err
+	var filtered []VolumeDetails
+	backendReported := false
+	for _, detail := range details {
+		if detail.BackendHost != notAvailable {
+			backendReported = true
 		}
-		projectVolumes = append(projectVolumes, volumeList...)
-		return true, nil
-	})
+		if f.AZ != "" && !strings.EqualFold(detail.AvailabilityZone, f.AZ) {
+			continue
+		}
+		if f.Backend != "" && (detail.BackendHost == notAvailable || !strings.Contains(strings.ToLower(detail.BackendHost), strings.ToLower(f.Backend))) {
+			continue
+		}
+		attached := detail.AttachedTo != ""
+		// A volume with an unknown image is left out too, as it may back an image
+		if f.NotAssociated && (detail.ImageName != "N/A" || attached) {
+			continue
+		}
+		if f.AttachedOnly && !attached {
+			continue
+		}
+		if f.UnattachedOnly && attached {
+			continue
+		}
+		filtered = append(filtered, detail)
+	}
+	if f.Backend != "" && len(details) > 0 && !backendReported {
+		log.Warnf("Cinder reported no backend host for any volume; --backend needs the admin role")
+	}
+	log.Debugf("Filter %+v kept %d of %d volumes", f, len(filtered), len(details))
+	return filtered
+}
+
+// splitProjects splits a comma-separated --project value, dropping empty names
+func splitProjects(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// listVolumes lists the volumes of one or more projects in one table. With several projects,
+// up to projectListConcurrency are listed at once; a project that cannot be listed is
+// reported on stderr and skipped, or fails the command when strict is set.
+func listVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, projectNames []string, outputFormat string, long bool, filter volumeFilter, summary, showAssociation, strict bool) error {
+	if len(projectNames) == 0 {
+		return fmt.Errorf("project name must be provided via --project or OS_PROJECT_NAME")
+	}
+
+	imageClient, err := newListImageClient(authClient, outputFormat, long, filter)
 	if err != nil {
-		return errors.Wrapf(err, "failed to list volumes for project %s", projectName)
+		return err
 	}
 
-	// Cache server names
+	// Cache server names across projects
 	serverNameCache := sync.Map{}
 
-	// Process volumes concurrently
-	volumeDetails := processVolumes(ctx, authClient, volumeClient, imageClient, projectVolumes, projectName, nil, &serverNameCache)
-
-	// Filter for unassociated volumes if requested
-	if notAssociated {
-		var filteredDetails []VolumeDetails
-		for _, detail := range volumeDetails {
-			if detail.ImageName == "N/A" && detail.AttachedTo == "" {
-				filteredDetails = append(filteredDetails, detail)
-			}
+	perProject := make([][]VolumeDetails, len(projectNames))
+	errs := make([]error, len(projectNames))
+	if len(projectNames) == 1 {
+		perProject[0], errs[0] = fetchProjectVolumes(ctx, authClient, volumeClient, imageClient, projectNames[0], &serverNameCache)
+		if errs[0] != nil {
+			return errs[0]
 		}
-		volumeDetails = filteredDetails
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, projectListConcurrency)
+		for i, name := range projectNames {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				perProject[i], errs[i] = fetchProjectVolumes(ctx, authClient, volumeClient, imageClient, name, &serverNameCache)
+			}(i, name)
+		}
+		wg.Wait()
 	}
 
-	// Define output structs
-	type volumeOutputStandard struct {
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		Size        int    `json:"size"`
-		VolumeType  string `json:"volume_type"`
-		ProjectName string `json:"project_name"`
-		ImageName   string `json:"image_name"`
+	var failed []string
+	var volumeDetails []VolumeDetails
+	for i, name := range projectNames {
+		if errs[i] != nil {
+			failed = append(failed, name)
+			fmt.Fprintf(os.Stderr, "Skipping project %s: %v\n", name, errs[i])
+			continue
+		}
+		volumeDetails = append(volumeDetails, perProject[i]...)
 	}
-	type volumeOutputLong struct {
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		Size        int    `json:"size"`
-		VolumeType  string `json:"volume_type"`
-		ProjectName string `json:"project_name"`
-		AttachedTo  string `json:"attached_to"`
-		WWN         string `json:"wwn"`
-		ImageName   string `json:"image_name"`
+	if len(failed) > 0 && (strict || len(failed) == len(projectNames)) {
+		return fmt.Errorf("failed to list volumes for %d of %d projects: %s", len(failed), len(projectNames), strings.Join(failed, ", "))
 	}
 
-	var outputStandard []volumeOutputStandard
-	var outputLong []volumeOutputLong
+	volumeDetails = filter.apply(volumeDetails)
+
+	// Empty rather than nil so that an empty listing is [] in JSON, also inside --summary output
+	outputStandard := []volumeOutputStandard{}
+	outputLong := []volumeOutputLong{}
 
 	for _, detail := range volumeDetails {
 		if long {
-			outputLong = append(outputLong, volumeOutputLong{
-				Name:        detail.Name,
-				Status:      detail.Status,
-				Size:        detail.Size,
-				VolumeType:  detail.VolumeType,
-				ProjectName: detail.ProjectName,
-				AttachedTo:  detail.AttachedTo,
-				WWN:         detail.WWN,
-				ImageName:   detail.ImageName,
-			})
+			row := volumeOutputLong{
+				Name:             detail.Name,
+				Status:           detail.Status,
+				Size:             detail.Size,
+				VolumeType:       detail.VolumeType,
+				ProjectName:      detail.ProjectName,
+				AttachedTo:       detail.AttachedTo,
+				Attachments:      detail.Attachments,
+				WWN:              detail.WWN,
+				ImageName:        detail.ImageName,
+				AvailabilityZone: detail.AvailabilityZone,
+				BackendHost:      detail.BackendHost,
+			}
+			if showAssociation {
+				row.Association = detail.association()
+			}
+			outputLong = append(outputLong, row)
 		} else {
 			outputStandard = append(outputStandard, volumeOutputStandard{
 				Name:        detail.Name,
@@ -310,47 +552,171 @@ func listVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gop
 		}
 	}
 
-	if strings.ToLower(outputFormat) == "json" {
-		if long {
-			data, err := json.MarshalIndent(outputLong, "", "  ")
-			if err != nil {
-				return errors.Wrap(err, "failed to marshal JSON")
-			}
-			fmt.Println(string(data))
-		} else {
-			data, err := json.MarshalIndent(outputStandard, "", "  ")
-			if err != nil {
-				return errors.Wrap(err, "failed to marshal JSON")
+	var totals *volumeTotals
+	if summary {
+		totals = sumVolumes(volumeDetails, len(projectNames) > 1)
+	}
+	return printVolumes(outputStandard, outputLong, outputFormat, long, showAssociation, totals, noVolumesMessage(projectNames))
+}
+
+// noVolumesMessage is the table message of a listing of projectNames without volumes
+func noVolumesMessage(projectNames []string) string {
+	if len(projectNames) > 1 {
+		return fmt.Sprintf("No volumes found in projects %s.", strings.Join(projectNames, ", "))
+	}
+	return fmt.Sprintf("No volumes found in project %s.", projectNames[0])
+}
+
+// fetchProjectVolumes resolves projectName and returns the details of its volumes
+func fetchProjectVolumes(ctx context.Context, authClient *auth.Client, volumeClient, imageClient *gophercloud.ServiceClient, projectName string, serverNameCache *sync.Map) ([]VolumeDetails, error) {
+	projectID, err := getProjectID(ctx, authClient, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := volumes.ListOpts{
+		TenantID: projectID,
+	}
+	var projectVolumes []volumes.Volume
+	err = volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		volumeList, err := volumes.ExtractVolumes(page)
+		if err != nil {
+			return false, err
+		}
+		projectVolumes = append(projectVolumes, volumeList...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list volumes for project %s", projectName)
+	}
+	log.Debugf("Fetched %d volumes for project %s", len(projectVolumes), projectName)
+
+	// Process volumes concurrently
+	return processVolumes(ctx, authClient, volumeClient, imageClient, projectVolumes, projectName, nil, serverNameCache), nil
+}
+
+// volumeOutputStandard is one row of the volume listing
+type volumeOutputStandard struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Size        int    `json:"size"`
+	VolumeType  string `json:"volume_type"`
+	ProjectName string `json:"project_name"`
+	ImageName   string `json:"image_name"`
+}
+
+// volumeOutputLong is one row of the --long volume listing
+type volumeOutputLong struct {
+	Name        string       `json:"name"`
+	Status      string       `json:"status"`
+	Size        int          `json:"size"`
+	VolumeType  string       `json:"volume_type"`
+	ProjectName string       `json:"project_name"`
+	AttachedTo  string       `json:"attached_to"` // Server names; details are in Attachments
+	Attachments []Attachment `json:"attachments"`
+	WWN         string       `json:"wwn"`
+	ImageName   string       `json:"image_name"`
+	// Backend host is admin only; both are "n/a" when Cinder does not report them
+	AvailabilityZone string `json:"availability_zone"`
+	BackendHost      string `json:"backend_host"`
+	Association      string `json:"association,omitempty"` // --show-association only
+}
+
+// volumeTotals is the --summary of a volume listing
+type volumeTotals struct {
+	Count    int             `json:"count"`
+	SizeGB   int             `json:"size_gb"`
+	Projects []projectTotals `json:"projects,omitempty"` // list-all only
+}
+
+// projectTotals is the volume count and size of one project in a list-all --summary
+type projectTotals struct {
+	Project string `json:"project"`
+	Count   int    `json:"count"`
+	SizeGB  int    `json:"size_gb"`
+}
+
+// sumVolumes totals the count and size of details, per project as well when perProject is set
+func sumVolumes(details []VolumeDetails, perProject bool) *volumeTotals {
+	totals := &volumeTotals{}
+	byProject := make(map[string]*projectTotals)
+	for _, d := range details {
+		totals.Count++
+		totals.SizeGB += d.Size
+		if !perProject {
+			continue
+		}
+		pt, ok := byProject[d.ProjectName]
+		if !ok {
+			pt = &projectTotals{Project: d.ProjectName}
+			byProject[d.ProjectName] = pt
+		}
+		pt.Count++
+		pt.SizeGB += d.Size
+	}
+	for _, pt := range byProject {
+		totals.Projects = append(totals.Projects, *pt)
+	}
+	sort.Slice(totals.Projects, func(i, j int) bool { return totals.Projects[i].Project < totals.Projects[j].Project })
+	return totals
+}
+
+// printVolumes prints the standard or, with long, the extended volume listing, followed by
+// totals when they are given. Tables print empty instead of an empty listing.
+func printVolumes(outputStandard []volumeOutputStandard, outputLong []volumeOutputLong, outputFormat string, long, showAssociation bool, totals *volumeTotals, empty string) error {
+	result := &output.Result{Empty: empty}
+	if long {
+		result.Headers = []string{"Name", "Status", "Size", "Volume Type", "Project Name", "Attached to", "WWN", "Image Name", "AZ", "Backend Host"}
+		result.Data = outputLong
+		if showAssociation {
+			result.Headers = append(result.Headers, "Association")
+		}
+		for _, v := range outputLong {
+			row := []interface{}{v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName, formatAttachments(v.Attachments), v.WWN, v.ImageName, v.AvailabilityZone, v.BackendHost}
+			if showAssociation {
+				row = append(row, v.Association)
 			}
-			fmt.Println(string(data))
+			result.AddRow(row...)
 		}
 	} else {
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		result.Headers = []string{"Name", "Status", "Size", "Volume Type", "Project Name"}
+		result.Data = outputStandard
+		for _, v := range outputStandard {
+			result.AddRow(v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName)
+		}
+	}
+	if totals != nil {
+		var volumes interface{} = append([]volumeOutputStandard{}, outputStandard...)
 		if long {
-			fmt.Fprintln(w, "Name\tStatus\tSize\tVolume Type\tProject Name\tAttached to\tWWN\tImage Name")
-			for _, v := range outputLong {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName, v.AttachedTo, v.WWN, v.ImageName)
+			volumes = append([]volumeOutputLong{}, outputLong...)
+		}
+		result.Data = struct {
+			Volumes interface{}   `json:"volumes"`
+			Totals  *volumeTotals `json:"totals"`
+		}{volumes, totals}
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print volumes")
+	}
+	if totals != nil && outputFormat == "table" {
+		fmt.Printf("\nTotal: %d volumes, %d GB\n", totals.Count, totals.SizeGB)
+		if len(totals.Projects) > 0 {
+			projects := &output.Result{Headers: []string{"Project", "Volumes", "Size (GB)"}, Data: totals.Projects}
+			for _, pt := range totals.Projects {
+				projects.AddRow(pt.Project, pt.Count, pt.SizeGB)
 			}
-		} else {
-			fmt.Fprintln(w, "Name\tStatus\tSize\tVolume Type\tProject Name")
-			for _, v := range outputStandard {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName)
+			if err := output.Print(outputFormat, projects); err != nil {
+				return errors.Wrap(err, "failed to print volume totals")
 			}
 		}
-		w.Flush()
 	}
 	return nil
 }
 
-func listAllVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient, authClient *auth.Client, outputFormat string, long, notAssociated bool) error {
-	// Initialize image client (only needed if long=true, JSON output, or notAssociated=true)
-	var imageClient *gophercloud.ServiceClient
-	if long || strings.ToLower(outputFormat) == "json" || notAssociated {
-		var err error
-		imageClient, err = auth.NewImageV2(authClient)
-		if err != nil {
-			log.Warnf("Failed to initialize image client: %v, proceeding without image names", err)
-		}
+func listAllVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient, authClient *auth.Client, outputFormat string, long bool, filter volumeFilter, maxResults int, summary, showAssociation bool, groupBy string) error {
+	imageClient, err := newListImageClient(authClient, outputFormat, long, filter)
+	if err != nil {
+		return err
 	}
 
 	// List all volumes with all_tenants=1
@@ -358,17 +724,26 @@ func listAllVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient
 		AllTenants: true,
 	}
 	var allVolumes []volumes.Volume
-	err := volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+	truncated := false
+	err = volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
 		volumeList, err := volumes.ExtractVolumes(page)
 		if err != nil {
 			return false, err
 		}
 		allVolumes = append(allVolumes, volumeList...)
+		if maxResults > 0 && len(allVolumes) >= maxResults {
+			allVolumes = allVolumes[:maxResults]
+			truncated = true
+			return false, nil
+		}
 		return true, nil
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to list volumes")
 	}
+	if truncated {
+		log.Warnf("Stopped listing after %d volumes (--max-results); results may be truncated", maxResults)
+	}
 
 	// Cache project names
 	projectNameCache := make(map[string]string)
@@ -397,52 +772,34 @@ func listAllVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient
 	// Process volumes concurrently
 	volumeDetails := processVolumes(ctx, authClient, volumeClient, imageClient, allVolumes, "", projectNameCache, &serverNameCache)
 
-	// Filter for unassociated volumes if requested
-	if notAssociated {
-		var filteredDetails []VolumeDetails
-		for _, detail := range volumeDetails {
-			if detail.ImageName == "N/A" && detail.AttachedTo == "" {
-				filteredDetails = append(filteredDetails, detail)
-			}
-		}
-		volumeDetails = filteredDetails
-	}
-
-	// Define output structs
-	type volumeOutputStandard struct {
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		Size        int    `json:"size"`
-		VolumeType  string `json:"volume_type"`
-		ProjectName string `json:"project_name"`
-		ImageName   string `json:"image_name"`
-	}
-	type volumeOutputLong struct {
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		Size        int    `json:"size"`
-		VolumeType  string `json:"volume_type"`
-		ProjectName string `json:"project_name"`
-		AttachedTo  string `json:"attached_to"`
-		WWN         string `json:"wwn"`
-		ImageName   string `json:"image_name"`
+	volumeDetails = filter.apply(volumeDetails)
+	if groupBy == "project" {
+		return printProjectGroups(groupByProject(volumeDetails), outputFormat)
 	}
 
-	var outputStandard []volumeOutputStandard
-	var outputLong []volumeOutputLong
+	// Empty rather than nil so that an empty listing is [] in JSON, also inside --summary output
+	outputStandard := []volumeOutputStandard{}
+	outputLong := []volumeOutputLong{}
 
 	for _, detail := range volumeDetails {
 		if long {
-			outputLong = append(outputLong, volumeOutputLong{
-				Name:        detail.Name,
-				Status:      detail.Status,
-				Size:        detail.Size,
-				VolumeType:  detail.VolumeType,
-				ProjectName: detail.ProjectName,
-				AttachedTo:  detail.AttachedTo,
-				WWN:         detail.WWN,
-				ImageName:   detail.ImageName,
-			})
+			row := volumeOutputLong{
+				Name:             detail.Name,
+				Status:           detail.Status,
+				Size:             detail.Size,
+				VolumeType:       detail.VolumeType,
+				ProjectName:      detail.ProjectName,
+				AttachedTo:       detail.AttachedTo,
+				Attachments:      detail.Attachments,
+				WWN:              detail.WWN,
+				ImageName:        detail.ImageName,
+				AvailabilityZone: detail.AvailabilityZone,
+				BackendHost:      detail.BackendHost,
+			}
+			if showAssociation {
+				row.Association = detail.association()
+			}
+			outputLong = append(outputLong, row)
 		} else {
 			outputStandard = append(outputStandard, volumeOutputStandard{
 				Name:        detail.Name,
@@ -455,113 +812,190 @@ func listAllVolumes(ctx context.Context, volumeClient *gophercloud.ServiceClient
 		}
 	}
 
-	if strings.ToLower(outputFormat) == "json" {
-		if long {
-			data, err := json.MarshalIndent(outputLong, "", "  ")
-			if err != nil {
-				return errors.Wrap(err, "failed to marshal JSON")
-			}
-			fmt.Println(string(data))
-		} else {
-			data, err := json.MarshalIndent(outputStandard, "", "  ")
-			if err != nil {
-				return errors.Wrap(err, "failed to marshal JSON")
-			}
-			fmt.Println(string(data))
-		}
-	} else {
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		if long {
-			fmt.Fprintln(w, "Name\tStatus\tSize\tVolume Type\tProject Name\tAttached to\tWWN\tImage Name")
-			for _, v := range outputLong {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName, v.AttachedTo, v.WWN, v.ImageName)
-			}
-		} else {
-			fmt.Fprintln(w, "Name\tStatus\tSize\tVolume Type\tProject Name")
-			for _, v := range outputStandard {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", v.Name, v.Status, v.Size, v.VolumeType, v.ProjectName)
-			}
-		}
-		w.Flush()
+	var totals *volumeTotals
+	if summary {
+		totals = sumVolumes(volumeDetails, true)
 	}
-	return nil
+	return printVolumes(outputStandard, outputLong, outputFormat, long, showAssociation, totals, "No volumes found.")
 }
 
-func changeVolumeStatus(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, volumeNames, projectName, status string) error {
+// changeVolumeStatus forces the status of the named volumes with os-reset_status. Cinder does not
+// check the real state of a volume, so the change needs a typed confirmation or cfg.Yes; with
+// cfg.DryRun the current and target status are only printed.
+func changeVolumeStatus(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, cfg Config) error {
+	if !cfg.DryRun && !cfg.Yes && cfg.OutputFormat != "table" {
+		return fmt.Errorf("--output=%s requires --yes for change-status; confirmation prompts are only shown with table output", cfg.OutputFormat)
+	}
 	// Get project ID
-	projectID, err := getProjectID(ctx, authClient, projectName)
+	projectID, err := getProjectID(ctx, authClient, cfg.ProjectName)
 	if err != nil {
 		return err
 	}
+	if cfg.Force && !isValidStatus(cfg.Status) {
+		log.Warnf("Forcing non-standard status '%s'", cfg.Status)
+	}
 
-	// Split volume names
-	volumeNameList := strings.Split(volumeNames, ",")
-	for _, volumeName := range volumeNameList {
+	// Every volume is looked up first, so the confirmation lists exactly what will change.
+	// With --fail-fast the first volume that is not found stops the lookups.
+	var firstErr error
+	var results []StatusResult
+	var found []int // indexes into results of the volumes that were found
+	for _, volumeName := range strings.Split(cfg.VolumeNames, ",") {
 		volumeName = strings.TrimSpace(volumeName)
 		if volumeName == "" {
 			continue
 		}
-
-		// Find volume by name and project
-		listOpts := volumes.ListOpts{
-			Name:       volumeName,
-			TenantID:   projectID,
-			AllTenants: true,
+		result := StatusResult{VolumeName: volumeName, TargetStatus: cfg.Status}
+		if firstErr != nil {
+			result.Result = "skipped"
+			result.Message = "not run after an earlier failure (--fail-fast)"
+			results = append(results, result)
+			continue
 		}
-		var volumeList []volumes.Volume
-		err = volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-			vols, err := volumes.ExtractVolumes(page)
-			if err != nil {
-				return false, err
-			}
-			volumeList = append(volumeList, vols...)
-			return true, nil
-		})
+		volume, err := findVolume(ctx, volumeClient, volumeName, projectID)
 		if err != nil {
-			return errors.Wrapf(err, "failed to list volumes for name %s", volumeName)
+			return err
 		}
-		if len(volumeList) == 0 {
-			log.Warnf("Volume %s not found in project %s", volumeName, projectName)
+		if volume == nil {
+			log.Debugf("Volume %s not found in project %s", volumeName, cfg.ProjectName)
+			result.Result = "failed"
+			result.Message = fmt.Sprintf("volume not found in project %s", cfg.ProjectName)
+			if cfg.FailFast {
+				firstErr = fmt.Errorf("volume %s not found in project %s", volumeName, cfg.ProjectName)
+			}
+			results = append(results, result)
 			continue
 		}
-		volume := volumeList[0] // Assume first match
+		result.VolumeID = volume.ID
+		result.CurrentStatus = volume.Status
+		found = append(found, len(results))
+		results = append(results, result)
+	}
 
-		// Reset volume status using os-reset_status action
-		resetStatusPayload := map[string]map[string]string{
-			"os-reset_status": {
-				"status": status,
-			},
+	switch {
+	case len(found) == 0:
+	case cfg.DryRun:
+		for _, i := range found {
+			results[i].Result = "dry-run"
+			results[i].Message = fmt.Sprintf("would change status %s -> %s", results[i].CurrentStatus, cfg.Status)
 		}
-		payloadBytes, err := json.Marshal(resetStatusPayload)
-		if err != nil {
-			return errors.Wrapf(err, "failed to marshal os-reset_status payload for volume %s", volumeName)
-		}
-
-		// Send POST request to /v3/{project_id}/volumes/{volume_id}/action
-		_, err = volumeClient.Post(
-			ctx,
-			fmt.Sprintf("%s/volumes/%s/action", volumeClient.ServiceURL(), volume.ID),
-			bytes.NewReader(payloadBytes),
-			nil,
-			&gophercloud.RequestOpts{
-				OkCodes: []int{202},
-			},
-		)
-		if err != nil {
-			log.Warnf("Failed to reset status of volume %s to %s: %v", volumeName, status, err)
-			continue
+	case firstErr != nil:
+		for _, i := range found {
+			results[i].Result = "skipped"
+			results[i].Message = "not run after an earlier failure (--fail-fast)"
+		}
+	case !cfg.Yes && !confirmChangeStatus(results, found, cfg.Status):
+		log.Info("Status change aborted by user; no volumes were changed")
+		for _, i := range found {
+			results[i].Result = "aborted"
+			results[i].Message = "not changed: confirmation declined"
+		}
+	default:
+		for _, i := range found {
+			result := &results[i]
+			if firstErr != nil {
+				result.Result = "skipped"
+				result.Message = "not run after an earlier failure (--fail-fast)"
+				continue
+			}
+			// Reset volume status using os-reset_status action
+			err := volumeAction(ctx, volumeClient, result.VolumeID, map[string]interface{}{
+				"os-reset_status": map[string]string{
+					"status": cfg.Status,
+				},
+			})
+			if err != nil {
+				log.Debugf("Failed to reset status of volume %s to %s: %v", result.VolumeName, cfg.Status, err)
+				result.Result = "failed"
+				result.Message = err.Error()
+				if cfg.FailFast {
+					firstErr = errors.Wrapf(err, "failed to reset status of volume %s", result.VolumeName)
+				}
+				continue
+			}
+			log.Debugf("Reset status of volume %s in project %s to %s", result.VolumeName, cfg.ProjectName, cfg.Status)
+			result.Result = "success"
+			result.Message = fmt.Sprintf("status changed %s -> %s", result.CurrentStatus, cfg.Status)
+		}
+	}
+
+	result := &output.Result{Headers: []string{"Volume", "ID", "Current Status", "Target Status", "Result", "Message"}, Data: results}
+	for _, r := range results {
+		result.AddRow(r.VolumeName, r.VolumeID, r.CurrentStatus, r.TargetStatus, r.Result, r.Message)
+	}
+	if err := output.Print(cfg.OutputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print results")
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Result == "failed" {
+			failed++
 		}
-		log.Infof("Reset status of volume %s in project %s to %s", volumeName, projectName, status)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrStatusChangeFailed, failed, len(results))
 	}
 	return nil
 }
 
-func deleteVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, volumeNames, projectName string) error {
+// confirmChangeStatus lists the volumes whose status will be forced and asks for a typed confirmation
+func confirmChangeStatus(results []StatusResult, found []int, status string) bool {
+	fmt.Printf("About to force the status of %d volume(s) to %s without checking their real state:\n", len(found), status)
+	for _, i := range found {
+		fmt.Printf("  %s (%s): %s -> %s\n", results[i].VolumeName, results[i].VolumeID, results[i].CurrentStatus, status)
+	}
+	fmt.Print("Type 'confirm' to continue: ")
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(strings.TrimSpace(response)) == "confirm"
+}
+
+// volumeAction POSTs an action such as os-reset_status to /v3/{project_id}/volumes/{volume_id}/action
+func volumeAction(ctx context.Context, volumeClient *gophercloud.ServiceClient, volumeID string, payload map[string]interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal action payload for volume %s", volumeID)
+	}
+	_, err = volumeClient.Post(
+		ctx,
+		fmt.Sprintf("%s/volumes/%s/action", volumeClient.ServiceURL(), volumeID),
+		bytes.NewReader(payloadBytes),
+		nil,
+		&gophercloud.RequestOpts{
+			OkCodes: []int{202},
+		},
+	)
+	return err
+}
+
+// VolumeDependent is a server a volume is attached to or an image it backs, which makes
+// deleting the volume unsafe
+type VolumeDependent struct {
+	VolumeName string `json:"volume_name"`
+	VolumeID   string `json:"volume_id"`
+	Kind       string `json:"kind"` // server or image
+	Name       string `json:"name"`
+}
+
+// deleteVolumes deletes the named volumes, warning about and skipping those it cannot find or
+// delete. Volumes that are attached or back an image are refused and their dependents listed;
+// delete --force handles those. With failFast the first such volume cancels the rest and its
+// error is returned.
+func deleteVolumes(ctx context.Context, authClient *auth.Client, volumeClient *gophercloud.ServiceClient, volumeNames, projectName, outputFormat string, failFast bool) error {
 	// Get project ID
 	projectID, err := getProjectID(ctx, authClient, projectName)
 	if err != nil {
 		return err
 	}
+	imageClient, err := auth.NewImageV2(authClient)
+	if err != nil {
+		log.Warnf("Failed to initialize image client: %v, not deleting volumes that may back an image", err)
+	}
+	var serverNameCache, imageCache sync.Map
+	var refused []VolumeDependent
 
 	// Split volume names
 	volumeNameList := strings.Split(volumeNames, ",")
@@ -571,39 +1005,108 @@ func deleteVolumes(ctx context.Context, authClient *auth.Client, volumeClient *g
 			continue
 		}
 
-		// Find volume by name and project
-		listOpts := volumes.ListOpts{
-			Name:       volumeName,
-			TenantID:   projectID,
-			AllTenants: true,
-		}
-		var volumeList []volumes.Volume
-		err = volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
-			vols, err := volumes.ExtractVolumes(page)
-			if err != nil {
-				return false, err
-			}
-			volumeList = append(volumeList, vols...)
-			return true, nil
-		})
+		volume, err := findVolume(ctx, volumeClient, volumeName, projectID)
 		if err != nil {
-			return errors.Wrapf(err, "failed to list volumes for name %s", volumeName)
+			return err
 		}
-		if len(volumeList) == 0 {
+		if volume == nil {
+			if failFast {
+				return fmt.Errorf("volume %s not found in project %s", volumeName, projectName)
+			}
 			log.Warnf("Volume %s not found in project %s", volumeName, projectName)
 			continue
 		}
-		volume := volumeList[0] // Assume first match
+
+		dependents := volumeDependents(ctx, authClient, imageClient, volume, &serverNameCache, &imageCache)
+		if len(dependents) > 0 {
+			refused = append(refused, dependents...)
+			if failFast {
+				break
+			}
+			log.Warnf("Not deleting volume %s: it has %d dependent(s)", volumeName, len(dependents))
+			continue
+		}
 
 		// Delete volume
 		err = volumes.Delete(ctx, volumeClient, volume.ID, volumes.DeleteOpts{}).ExtractErr()
 		if err != nil {
+			if failFast {
+				return errors.Wrapf(err, "failed to delete volume %s", volumeName)
+			}
 			log.Warnf("Failed to delete volume %s: %v", volumeName, err)
 			continue
 		}
 		log.Infof("Deleted volume %s in project %s", volumeName, projectName)
 	}
-	return nil
+	if len(refused) == 0 {
+		return nil
+	}
+
+	result := &output.Result{
+		Headers: []string{"Volume", "Volume ID", "Dependent", "Name"},
+		Data:    refused,
+	}
+	volumeCount := make(map[string]bool)
+	for _, d := range refused {
+		result.AddRow(d.VolumeName, d.VolumeID, d.Kind, d.Name)
+		volumeCount[d.VolumeID] = true
+	}
+	if outputFormat == "table" {
+		fmt.Println("Volumes not deleted because they are still in use:")
+	}
+	if err := output.Print(outputFormat, result); err != nil {
+		return errors.Wrap(err, "failed to print volume dependents")
+	}
+	return fmt.Errorf("refused to delete %d volume(s) that are attached or may back an image; detach them or use --force", len(volumeCount))
+}
+
+// volumeDependents lists the servers vol is attached to and the image whose
+// block_device_mapping uses it. When the images cannot be checked, without an image client or
+// because the lookup failed, an image named imageLookupFailed is listed, as vol may back one.
+func volumeDependents(ctx context.Context, authClient *auth.Client, imageClient *gophercloud.ServiceClient, vol *volumes.Volume, serverNameCache, imageCache *sync.Map) []VolumeDependent {
+	var dependents []VolumeDependent
+	for _, att := range vol.Attachments {
+		serverName, _ := getServerName(ctx, authClient, att.ServerID, serverNameCache)
+		dependents = append(dependents, VolumeDependent{VolumeName: vol.Name, VolumeID: vol.ID, Kind: "server", Name: serverName})
+	}
+	if imageClient == nil {
+		return append(dependents, VolumeDependent{VolumeName: vol.Name, VolumeID: vol.ID, Kind: "image", Name: imageLookupFailed})
+	}
+	imageName, err := getAssociatedImageName(ctx, imageClient, vol.ID, imageCache)
+	if err != nil {
+		log.Warnf("Failed to check images using volume %s: %v", vol.ID, err)
+		imageName = imageLookupFailed
+	}
+	if imageName != "N/A" {
+		dependents = append(dependents, VolumeDependent{VolumeName: vol.Name, VolumeID: vol.ID, Kind: "image", Name: imageName})
+	}
+	return dependents
+}
+
+// findVolume returns the volume named volumeName in the project, or nil when there is none.
+// With several volumes of that name the first is used.
+func findVolume(ctx context.Context, volumeClient *gophercloud.ServiceClient, volumeName, projectID string) (*volumes.Volume, error) {
+	listOpts := volumes.ListOpts{
+		Name:       volumeName,
+		TenantID:   projectID,
+		AllTenants: true,
+	}
+	var volumeList []volumes.Volume
+	err := volumes.List(volumeClient, listOpts).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		vols, err := volumes.ExtractVolumes(page)
+		if err != nil {
+			return false, err
+		}
+		volumeList = append(volumeList, vols...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list volumes for name %s", volumeName)
+	}
+	if len(volumeList) == 0 {
+		return nil, nil
+	}
+	return &volumeList[0], nil
 }
 
 func getProjectID(ctx context.Context, authClient *auth.Client, projectName string) (string, error) {
@@ -611,7 +1114,7 @@ func getProjectID(ctx context.Context, authClient *auth.Client, projectName stri
 
 	// Try authenticated project's ID if it matches projectName
 	if strings.EqualFold(projectName, os.Getenv("OS_PROJECT_NAME")) {
-		ao, err := openstack.AuthOptionsFromEnv()
+		ao, err := auth.AuthOptionsFromEnv()
 		if err == nil && ao.TenantName == projectName {
 			log.Debugf("Using authenticated project ID for %s: %s", projectName, ao.TenantID)
 			return ao.TenantID, nil
@@ -669,7 +1172,8 @@ func getProjectID(ctx context.Context, authClient *auth.Client, projectName stri
 	// Fallback: Try default domain explicitly
 	log.Debug("Attempting fallback: querying projects in default domain")
 	domainClient, err := openstack.NewIdentityV3(authClient.Provider, gophercloud.EndpointOpts{
-		Region: os.Getenv("OS_REGION_NAME"),
+		Region:       os.Getenv("OS_REGION_NAME"),
+		Availability: authClient.Availability,
 	})
 	if err == nil {
 		listOpts = projects.ListOpts{
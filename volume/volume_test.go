@@ -0,0 +1,293 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/sudeeshjohn/openstack-tool/auth"
+	"github.com/sudeeshjohn/openstack-tool/internal/testutil"
+	"github.com/sudeeshjohn/openstack-tool/output"
+)
+
+// newFakeGlance lists the given images, each a JSON object, as one page
+func newFakeGlance(t *testing.T, images ...string) *gophercloud.ServiceClient {
+	t.Helper()
+	body := `{"images": [`
+	for i, img := range images {
+		if i > 0 {
+			body += ", "
+		}
+		body += img
+	}
+	body += `]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return &gophercloud.ServiceClient{ProviderClient: &gophercloud.ProviderClient{}, Endpoint: srv.URL + "/v2/"}
+}
+
+// imageJSON is a Glance image in status whose block_device_mapping references volumeID
+func imageJSON(name, status, volumeID string) string {
+	return fmt.Sprintf(`{"id": "id-%s", "name": %q, "status": %q, "block_device_mapping": "[{\"volume_id\": \"%s\"}]"}`,
+		name, name, status, volumeID)
+}
+
+func TestGetAssociatedImageName(t *testing.T) {
+	imageClient := newFakeGlance(t,
+		imageJSON("deleted-image", "deleted", "vol-deleted"),
+		imageJSON("pending-image", "pending_delete", "vol-pending"),
+		imageJSON("killed-image", "killed", "vol-shared"),
+		imageJSON("active-image", "active", "vol-shared"),
+		imageJSON("queued-image", "queued", "vol-queued"),
+	)
+	tests := []struct {
+		volumeID string
+		want     string
+	}{
+		{volumeID: "vol-deleted", want: "N/A"},
+		{volumeID: "vol-pending", want: "N/A"},
+		{volumeID: "vol-shared", want: "active-image"},
+		{volumeID: "vol-queued", want: "queued-image"},
+		{volumeID: "vol-unknown", want: "N/A"},
+	}
+	var imageCache sync.Map
+	for _, tt := range tests {
+		t.Run(tt.volumeID, func(t *testing.T) {
+			got, err := getAssociatedImageName(context.Background(), imageClient, tt.volumeID, &imageCache)
+			if err != nil {
+				t.Fatalf("getAssociatedImageName: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNotAssociatedDeletedImage checks that a volume referenced only by a deleted image is
+// listed by --not-associated and shows no association
+func TestNotAssociatedDeletedImage(t *testing.T) {
+	imageClient := newFakeGlance(t, imageJSON("deleted-image", "deleted", "vol-1"))
+	var imageCache sync.Map
+	imageName, err := getAssociatedImageName(context.Background(), imageClient, "vol-1", &imageCache)
+	if err != nil {
+		t.Fatalf("getAssociatedImageName: %v", err)
+	}
+	detail := VolumeDetails{Name: "data", ImageName: imageName}
+	if got := (volumeFilter{NotAssociated: true}).apply([]VolumeDetails{detail}); len(got) != 1 {
+		t.Errorf("--not-associated dropped the volume of a deleted image")
+	}
+	if got := detail.association(); got != "none" {
+		t.Errorf("got association %q, want none", got)
+	}
+}
+
+// TestNotAssociatedUnknownImage checks that a volume whose image lookup failed is not reported
+// as an orphan by --not-associated
+func TestNotAssociatedUnknownImage(t *testing.T) {
+	detail := VolumeDetails{Name: "data", ImageName: imageUnknown}
+	if got := (volumeFilter{NotAssociated: true}).apply([]VolumeDetails{detail}); len(got) != 0 {
+		t.Errorf("--not-associated listed a volume whose image is unknown")
+	}
+	if got := detail.association(); got != imageUnknown {
+		t.Errorf("got association %q, want %s", got, imageUnknown)
+	}
+}
+
+// TestVolumeDependentsImageCheckFails checks that a volume whose images cannot be checked is
+// refused by delete instead of being treated as unused
+func TestVolumeDependentsImageCheckFails(t *testing.T) {
+	vol := &volumes.Volume{ID: "v1", Name: "data"}
+	var serverNameCache, imageCache sync.Map
+	got := volumeDependents(context.Background(), &auth.Client{}, nil, vol, &serverNameCache, &imageCache)
+	if len(got) != 1 || got[0].Kind != "image" || got[0].Name != imageLookupFailed {
+		t.Errorf("got dependents %+v, want the image %q", got, imageLookupFailed)
+	}
+}
+
+func TestPrintVolumesEmpty(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		long       bool
+		projects   []string
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "json", format: "json", projects: []string{"demo"}, wantStdout: "[]\n"},
+		{name: "json long", format: "json", long: true, projects: []string{"demo"}, wantStdout: "[]\n"},
+		{name: "yaml", format: "yaml", projects: []string{"demo"}, wantStdout: "[]\n"},
+		{name: "table", format: "table", projects: []string{"demo"}, wantStderr: "No volumes found in project demo.\n"},
+		{name: "table of several projects", format: "table", projects: []string{"demo", "prod"}, wantStderr: "No volumes found in projects demo, prod.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			stdout, stderr := testutil.CaptureOutput(t, func() {
+				err = printVolumes(nil, nil, tt.format, tt.long, false, nil, noVolumesMessage(tt.projects))
+			})
+			if err != nil {
+				t.Fatalf("printVolumes: %v", err)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("got stdout %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestPrintVolumesEmptySummaryJSON(t *testing.T) {
+	var err error
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		err = printVolumes(nil, nil, "json", false, false, sumVolumes(nil, false), noVolumesMessage([]string{"demo"}))
+	})
+	if err != nil {
+		t.Fatalf("printVolumes: %v", err)
+	}
+	var got struct {
+		Volumes []json.RawMessage `json:"volumes"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil || got.Volumes == nil {
+		t.Errorf("got %s, want volumes as []", stdout)
+	}
+}
+
+func TestGetAssociatedImageNameCancelledContext(t *testing.T) {
+	imageClient := newFakeGlance(t, imageJSON("active-image", "active", "vol-1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var imageCache sync.Map
+	if _, err := getAssociatedImageName(ctx, imageClient, "vol-1", &imageCache); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if _, cached := imageCache.Load("vol-1"); cached {
+		t.Errorf("a cancelled lookup was cached")
+	}
+}
+
+func TestPrintVolumesProjectTotals(t *testing.T) {
+	details := []VolumeDetails{
+		{Name: "a", Size: 10, ProjectName: "demo"},
+		{Name: "b", Size: 5, ProjectName: "prod"},
+		{Name: "c", Size: 20, ProjectName: "prod"},
+	}
+	var rows []volumeOutputStandard
+	for _, d := range details {
+		rows = append(rows, volumeOutputStandard{Name: d.Name, Size: d.Size, ProjectName: d.ProjectName})
+	}
+	for _, noHeader := range []bool{false, true} {
+		t.Run(fmt.Sprintf("no-header=%v", noHeader), func(t *testing.T) {
+			saved := output.NoHeader
+			output.NoHeader = noHeader
+			defer func() { output.NoHeader = saved }()
+			var err error
+			stdout, _ := testutil.CaptureOutput(t, func() {
+				err = printVolumes(rows, nil, "table", false, false, sumVolumes(details, true), "")
+			})
+			if err != nil {
+				t.Fatalf("printVolumes: %v", err)
+			}
+			_, projects, ok := strings.Cut(stdout, "Total: 3 volumes, 35 GB\n")
+			if !ok {
+				t.Fatalf("no total line in:\n%s", stdout)
+			}
+			lines := strings.Split(strings.TrimSpace(projects), "\n")
+			want := []string{"demo  1  10", "prod  2  25"}
+			if !noHeader {
+				want = append([]string{"Project  Volumes  Size  (GB)"}, want...)
+			}
+			for i := range lines {
+				lines[i] = strings.Join(strings.Fields(lines[i]), "  ")
+			}
+			if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+				t.Errorf("got per-project totals %q, want %q", lines, want)
+			}
+		})
+	}
+}
+
+// newFakeProjectAndVolumes serves project "demo" from a fake Keystone and, from a fake Cinder,
+// the AVAILABLE volume "data-1"; any other volume name is not found
+func newFakeProjectAndVolumes(t *testing.T) (*auth.Client, *gophercloud.ServiceClient) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/v3/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"projects": [{"id": "p1", "name": "demo"}], "links": {}}`)
+	})
+	mux.HandleFunc("/volume/v3/volumes/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("name") != "data-1" {
+			fmt.Fprint(w, `{"volumes": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"volumes": [{"id": "v1", "name": "data-1", "status": "available", "size": 10}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	provider := &gophercloud.ProviderClient{}
+	authClient := &auth.Client{
+		Provider: provider,
+		Identity: &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/identity/v3/"},
+	}
+	return authClient, &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: srv.URL + "/volume/v3/"}
+}
+
+func TestChangeVolumeStatusNotFound(t *testing.T) {
+	t.Setenv("OS_PROJECT_NAME", "")
+	tests := []struct {
+		name        string
+		volumeNames string
+		wantErr     bool
+	}{
+		{name: "all found", volumeNames: "data-1", wantErr: false},
+		{name: "one not found", volumeNames: "data-1,data-2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClient, volumeClient := newFakeProjectAndVolumes(t)
+			cfg := Config{ProjectName: "demo", VolumeNames: tt.volumeNames, Status: "error", DryRun: true, OutputFormat: "json"}
+			var err error
+			stdout, _ := testutil.CaptureOutput(t, func() {
+				err = changeVolumeStatus(context.Background(), authClient, volumeClient, cfg)
+			})
+			if got := errors.Is(err, ErrStatusChangeFailed); got != tt.wantErr {
+				t.Fatalf("got error %v, want ErrStatusChangeFailed: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("changeVolumeStatus: %v", err)
+			}
+			var results []StatusResult
+			if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+				t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+			}
+			if len(results) != len(strings.Split(tt.volumeNames, ",")) || results[0].Result != "dry-run" {
+				t.Errorf("got results %+v, want data-1 as dry-run and one result per volume", results)
+			}
+		})
+	}
+}
+
+// TestReportOrphanSnapshotsDeleteNeedsYes checks that --delete with JSON output is refused
+// without --yes, as the confirmation prompt would be written into the JSON document
+func TestReportOrphanSnapshotsDeleteNeedsYes(t *testing.T) {
+	cfg := Config{AllProjects: true, Delete: true, OutputFormat: "json"}
+	err := reportOrphanSnapshots(context.Background(), nil, nil, cfg)
+	if err == nil || !strings.Contains(err.Error(), "requires --yes") {
+		t.Errorf("got error %v, want --output=json requires --yes", err)
+	}
+}